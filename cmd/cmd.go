@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/services"
 	"altintakip/internal/tui"
 
 	"github.com/joho/godotenv"
@@ -15,16 +20,49 @@ import (
 
 // Execute ana uygulama mantığını çalıştırır
 func Execute() {
-	// Komut satırı parametrelerini kontrol et
+	// İlk parametre alt komutu belirler, boşsa TUI başlatılır
 	args := os.Args[1:]
-	isListMode := false
-	for _, arg := range args {
-		if arg == "list" {
-			isListMode = true
-			break
-		}
+	komut := ""
+	if len(args) > 0 {
+		komut, args = args[0], args[1:]
+	}
+
+	if komut == "help" || komut == "-h" || komut == "--help" {
+		printKullanim(os.Stdout)
+		return
+	}
+
+	calistir, exists := komutlar[komut]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Bilinmeyen komut: %s\n\n", komut)
+		printKullanim(os.Stderr)
+		os.Exit(2)
+	}
+
+	// Veritabanı komut parametreleri ayrıştırıldıktan sonra parametreleriOku ile açılır;
+	// böylece -h ve hatalı parametrede parola sorulmaz, migrasyon ve yedek çalışmaz
+	saltOkunur = saltOkunurKomutlar[komut]
+	kapat := ortamiHazirla()
+	err := calistir(args)
+	kapat()
+
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "HATA: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// saltOkunur çalışan komutun veritabanını değiştirmediğini belirtir
+var saltOkunur bool
 
+// veritabaniAcik veritabanının bu çalıştırmada açılıp açılmadığını belirtir
+var veritabaniAcik bool
+
+// ortamiHazirla env dosyasını ve log çıktısını hazırlar; dönen fonksiyon açılan kaynakları kapatır
+func ortamiHazirla() func() {
 	// Kullanıcı dizininde altintakip klasörünü oluştur
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -59,24 +97,77 @@ func Execute() {
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err == nil {
 		log.SetOutput(logFile)
-		defer logFile.Close()
 	} else {
 		// Log dosyası açılamazsa, log'ları tamamen sustur
 		log.SetOutput(io.Discard)
 	}
 
-	// Veritabanı bağlantısını kur
-	if err := database.Connect(); err != nil {
-		log.Fatalf("Veritabanı bağlantısı kurulamadı: %v", err)
+	// Log seviyesini ayarla (varsayılan: ERROR)
+	if levelName := os.Getenv("LOG_LEVEL"); levelName != "" {
+		level, err := logger.ParseLevel(levelName)
+		if err != nil {
+			logger.Errorf("%v, varsayılan seviye kullanılıyor: %s", err, logger.GetLevel())
+		} else {
+			logger.SetLevel(level)
+		}
+	}
+
+	return func() {
+		if veritabaniAcik {
+			if err := database.Close(); err != nil {
+				logger.Errorf("Veritabanı kapatılamadı: %v", err)
+				fmt.Fprintf(os.Stderr, "HATA: Veritabanı kapatılamadı: %v\n", err)
+			}
+		}
+		if logFile != nil {
+			logFile.Close()
+		}
+	}
+}
+
+// veritabaniniHazirla veritabanına bağlanıp migrasyonları çalıştırır. Salt okunur
+// komutlarda açılış yedeği alınmaz ve eksik günlük özetler tamamlanmaz.
+func veritabaniniHazirla() {
+	if veritabaniAcik {
+		return
+	}
+
+	// Veritabanı bağlantısını kur (şifreli modda önce parola alınır)
+	if err := veritabaninaBaglan(); err != nil {
+		olumcul("Veritabanı bağlantısı kurulamadı: %v", err)
 	}
-	defer database.Close()
 
 	// Veritabanı migrasyonunu çalıştır
 	if err := database.Migrate(); err != nil {
-		log.Fatalf("Veritabanı migrasyonu başarısız: %v", err)
+		olumcul("Veritabanı migrasyonu başarısız: %v", err)
 	}
 
-	// TUI uygulamasını başlat
+	veritabaniAcik = true
+	if saltOkunur {
+		return
+	}
+
+	// Son yedekten sonra değişiklik olduysa açılış yedeği al, eski otomatik yedekleri döndür
+	database.AcilisYedegiAl()
+
+	// Uygulamanın çalışmadığı günlerin portföy özetlerini tamamla
+	if _, err := services.NewPortfoyGecmisiService().TamamlaEksikGunler(time.Now()); err != nil {
+		logger.Errorf("Eksik günlük portföy özetleri tamamlanamadı: %v", err)
+	}
+}
+
+// olumcul hatayı log dosyasına ve stderr'e yazıp uygulamayı sonlandırır
+func olumcul(format string, args ...interface{}) {
+	mesaj := fmt.Sprintf(format, args...)
+	logger.Errorf("%s", mesaj)
+	fmt.Fprintf(os.Stderr, "HATA: %s\n", mesaj)
+	os.Exit(1)
+}
+
+// runTUI terminal arayüzünü başlatır
+func runTUI(isListMode bool) error {
+	veritabaniniHazirla()
+	services.SetDegisiklikKaynagi("tui")
 	app := tui.NewApp()
 	if isListMode {
 		fmt.Printf("Liste modu: Sadece veritabanındaki veriler gösterilecek (fiyat güncellenmeyecek)...\n")
@@ -87,8 +178,9 @@ func Execute() {
 	}
 
 	if err := app.Run(); err != nil {
-		log.Fatalf("TUI uygulaması başlatılamadı: %v", err)
+		return fmt.Errorf("TUI uygulaması başlatılamadı: %w", err)
 	}
+	return nil
 }
 
 // getEnv çevre değişkenini alır, yoksa varsayılan değeri döner
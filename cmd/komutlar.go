@@ -0,0 +1,1242 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"altintakip/internal/api"
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+	"altintakip/internal/services"
+
+	"github.com/shopspring/decimal"
+)
+
+// komutlar alt komut adlarını çalıştırıcılarına eşler; boş ad TUI'yi başlatır
+var komutlar = map[string]func(args []string) error{
+	"":              func(args []string) error { return runTUI(false) },
+	"list":          func(args []string) error { return runTUI(true) },
+	"add":           komutAdd,
+	"ls":            komutLs,
+	"update-prices": komutUpdatePrices,
+	"rm":            komutRm,
+	"summary":       komutSummary,
+	"rapor":         komutRapor,
+	"export":        komutExport,
+	"import":        komutImport,
+	"migrations":    komutMigrations,
+	"restore":       komutRestore,
+	"rekey":         komutRekey,
+	"portfoy":       komutPortfoy,
+	"alarm":         komutAlarm,
+	"cop":           komutCop,
+	"gecmis":        komutGecmis,
+	"serve":         komutServe,
+}
+
+// saltOkunurKomutlar veritabanını değiştirmeyen komutlar; açılışta yedek alınmaz ve eksik günler tamamlanmaz
+var saltOkunurKomutlar = map[string]bool{
+	"ls":         true,
+	"summary":    true,
+	"rapor":      true,
+	"export":     true,
+	"migrations": true,
+	"gecmis":     true,
+}
+
+// printKullanim komut satırı kullanımını yazdırır
+func printKullanim(w io.Writer) {
+	fmt.Fprint(w, `Kullanım: altintakip [komut] [parametreler]
+
+Komutlar:
+  (boş)           Terminal arayüzünü başlatır, fiyatları günceller
+  list            Terminal arayüzünü liste modunda başlatır (fiyat güncellemez)
+  add             Yeni envanter kaydı ekler
+                  --kod C --miktar 2 --fiyat 9500 [--tarih 01.03.2025] [--birim adet]
+                  [--tur Altın --cins Çeyrek] [--hediye] [--offline] [--notlar ...]
+                  [--portfoy ad]  (verilmezse ana portföye eklenir)
+  ls              Açık envanter kayıtlarını listeler [--portfoy ad]
+  update-prices   Güncel fiyatları çeker, kayıtları günceller ve alarmları değerlendirir
+  rm <id>         Envanter kaydını çöp kutusuna taşır
+  summary         Toplam ve kod bazlı özetleri yazdırır [--portfoy ad]
+  rapor           Haftalık ve aylık değişim raporunu yazdırır
+  export          Envanter, grup ve özet tablolarını JSON veya CSV olarak dışa aktarır
+                  [--format json|csv] [--sayi tr|makine] [--tablo envanter|gruplar|ozet]
+                  [--cikti dizin]  (dizin verilmezse stdout'a yazar) [--portfoy ad]
+  import <dosya>  CSV dosyasındaki alışları önizler ve tek işlemde ekler
+                  [--format tr|en] [--sutun kod=Ürün,miktar=Adet,...] [--onizle]
+                  [--kopyalari-al] [--offline] [--portfoy ad]
+  migrations      Veritabanı şema sürümünü ve migrasyon durumlarını gösterir
+  restore <dosya> Yedeği bütünlük kontrolünden geçirip veritabanının yerine koyar
+                  (dosya verilmezse mevcut yedekleri listeler)
+  rekey           Şifreli veritabanının ve şifreli yedeklerin parolasını değiştirir
+  portfoy         Portföyleri lot sayısı ve toplamlarıyla listeler
+                  ekle <ad> [--notlar ...] | adlandir <ad> <yeni-ad> | sil <ad>
+  alarm           Fiyat ve kar/zarar alarmlarını listeler
+                  ekle --tip fiyat_ustu|fiyat_alti|degisim|kar_ustu|kar_alti --esik 10000
+                       [--kod C] [--alan alis|satis] [--portfoy ad] [--ad ...]
+                       [--komut "..."] [--webhook http://...]
+                  sil <id> | ac <id> | kapat <id> | test <id>
+  cop             Çöp kutusundaki (silinmiş) kayıtları listeler [--portfoy ad]
+                  geri-al <id> | sil <id> (kalıcı) | bosalt (kalıcı)
+  gecmis [<id>]   Lotun değişiklik geçmişini (kim, ne zaman, ne değişti) gösterir
+                  (ID verilmezse tüm lotlardaki son değişiklikler) [--limit 50]
+  serve           TUI olmadan fiyat güncelleme döngüsünü çalıştırır ve yerel JSON API sunar
+                  [--adres 127.0.0.1:8787] [--aralik 1m] [--offline]
+
+--portfoy parametresi portföy adı veya ID'si alır; verilmezse tüm portföyler birlikte gösterilir.
+add, ls, update-prices, rm, summary, rapor, import, migrations, restore, rekey, portfoy, alarm, cop ve gecmis --json parametresi ile JSON çıktı verir.
+`)
+}
+
+// yeniFlagSet alt komut için ortak --json parametresini içeren flag seti oluşturur
+func yeniFlagSet(ad string) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet(ad, flag.ContinueOnError)
+	jsonCikti := fs.Bool("json", false, "JSON formatında çıktı ver")
+	return fs, jsonCikti
+}
+
+// parametreleriOku komut parametrelerini ayrıştırır ve ardından veritabanını açar;
+// yardım istendiğinde veya parametre hatalıysa veritabanına dokunulmaz
+func parametreleriOku(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	veritabaniniHazirla()
+	return nil
+}
+
+// bayraklariOneAl "rm 5 --json" gibi kullanımlar için parametreleri değerleriyle birlikte konumsal argümanların önüne taşır
+func bayraklariOneAl(fs *flag.FlagSet, args []string) []string {
+	var bayraklar, konumsal []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			konumsal = append(konumsal, arg)
+			continue
+		}
+		bayraklar = append(bayraklar, arg)
+
+		// "=" ile verilmemiş ve bool olmayan parametrelerin değeri sonraki argümandır
+		ad := strings.TrimLeft(arg, "-")
+		if strings.Contains(ad, "=") {
+			continue
+		}
+		if f := fs.Lookup(ad); f != nil && i+1 < len(args) {
+			if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !boolFlag.IsBoolFlag() {
+				i++
+				bayraklar = append(bayraklar, args[i])
+			}
+		}
+	}
+	return append(bayraklar, konumsal...)
+}
+
+// decimalBayragi flag setine decimal değer alan (1234.56 formatında) bir parametre ekler
+func decimalBayragi(fs *flag.FlagSet, ad, kullanim string) *decimal.Decimal {
+	deger := new(decimal.Decimal)
+	fs.Func(ad, kullanim, func(metin string) error {
+		d, err := decimal.NewFromString(metin)
+		if err != nil {
+			return fmt.Errorf("geçersiz sayı: %s", metin)
+		}
+		*deger = d
+		return nil
+	})
+	return deger
+}
+
+// portfoyBayragi flag setine portföy adı veya ID'si alan --portfoy parametresi ekler
+func portfoyBayragi(fs *flag.FlagSet) *string {
+	return fs.String("portfoy", "", "portföy adı veya ID'si; boşsa tüm portföyler")
+}
+
+// portfoyIDCoz --portfoy değerini portföy ID'sine çevirir; boş değer için 0 (tüm portföyler) döner
+func portfoyIDCoz(adVeyaID string) (uint, error) {
+	if strings.TrimSpace(adVeyaID) == "" {
+		return 0, nil
+	}
+	portfoy, err := services.NewPortfoyService().PortfoyBul(adVeyaID)
+	if err != nil {
+		return 0, err
+	}
+	return portfoy.ID, nil
+}
+
+// isaretli tutarı 2 ondalıkla, pozitif ve sıfır değerlerde başına "+" koyarak yazar
+func isaretli(d decimal.Decimal) string {
+	if d.IsNegative() {
+		return d.StringFixed(2)
+	}
+	return "+" + d.StringFixed(2)
+}
+
+// jsonYaz değeri girintili JSON olarak yazar
+func jsonYaz(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// komutAdd yeni envanter kaydı ekler
+func komutAdd(args []string) error {
+	fs, jsonCikti := yeniFlagSet("add")
+	kod := fs.String("kod", "", "API ürün kodu (ör. C, GA, USD)")
+	miktar := decimalBayragi(fs, "miktar", "miktar (gram veya adet)")
+	fiyat := decimalBayragi(fs, "fiyat", "birim alış fiyatı (TL)")
+	tarih := fs.String("tarih", time.Now().Format("02.01.2006"), "alış tarihi (GG.AA.YYYY)")
+	tur := fs.String("tur", "", "tür (Altın, Gümüş, Döviz); boşsa koddan bulunur")
+	cins := fs.String("cins", "", "cins; boşsa koddan bulunur")
+	birim := fs.String("birim", "", "birim (gram, adet, kilogram, ons); boşsa koddan tahmin edilir")
+	hediye := fs.Bool("hediye", false, "kaydı hediye girişi olarak ekle")
+	offline := fs.Bool("offline", false, "güncel fiyatı API'den çekme")
+	notlar := fs.String("notlar", "", "notlar")
+	portfoy := fs.String("portfoy", "", "kaydın ekleneceği portföyün adı veya ID'si; boşsa ana portföy")
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+
+	portfoyID, err := portfoyIDCoz(*portfoy)
+	if err != nil {
+		return err
+	}
+
+	*kod = strings.ToUpper(strings.TrimSpace(*kod))
+	if *kod == "" {
+		return fmt.Errorf("--kod zorunlu")
+	}
+	if !miktar.IsPositive() {
+		return fmt.Errorf("--miktar sıfırdan büyük olmalı")
+	}
+	if fiyat.IsNegative() || (fiyat.IsZero() && !*hediye) {
+		return fmt.Errorf("--fiyat sıfırdan büyük olmalı")
+	}
+
+	alisTarihi, err := time.ParseInLocation("02.01.2006", *tarih, time.Local)
+	if err != nil {
+		return fmt.Errorf("--tarih GG.AA.YYYY formatında olmalı: %w", err)
+	}
+
+	// Tür ve cins verilmemişse ürün kataloğundan bul
+	if *tur == "" || *cins == "" {
+		katalogTur, katalogCins, ok := models.UrunBul(*kod)
+		if !ok {
+			return fmt.Errorf("bilinmeyen kod %s: --tur ve --cins belirtin", *kod)
+		}
+		if *tur == "" {
+			*tur = katalogTur
+		}
+		if *cins == "" {
+			*cins = katalogCins.Name
+		}
+	}
+	if *birim == "" {
+		*birim = models.VarsayilanBirim(*tur, *kod)
+	}
+
+	girisTipi := models.IslemAlis
+	if *hediye {
+		girisTipi = models.IslemHediyeGiris
+	}
+
+	envanter := models.Envanter{
+		Tur:        *tur,
+		Cins:       *cins,
+		Kod:        *kod,
+		Miktar:     *miktar,
+		Birim:      *birim,
+		GirisTipi:  girisTipi,
+		AlisTarihi: alisTarihi,
+		AlisFiyati: *fiyat,
+		Notlar:     *notlar,
+	}
+
+	envanterService := services.NewEnvanterService()
+	envanterService.SetPortfoy(portfoyID)
+	if err := envanterService.AddEnvanterWithMode(&envanter, *offline); err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		return jsonYaz(os.Stdout, envanter)
+	}
+	fmt.Printf("Eklendi: ID %d, %s %s %s %s, birim fiyat %s ₺\n",
+		envanter.ID, envanter.Kod, envanter.Cins, envanter.Miktar, envanter.Birim, envanter.AlisFiyati.StringFixed(2))
+	return nil
+}
+
+// komutLs açık envanter kayıtlarını veritabanından listeler
+func komutLs(args []string) error {
+	fs, jsonCikti := yeniFlagSet("ls")
+	portfoy := portfoyBayragi(fs)
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+
+	portfoyID, err := portfoyIDCoz(*portfoy)
+	if err != nil {
+		return err
+	}
+
+	envanterService := services.NewEnvanterService()
+	envanterService.SetPortfoy(portfoyID)
+	envanterler, err := envanterService.GetAllEnvanterFromDB()
+	if err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		if envanterler == nil {
+			envanterler = []models.Envanter{}
+		}
+		return jsonYaz(os.Stdout, envanterler)
+	}
+
+	// Tüm portföyler listelenirken birden fazla portföy varsa lotun portföyü de yazılır
+	var portfoyAdlari map[uint]string
+	if portfoyID == 0 {
+		portfoylar, err := services.NewPortfoyService().GetPortfoyler()
+		if err != nil {
+			return err
+		}
+		if len(portfoylar) > 1 {
+			portfoyAdlari = make(map[uint]string, len(portfoylar))
+			for _, p := range portfoylar {
+				portfoyAdlari[p.ID] = p.Ad
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	baslik := "ID\tKOD\tCİNS\tMİKTAR\tBİRİM\tALIŞ TARİHİ\tALIŞ ₺\tGÜNCEL ₺\tTUTAR ₺\tK/Z ₺\t"
+	if portfoyAdlari != nil {
+		baslik += "PORTFÖY\t"
+	}
+	fmt.Fprintln(tw, baslik)
+	for _, e := range envanterler {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t",
+			e.ID, e.Kod, e.Cins, e.Miktar, e.Birim, e.AlisTarihi.Format("02.01.2006"),
+			e.AlisFiyati.StringFixed(2), e.GuncelFiyat.StringFixed(2), e.GuncelTutar.StringFixed(2), isaretli(e.KarZarar))
+		if portfoyAdlari != nil {
+			fmt.Fprintf(tw, "%s\t", portfoyAdlari[e.PortfoyID])
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// komutUpdatePrices güncel fiyatları çekip envanter kayıtlarına yazar
+func komutUpdatePrices(args []string) error {
+	fs, jsonCikti := yeniFlagSet("update-prices")
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+
+	envanterService := services.NewEnvanterService()
+	if err := envanterService.UpdateGuncelFiyatlar(); err != nil {
+		return err
+	}
+
+	envanterler, err := envanterService.GetAllEnvanterFromDB()
+	if err != nil {
+		return err
+	}
+
+	alarmlar, err := services.NewAlarmService().Degerlendir()
+	if err != nil {
+		return err
+	}
+
+	// Kısmi güncellemede alınamayan gruplar son başarılı fiyatlarıyla kalır
+	var eksikler []string
+	fiyatZamani := time.Now()
+	if fiyatlar := envanterService.SonFiyatlar(); fiyatlar != nil {
+		eksikler = fiyatlar.Eksikler
+		fiyatZamani = fiyatlar.EnEskiZaman()
+	}
+
+	if *jsonCikti {
+		if alarmlar == nil {
+			alarmlar = []services.AlarmOlayi{}
+		}
+		if eksikler == nil {
+			eksikler = []string{}
+		}
+		return jsonYaz(os.Stdout, map[string]interface{}{
+			"guncellenen":   len(envanterler),
+			"zaman":         time.Now(),
+			"eksik_gruplar": eksikler,
+			"en_eski_fiyat": fiyatZamani,
+			"alarmlar":      alarmlar,
+		})
+	}
+	if len(eksikler) > 0 {
+		fmt.Fprintf(os.Stderr, "UYARI: %s fiyatları alınamadı; en eski fiyat: %s\n", strings.Join(eksikler, ", "), fiyatZamani.Format("02.01.2006 15:04"))
+	}
+	fmt.Printf("%d kayıt güncellendi\n", len(envanterler))
+	printAlarmOlaylari(os.Stdout, alarmlar)
+	return nil
+}
+
+// komutRm envanter kaydını ID ile siler
+func komutRm(args []string) error {
+	fs, jsonCikti := yeniFlagSet("rm")
+	if err := parametreleriOku(fs, bayraklariOneAl(fs, args)); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("kullanım: altintakip rm <id>")
+	}
+
+	id, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("geçersiz ID: %s", fs.Arg(0))
+	}
+
+	envanterService := services.NewEnvanterService()
+	envanter, err := envanterService.GetEnvanterByID(uint(id))
+	if err != nil {
+		return err
+	}
+	if err := envanterService.DeleteEnvanter(envanter.ID); err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		return jsonYaz(os.Stdout, map[string]interface{}{"silinen": envanter})
+	}
+	fmt.Printf("Çöp kutusuna taşındı: ID %d, %s %s %s %s (geri almak için: altintakip cop geri-al %d)\n",
+		envanter.ID, envanter.Kod, envanter.Cins, envanter.Miktar, envanter.Birim, envanter.ID)
+	return nil
+}
+
+// komutSummary toplam değerleri ve kod bazlı grupları yazdırır
+func komutSummary(args []string) error {
+	fs, jsonCikti := yeniFlagSet("summary")
+	portfoy := portfoyBayragi(fs)
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+
+	portfoyID, err := portfoyIDCoz(*portfoy)
+	if err != nil {
+		return err
+	}
+
+	envanterService := services.NewEnvanterService()
+	envanterService.SetPortfoy(portfoyID)
+	toplamlar, err := envanterService.GetToplamDegerler()
+	if err != nil {
+		return err
+	}
+	gruplar, err := envanterService.GetKodBazliGruplar()
+	if err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		return jsonYaz(os.Stdout, map[string]interface{}{
+			"toplam":  toplamlar,
+			"gruplar": gruplar,
+		})
+	}
+
+	fmt.Printf("Toplam Alış:        %16s ₺\n", toplamlar["toplam_alis"].StringFixed(2))
+	fmt.Printf("Güncel Değer:       %16s ₺\n", toplamlar["toplam_guncel"].StringFixed(2))
+	fmt.Printf("Kar/Zarar:          %16s ₺ (%s%%)\n", isaretli(toplamlar["toplam_kar"]), isaretli(toplamlar["toplam_kar_yuzde"]))
+	fmt.Printf("Gerçekleşen K/Z:    %16s ₺\n", isaretli(toplamlar["toplam_gerceklesen_kar"]))
+	fmt.Println()
+
+	kodlar := make([]string, 0, len(gruplar))
+	for kod := range gruplar {
+		kodlar = append(kodlar, kod)
+	}
+	sort.Strings(kodlar)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "KOD\tCİNS\tMİKTAR\tBİRİM\tORT. ALIŞ ₺\tALIŞ ₺\tTUTAR ₺\tK/Z ₺\tK/Z %\tGERÇEKLEŞEN ₺\t")
+	for _, kod := range kodlar {
+		grup := gruplar[kod]
+		tutar := func(alan string) decimal.Decimal { return grup[alan].(decimal.Decimal) }
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s%%\t%s\t\n",
+			kod, grup["cins"], tutar("toplam_miktar"), grup["birim"],
+			tutar("ortalama_alis_fiyati").StringFixed(2), tutar("toplam_alis_tutar").StringFixed(2), tutar("toplam_guncel_tutar").StringFixed(2),
+			isaretli(tutar("gerceklesmemis_kar_zarar")), isaretli(tutar("kar_zarar_yuzde")), isaretli(tutar("gerceklesen_kar_zarar")))
+	}
+	return tw.Flush()
+}
+
+// komutRapor haftalık ve aylık değişim raporunu yazdırır
+func komutRapor(args []string) error {
+	fs, jsonCikti := yeniFlagSet("rapor")
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		portfoyGecmisi := services.NewPortfoyGecmisiService()
+		haftalik, err := portfoyGecmisi.GetDonemselDegisimler(services.DonemHafta, 8)
+		if err != nil {
+			return err
+		}
+		aylik, err := portfoyGecmisi.GetDonemselDegisimler(services.DonemAy, 12)
+		if err != nil {
+			return err
+		}
+		return jsonYaz(os.Stdout, map[string]interface{}{
+			"haftalik": haftalik,
+			"aylik":    aylik,
+		})
+	}
+
+	printRapor(os.Stdout)
+	return nil
+}
+
+// komutExport envanter verilerini JSON veya CSV olarak dışa aktarır
+func komutExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	bicim := fs.String("format", services.BicimJSON, "dışa aktarım biçimi (json, csv)")
+	sayiFormati := fs.String("sayi", services.SayiFormatiTR, "CSV sayı formatı (tr: 1.234,56 ; makine: 1234.56)")
+	tablo := fs.String("tablo", services.TabloEnvanter, "stdout'a CSV yazarken tablo (envanter, gruplar, ozet)")
+	cikti := fs.String("cikti", "", "dosyaların yazılacağı dizin; boşsa stdout")
+	portfoy := portfoyBayragi(fs)
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+
+	portfoyID, err := portfoyIDCoz(*portfoy)
+	if err != nil {
+		return err
+	}
+
+	disaAktarim := services.NewDisaAktarimService()
+	disaAktarim.SetPortfoy(portfoyID)
+	veri, err := disaAktarim.Topla()
+	if err != nil {
+		return err
+	}
+
+	// Dizin verilmişse tüm tablolar dosyalara yazılır
+	if *cikti != "" {
+		dosyalar, err := disaAktarim.SaveDosyalar(veri, *cikti, *bicim, *sayiFormati)
+		if err != nil {
+			return err
+		}
+		for _, dosya := range dosyalar {
+			fmt.Fprintln(os.Stderr, dosya)
+		}
+		return nil
+	}
+
+	switch *bicim {
+	case services.BicimJSON:
+		return disaAktarim.WriteJSON(os.Stdout, veri)
+	case services.BicimCSV:
+		return disaAktarim.WriteCSV(os.Stdout, veri, *tablo, *sayiFormati)
+	default:
+		return fmt.Errorf("geçersiz dışa aktarım biçimi: %s (json veya csv olmalı)", *bicim)
+	}
+}
+
+// komutImport CSV dosyasındaki alışları önizler ve olası kopyalar hariç tek işlemde ekler
+func komutImport(args []string) error {
+	fs, jsonCikti := yeniFlagSet("import")
+	sayiFormati := fs.String("format", services.IceAktarimTR, "sayı ve tarih formatı (tr: 1.234,56 02.01.2006 ; en: 1,234.56 2006-01-02)")
+	sutun := fs.String("sutun", "", "sütun eşleştirmesi, ör. kod=Ürün,miktar=Adet,fiyat=Fiyat,tarih=Tarih")
+	onizle := fs.Bool("onizle", false, "sadece önizle, kaydetme")
+	kopyalariAl := fs.Bool("kopyalari-al", false, "olası kopya satırları da ekle")
+	offline := fs.Bool("offline", false, "güncel fiyatı API'den çekme")
+	portfoy := fs.String("portfoy", "", "kayıtların ekleneceği portföyün adı veya ID'si; boşsa ana portföy")
+	if err := parametreleriOku(fs, bayraklariOneAl(fs, args)); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("kullanım: altintakip import <dosya.csv>")
+	}
+
+	portfoyID, err := portfoyIDCoz(*portfoy)
+	if err != nil {
+		return err
+	}
+
+	eslesme, err := services.SutunEslesmesiCoz(*sutun)
+	if err != nil {
+		return err
+	}
+
+	dosya, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("dosya açılamadı: %w", err)
+	}
+	defer dosya.Close()
+
+	iceAktarim := services.NewIceAktarimService()
+	iceAktarim.SetPortfoy(portfoyID)
+	onizleme, err := iceAktarim.Onizle(dosya, *sayiFormati, eslesme)
+	if err != nil {
+		return err
+	}
+
+	eklenen := 0
+	if !*onizle && onizleme.HataSayisi() == 0 {
+		eklenen, err = iceAktarim.Kaydet(onizleme, *kopyalariAl, *offline)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *jsonCikti {
+		if err := jsonYaz(os.Stdout, map[string]interface{}{
+			"onizleme": onizleme,
+			"eklenen":  eklenen,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printIceAktarimOnizleme(os.Stdout, onizleme)
+	}
+
+	if hataSayisi := onizleme.HataSayisi(); hataSayisi > 0 {
+		return fmt.Errorf("%d satır hatalı, içe aktarım yapılmadı", hataSayisi)
+	}
+	if !*jsonCikti {
+		if *onizle {
+			fmt.Printf("Önizleme: %d satır, %d olası kopya (kaydedilmedi)\n", len(onizleme.Satirlar), onizleme.KopyaSayisi())
+		} else {
+			fmt.Printf("%d kayıt eklendi, %d olası kopya atlandı\n", eklenen, len(onizleme.Satirlar)-eklenen)
+		}
+	}
+	return nil
+}
+
+// printIceAktarimOnizleme ayrıştırılan satırları durumlarıyla birlikte yazdırır
+func printIceAktarimOnizleme(w io.Writer, onizleme *services.IceAktarimOnizleme) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SATIR\tKOD\tCİNS\tMİKTAR\tBİRİM\tTARİH\tFİYAT ₺\tDURUM")
+	for _, satir := range onizleme.Satirlar {
+		e := satir.Envanter
+		durum := "OK"
+		if satir.Hata != "" {
+			durum = "HATA: " + satir.Hata
+		} else if satir.OlasiKopya {
+			durum = "KOPYA? " + satir.KopyaNotu
+		}
+		tarih := ""
+		if !e.AlisTarihi.IsZero() {
+			tarih = e.AlisTarihi.Format("02.01.2006")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			satir.Satir, e.Kod, e.Cins, e.Miktar, e.Birim, tarih, e.AlisFiyati.StringFixed(2), durum)
+	}
+	tw.Flush()
+}
+
+// komutMigrations şema migrasyonlarının uygulanma durumunu yazdırır
+func komutMigrations(args []string) error {
+	fs, jsonCikti := yeniFlagSet("migrations")
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+
+	durumlar, err := database.GetMigrasyonDurumlari()
+	if err != nil {
+		return err
+	}
+	surum, err := database.SemaSurumu()
+	if err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		return jsonYaz(os.Stdout, map[string]interface{}{
+			"surum":        surum,
+			"migrasyonlar": durumlar,
+		})
+	}
+
+	fmt.Printf("Şema sürümü: %d\n\n", surum)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SÜRÜM\tUYGULANMA\tAÇIKLAMA")
+	for _, durum := range durumlar {
+		zaman := "bekliyor"
+		if durum.UygulanmaZamani != nil {
+			zaman = durum.UygulanmaZamani.Format("02.01.2006 15:04:05")
+		}
+		aciklama := durum.Aciklama
+		if durum.Yikici {
+			aciklama += " (yedek alınır)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", durum.Surum, zaman, aciklama)
+	}
+	return tw.Flush()
+}
+
+// komutRestore yedeği doğrulayıp geri yükler; dosya verilmezse yedekleri listeler
+func komutRestore(args []string) error {
+	fs, jsonCikti := yeniFlagSet("restore")
+	if err := parametreleriOku(fs, bayraklariOneAl(fs, args)); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("kullanım: altintakip restore <yedek-dosyası>")
+	}
+
+	if fs.NArg() == 0 {
+		yedekler, err := database.Yedekler()
+		if err != nil {
+			return err
+		}
+		if *jsonCikti {
+			if yedekler == nil {
+				yedekler = []database.YedekBilgisi{}
+			}
+			return jsonYaz(os.Stdout, map[string]interface{}{"yedekler": yedekler})
+		}
+		if len(yedekler) == 0 {
+			fmt.Println("Yedek bulunamadı")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ZAMAN\tBOYUT\tDOSYA")
+		for i := len(yedekler) - 1; i >= 0; i-- {
+			yedek := yedekler[i]
+			fmt.Fprintf(tw, "%s\t%d KB\t%s\n", yedek.Zaman.Format("02.01.2006 15:04:05"), (yedek.Boyut+1023)/1024, yedek.Yol)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Println("\nGeri yüklemek için: altintakip restore <dosya>")
+		return nil
+	}
+
+	oncekiYedek, err := database.GeriYukle(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("geri yükleme başarısız: %w", err)
+	}
+
+	if *jsonCikti {
+		return jsonYaz(os.Stdout, map[string]interface{}{
+			"geri_yuklenen": fs.Arg(0),
+			"onceki_yedek":  oncekiYedek,
+		})
+	}
+	fmt.Printf("Geri yüklendi: %s\n", fs.Arg(0))
+	fmt.Printf("Önceki veritabanı yedeklendi: %s\n", oncekiYedek)
+	return nil
+}
+
+// komutRekey şifreli veritabanının parolasını terminalden sorulan yeni parolayla değiştirir
+func komutRekey(args []string) error {
+	fs, jsonCikti := yeniFlagSet("rekey")
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+	if !database.SifreliMi() {
+		return fmt.Errorf("veritabanı şifreli değil; şifrelemek için DB_ENCRYPTION=true ayarlayıp uygulamayı açın")
+	}
+
+	yeniParola, err := yeniParolaOku()
+	if err != nil {
+		return err
+	}
+	sayi, err := database.ParolaDegistir(yeniParola)
+	if err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		return jsonYaz(os.Stdout, map[string]interface{}{"yeniden_sifrelenen_yedek": sayi})
+	}
+	fmt.Printf("Parola değiştirildi, %d yedek yeni parolayla yeniden şifrelendi\n", sayi)
+	return nil
+}
+
+// komutPortfoy portföyleri listeler; ekle, adlandir ve sil alt komutlarıyla portföyleri yönetir
+func komutPortfoy(args []string) error {
+	fs, jsonCikti := yeniFlagSet("portfoy")
+	notlar := fs.String("notlar", "", "yeni portföyün notları")
+	if err := parametreleriOku(fs, bayraklariOneAl(fs, args)); err != nil {
+		return err
+	}
+
+	portfoyService := services.NewPortfoyService()
+	switch fs.Arg(0) {
+	case "":
+		return portfoylariYazdir(portfoyService, *jsonCikti)
+	case "ekle":
+		if fs.NArg() != 2 {
+			return fmt.Errorf("kullanım: altintakip portfoy ekle <ad> [--notlar ...]")
+		}
+		portfoy, err := portfoyService.AddPortfoy(fs.Arg(1), *notlar)
+		if err != nil {
+			return err
+		}
+		if *jsonCikti {
+			return jsonYaz(os.Stdout, portfoy)
+		}
+		fmt.Printf("Portföy eklendi: ID %d, %s\n", portfoy.ID, portfoy.Ad)
+		return nil
+	case "adlandir":
+		if fs.NArg() != 3 {
+			return fmt.Errorf("kullanım: altintakip portfoy adlandir <ad> <yeni-ad>")
+		}
+		portfoy, err := portfoyService.PortfoyBul(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		if err := portfoyService.RenamePortfoy(portfoy.ID, fs.Arg(2)); err != nil {
+			return err
+		}
+		if *jsonCikti {
+			return jsonYaz(os.Stdout, map[string]interface{}{"id": portfoy.ID, "eski_ad": portfoy.Ad, "yeni_ad": strings.TrimSpace(fs.Arg(2))})
+		}
+		fmt.Printf("Portföy adı değiştirildi: %s -> %s\n", portfoy.Ad, strings.TrimSpace(fs.Arg(2)))
+		return nil
+	case "sil":
+		if fs.NArg() != 2 {
+			return fmt.Errorf("kullanım: altintakip portfoy sil <ad>")
+		}
+		portfoy, err := portfoyService.PortfoyBul(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		if err := portfoyService.DeletePortfoy(portfoy.ID); err != nil {
+			return err
+		}
+		if *jsonCikti {
+			return jsonYaz(os.Stdout, map[string]interface{}{"silinen": portfoy})
+		}
+		fmt.Printf("Portföy silindi: ID %d, %s\n", portfoy.ID, portfoy.Ad)
+		return nil
+	default:
+		return fmt.Errorf("bilinmeyen portfoy komutu: %s (ekle, adlandir veya sil)", fs.Arg(0))
+	}
+}
+
+// portfoylariYazdir her portföyü açık lot sayısı ve toplam değerleriyle yazdırır
+func portfoylariYazdir(portfoyService *services.PortfoyService, jsonCikti bool) error {
+	portfoylar, err := portfoyService.GetPortfoyler()
+	if err != nil {
+		return err
+	}
+
+	type portfoyOzeti struct {
+		models.Portfoy
+		LotSayisi int                        `json:"lot_sayisi"`
+		Toplam    map[string]decimal.Decimal `json:"toplam"`
+	}
+	ozetler := make([]portfoyOzeti, 0, len(portfoylar))
+	for _, portfoy := range portfoylar {
+		envanterService := services.NewEnvanterService()
+		envanterService.SetPortfoy(portfoy.ID)
+		envanterler, err := envanterService.GetAllEnvanterFromDB()
+		if err != nil {
+			return err
+		}
+		toplamlar, err := envanterService.GetToplamDegerler()
+		if err != nil {
+			return err
+		}
+		ozetler = append(ozetler, portfoyOzeti{Portfoy: portfoy, LotSayisi: len(envanterler), Toplam: toplamlar})
+	}
+
+	if jsonCikti {
+		return jsonYaz(os.Stdout, map[string]interface{}{"portfoyler": ozetler})
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "ID\tAD\tLOT\tALIŞ ₺\tTUTAR ₺\tK/Z ₺\tGERÇEKLEŞEN ₺\t")
+	for _, ozet := range ozetler {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%s\t\n",
+			ozet.ID, ozet.Ad, ozet.LotSayisi, ozet.Toplam["toplam_alis"].StringFixed(2), ozet.Toplam["toplam_guncel"].StringFixed(2),
+			isaretli(ozet.Toplam["toplam_kar"]), isaretli(ozet.Toplam["toplam_gerceklesen_kar"]))
+	}
+	return tw.Flush()
+}
+
+// komutAlarm alarmları listeler; ekle, sil, ac, kapat ve test alt komutlarıyla alarmları yönetir
+func komutAlarm(args []string) error {
+	fs, jsonCikti := yeniFlagSet("alarm")
+	tip := fs.String("tip", "", "alarm tipi (fiyat_ustu, fiyat_alti, degisim, kar_ustu, kar_alti)")
+	kod := fs.String("kod", "", "fiyat ve değişim alarmlarında API kodu")
+	alan := fs.String("alan", models.AlarmAlanAlis, "fiyat alarmlarında karşılaştırılan fiyat (alis, satis)")
+	esik := decimalBayragi(fs, "esik", "eşik: fiyat ve kar alarmlarında TL, değişim alarmında yüzde")
+	portfoy := fs.String("portfoy", "", "kar alarmlarında portföy adı veya ID'si; boşsa tüm portföyler")
+	ad := fs.String("ad", "", "alarmın adı; boşsa koşuldan üretilir")
+	komut := fs.String("komut", "", "tetiklenince çalıştırılacak kabuk komutu (ALARM_* ortam değişkenleriyle)")
+	webhook := fs.String("webhook", "", "tetiklenince JSON POST atılacak adres")
+	if err := parametreleriOku(fs, bayraklariOneAl(fs, args)); err != nil {
+		return err
+	}
+
+	alarmService := services.NewAlarmService()
+	switch fs.Arg(0) {
+	case "":
+		return alarmlariYazdir(alarmService, *jsonCikti)
+	case "ekle":
+		portfoyID, err := portfoyIDCoz(*portfoy)
+		if err != nil {
+			return err
+		}
+		alarm := models.Alarm{
+			Ad:        *ad,
+			Tip:       *tip,
+			Kod:       *kod,
+			Alan:      *alan,
+			Esik:      *esik,
+			PortfoyID: portfoyID,
+			Komut:     *komut,
+			Webhook:   *webhook,
+		}
+		if err := alarmService.AddAlarm(&alarm); err != nil {
+			return err
+		}
+		if *jsonCikti {
+			return jsonYaz(os.Stdout, alarm)
+		}
+		fmt.Printf("Alarm eklendi: ID %d, %s\n", alarm.ID, services.AlarmAciklamasi(&alarm))
+		return nil
+	case "sil", "ac", "kapat", "test":
+		if fs.NArg() != 2 {
+			return fmt.Errorf("kullanım: altintakip alarm %s <id>", fs.Arg(0))
+		}
+		id, err := strconv.ParseUint(fs.Arg(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("geçersiz ID: %s", fs.Arg(1))
+		}
+		return alarmIslemi(alarmService, fs.Arg(0), uint(id), *jsonCikti)
+	default:
+		return fmt.Errorf("bilinmeyen alarm komutu: %s (ekle, sil, ac, kapat veya test)", fs.Arg(0))
+	}
+}
+
+// alarmIslemi tek bir alarmı siler, açar, kapatır veya bildirimlerini test eder
+func alarmIslemi(alarmService *services.AlarmService, islem string, id uint, jsonCikti bool) error {
+	if islem == "test" {
+		olay, err := alarmService.Test(id)
+		if err != nil {
+			return err
+		}
+		if jsonCikti {
+			return jsonYaz(os.Stdout, olay)
+		}
+		printAlarmOlaylari(os.Stdout, []services.AlarmOlayi{*olay})
+		if len(olay.Hatalar) > 0 {
+			return fmt.Errorf("alarm bildirimi başarısız")
+		}
+		return nil
+	}
+
+	var err error
+	var mesaj string
+	switch islem {
+	case "sil":
+		err = alarmService.DeleteAlarm(id)
+		mesaj = "Alarm silindi"
+	case "ac":
+		err = alarmService.SetAlarmAktif(id, true)
+		mesaj = "Alarm açıldı"
+	case "kapat":
+		err = alarmService.SetAlarmAktif(id, false)
+		mesaj = "Alarm kapatıldı"
+	}
+	if err != nil {
+		return err
+	}
+
+	if jsonCikti {
+		return jsonYaz(os.Stdout, map[string]interface{}{"id": id, "islem": islem})
+	}
+	fmt.Printf("%s: ID %d\n", mesaj, id)
+	return nil
+}
+
+// alarmlariYazdir alarmları durumlarıyla birlikte yazdırır
+func alarmlariYazdir(alarmService *services.AlarmService, jsonCikti bool) error {
+	alarmlar, err := alarmService.GetAlarmlar()
+	if err != nil {
+		return err
+	}
+
+	if jsonCikti {
+		if alarmlar == nil {
+			alarmlar = []models.Alarm{}
+		}
+		return jsonYaz(os.Stdout, map[string]interface{}{"alarmlar": alarmlar})
+	}
+	if len(alarmlar) == 0 {
+		fmt.Println("Alarm tanımlı değil")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tALARM\tDURUM\tSON DEĞER\tSON TETİKLENME\tBİLDİRİM")
+	for i := range alarmlar {
+		alarm := &alarmlar[i]
+		durum := "bekliyor"
+		if !alarm.Aktif {
+			durum = "kapalı"
+		} else if alarm.Tetiklendi {
+			durum = "tetiklendi"
+		}
+		sonDeger, sonTetiklenme := "-", "-"
+		if alarm.SonDeger != nil {
+			sonDeger = alarm.SonDeger.StringFixed(2)
+		}
+		if alarm.SonTetiklenme != nil {
+			sonTetiklenme = alarm.SonTetiklenme.Format("02.01.2006 15:04")
+		}
+		var bildirimler []string
+		if alarm.Komut != "" {
+			bildirimler = append(bildirimler, "komut")
+		}
+		if alarm.Webhook != "" {
+			bildirimler = append(bildirimler, "webhook")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", alarm.ID, services.AlarmAciklamasi(alarm), durum, sonDeger, sonTetiklenme, strings.Join(bildirimler, ", "))
+	}
+	return tw.Flush()
+}
+
+// printAlarmOlaylari tetiklenen alarmları ve bildirim hatalarını yazdırır
+func printAlarmOlaylari(w io.Writer, olaylar []services.AlarmOlayi) {
+	for _, olay := range olaylar {
+		onek := "ALARM"
+		if olay.Test {
+			onek = "ALARM (test)"
+		}
+		fmt.Fprintf(w, "%s: %s\n", onek, olay.Mesaj)
+		for _, hata := range olay.Hatalar {
+			fmt.Fprintf(w, "  bildirim hatası: %s\n", hata)
+		}
+	}
+}
+
+// komutCop çöp kutusunu listeler; geri-al, sil ve bosalt alt komutlarıyla silinmiş kayıtları yönetir
+func komutCop(args []string) error {
+	fs, jsonCikti := yeniFlagSet("cop")
+	portfoy := portfoyBayragi(fs)
+	if err := parametreleriOku(fs, bayraklariOneAl(fs, args)); err != nil {
+		return err
+	}
+
+	portfoyID, err := portfoyIDCoz(*portfoy)
+	if err != nil {
+		return err
+	}
+	envanterService := services.NewEnvanterService()
+	envanterService.SetPortfoy(portfoyID)
+
+	switch fs.Arg(0) {
+	case "":
+		return copKutusunuYazdir(envanterService, *jsonCikti)
+	case "geri-al", "sil":
+		if fs.NArg() != 2 {
+			return fmt.Errorf("kullanım: altintakip cop %s <id>", fs.Arg(0))
+		}
+		id, err := strconv.ParseUint(fs.Arg(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("geçersiz ID: %s", fs.Arg(1))
+		}
+
+		var envanter *models.Envanter
+		mesaj, anahtar := "Geri alındı", "geri_alinan"
+		if fs.Arg(0) == "sil" {
+			envanter, err = envanterService.PurgeEnvanter(uint(id))
+			mesaj, anahtar = "Kalıcı olarak silindi", "kalici_silinen"
+		} else {
+			envanter, err = envanterService.RestoreEnvanter(uint(id))
+		}
+		if err != nil {
+			return err
+		}
+
+		if *jsonCikti {
+			return jsonYaz(os.Stdout, map[string]interface{}{anahtar: envanter})
+		}
+		fmt.Printf("%s: ID %d, %s %s %s %s\n", mesaj, envanter.ID, envanter.Kod, envanter.Cins, envanter.Miktar, envanter.Birim)
+		return nil
+	case "bosalt":
+		silinen, korunan, err := envanterService.EmptyCopKutusu()
+		if err != nil {
+			return err
+		}
+		if *jsonCikti {
+			return jsonYaz(os.Stdout, map[string]interface{}{"kalici_silinen": silinen, "korunan": korunan})
+		}
+		fmt.Printf("Çöp kutusu boşaltıldı: %d kayıt kalıcı olarak silindi\n", silinen)
+		if korunan > 0 {
+			fmt.Printf("%d kayıt satış/çıkış geçmişi olduğu için çöp kutusunda bırakıldı\n", korunan)
+		}
+		return nil
+	default:
+		return fmt.Errorf("bilinmeyen cop komutu: %s (geri-al, sil veya bosalt)", fs.Arg(0))
+	}
+}
+
+// copKutusunuYazdir silinmiş kayıtları silinme zamanıyla yazdırır
+func copKutusunuYazdir(envanterService *services.EnvanterService, jsonCikti bool) error {
+	envanterler, err := envanterService.GetSilinenEnvanterler()
+	if err != nil {
+		return err
+	}
+
+	if jsonCikti {
+		type silinenKayit struct {
+			models.Envanter
+			SilinmeZamani time.Time `json:"silinme_zamani"`
+		}
+		kayitlar := make([]silinenKayit, 0, len(envanterler))
+		for _, e := range envanterler {
+			kayitlar = append(kayitlar, silinenKayit{Envanter: e, SilinmeZamani: e.DeletedAt.Time})
+		}
+		return jsonYaz(os.Stdout, map[string]interface{}{"silinenler": kayitlar})
+	}
+	if len(envanterler) == 0 {
+		fmt.Println("Çöp kutusu boş")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "ID\tKOD\tCİNS\tMİKTAR\tBİRİM\tALIŞ TARİHİ\tTOPLAM ALIŞ ₺\tSİLİNME\t")
+	for _, e := range envanterler {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			e.ID, e.Kod, e.Cins, e.Miktar, e.Birim, e.AlisTarihi.Format("02.01.2006"),
+			e.ToplamAlis.StringFixed(2), e.DeletedAt.Time.Format("02.01.2006 15:04"))
+	}
+	return tw.Flush()
+}
+
+// komutGecmis bir lotun veya tüm lotların değişiklik geçmişini denetim kaydından yazdırır
+func komutGecmis(args []string) error {
+	fs, jsonCikti := yeniFlagSet("gecmis")
+	limit := fs.Int("limit", 50, "ID verilmediğinde gösterilecek son değişiklik sayısı")
+	if err := parametreleriOku(fs, bayraklariOneAl(fs, args)); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("kullanım: altintakip gecmis [<id>]")
+	}
+
+	degisiklikService := services.NewDegisiklikKaydiService()
+	var kayitlar []models.DegisiklikKaydi
+	var err error
+	if fs.NArg() == 1 {
+		// Kalıcı silinen lotların geçmişi de korunduğundan lotun var olması gerekmez
+		id, parseErr := strconv.ParseUint(fs.Arg(0), 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("geçersiz ID: %s", fs.Arg(0))
+		}
+		kayitlar, err = degisiklikService.GetEnvanterGecmisi(uint(id))
+	} else {
+		if *limit <= 0 {
+			return fmt.Errorf("--limit sıfırdan büyük olmalı")
+		}
+		kayitlar, err = degisiklikService.GetSonDegisiklikler(*limit)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *jsonCikti {
+		cikti, err := services.DegisiklikGorunumleri(kayitlar)
+		if err != nil {
+			return err
+		}
+		return jsonYaz(os.Stdout, map[string]interface{}{"degisiklikler": cikti})
+	}
+	if len(kayitlar) == 0 {
+		fmt.Println("Değişiklik kaydı yok")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ZAMAN\tLOT\tTÜR\tKİM\tKAYNAK\tDEĞİŞİKLİK")
+	for i := range kayitlar {
+		k := &kayitlar[i]
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n",
+			k.Zaman.Format("02.01.2006 15:04:05"), k.EnvanterID, models.DegisiklikTurleri[k.Tur],
+			k.Kullanici, k.Kaynak, services.DegisiklikOzeti(k))
+	}
+	return tw.Flush()
+}
+
+// komutServe fiyat güncelleme döngüsünü TUI olmadan çalıştırır ve envanteri yerel HTTP/JSON API olarak sunar.
+// Kesme (Ctrl+C) veya SIGTERM ile açık istekler tamamlanıp veritabanı kapatılarak durur.
+func komutServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	adres := fs.String("adres", getEnv("SERVE_ADDR", "127.0.0.1:8787"), "dinlenecek adres")
+	aralik := fs.Duration("aralik", time.Minute, "fiyat güncelleme aralığı")
+	offline := fs.Bool("offline", false, "fiyatları güncelleme (sadece veritabanındaki veriler)")
+	if err := parametreleriOku(fs, args); err != nil {
+		return err
+	}
+	if *aralik < 10*time.Second {
+		return fmt.Errorf("--aralik en az 10s olmalı")
+	}
+
+	services.SetDegisiklikKaynagi("api")
+	token := os.Getenv("SERVE_TOKEN")
+	sunucu := api.NewSunucu(token, *offline)
+	httpSunucu := &http.Server{
+		Addr:              *adres,
+		Handler:           sunucu.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, durdur := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer durdur()
+
+	dinlemeHatasi := make(chan error, 1)
+	go func() {
+		dinlemeHatasi <- httpSunucu.ListenAndServe()
+	}()
+
+	guncellemeBitti := make(chan struct{})
+	go func() {
+		sunucu.GuncellemeDongusu(ctx, *aralik)
+		close(guncellemeBitti)
+	}()
+
+	logger.Infof("API sunucusu başlatıldı: http://%s", *adres)
+	fmt.Printf("API dinleniyor: http://%s/api (durdurmak için Ctrl+C)\n", *adres)
+	if token == "" && !strings.HasPrefix(*adres, "127.0.0.1:") && !strings.HasPrefix(*adres, "localhost:") {
+		fmt.Fprintln(os.Stderr, "UYARI: SERVE_TOKEN tanımlı değil; API ağdaki herkese açık olabilir")
+	}
+
+	var err error
+	select {
+	case err = <-dinlemeHatasi:
+		durdur()
+	case <-ctx.Done():
+		fmt.Println("Durduruluyor...")
+	}
+
+	kapatmaCtx, iptal := context.WithTimeout(context.Background(), 10*time.Second)
+	defer iptal()
+	if kapatmaHatasi := httpSunucu.Shutdown(kapatmaCtx); kapatmaHatasi != nil {
+		logger.Warnf("API sunucusu düzgün kapatılamadı: %v", kapatmaHatasi)
+	}
+	<-guncellemeBitti
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("API sunucusu başlatılamadı: %w", err)
+	}
+	logger.Infof("API sunucusu durduruldu")
+	return nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"altintakip/internal/database"
+
+	"golang.org/x/term"
+)
+
+// enKisaParolaUzunlugu yeni veritabanı parolası için en az karakter sayısı
+const enKisaParolaUzunlugu = 8
+
+// parolaDenemeSayisi terminalden yanlış girilen parola için deneme hakkı
+const parolaDenemeSayisi = 3
+
+// veritabaninaBaglan şifreleme etkinse parolayı alıp veritabanına bağlanır. Parola
+// DB_PASSPHRASE'den okunur, yoksa terminalden sorulur; yanlış parola tekrar sorulur.
+func veritabaninaBaglan() error {
+	etkin, mevcut, err := database.SifrelemeDurumu()
+	if err != nil {
+		return err
+	}
+	if !etkin {
+		return database.Connect()
+	}
+
+	for deneme := 1; ; deneme++ {
+		var parola []byte
+		if ortamParolasi := os.Getenv("DB_PASSPHRASE"); ortamParolasi != "" {
+			parola = []byte(ortamParolasi)
+		} else if mevcut {
+			parola, err = parolaOku("Veritabanı parolası: ")
+		} else {
+			fmt.Fprintln(os.Stderr, "Şifreli veritabanı oluşturuluyor, yeni bir parola belirleyin.")
+			parola, err = yeniParolaOku()
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := database.SetParola(parola); err != nil {
+			return err
+		}
+		err = database.Connect()
+		if errors.Is(err, database.ErrYanlisParola) && os.Getenv("DB_PASSPHRASE") == "" && deneme < parolaDenemeSayisi {
+			fmt.Fprintln(os.Stderr, "Parola yanlış, tekrar deneyin.")
+			continue
+		}
+		if err == nil {
+			for _, uyari := range database.CalismaUyarilari() {
+				fmt.Fprintf(os.Stderr, "UYARI: %s\n", uyari)
+			}
+		}
+		return err
+	}
+}
+
+// parolaOku terminalden ekrana yansıtmadan parola okur
+func parolaOku(istem string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("veritabanı şifreli: parola için DB_PASSPHRASE ayarlayın veya komutu terminalden çalıştırın")
+	}
+
+	fmt.Fprint(os.Stderr, istem)
+	parola, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("parola okunamadı: %w", err)
+	}
+	if len(parola) == 0 {
+		return nil, fmt.Errorf("parola boş olamaz")
+	}
+	return parola, nil
+}
+
+// yeniParolaOku yeni parolayı iki kez sorup eşleştiğini ve yeterince uzun olduğunu kontrol eder
+func yeniParolaOku() ([]byte, error) {
+	parola, err := parolaOku("Yeni parola: ")
+	if err != nil {
+		return nil, err
+	}
+	if len([]rune(string(parola))) < enKisaParolaUzunlugu {
+		return nil, fmt.Errorf("parola en az %d karakter olmalı", enKisaParolaUzunlugu)
+	}
+	tekrar, err := parolaOku("Yeni parola (tekrar): ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(parola, tekrar) {
+		return nil, fmt.Errorf("parolalar eşleşmiyor")
+	}
+	return parola, nil
+}
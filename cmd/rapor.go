@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"altintakip/internal/services"
+)
+
+// printRapor portföyün TL değerinin haftalık ve aylık değişimini yazdırır
+func printRapor(w io.Writer) {
+	portfoyGecmisi := services.NewPortfoyGecmisiService()
+
+	bolumler := []struct {
+		baslik    string
+		donemTipi string
+		adet      int
+	}{
+		{"HAFTALIK DEĞİŞİM (son 8 hafta)", services.DonemHafta, 8},
+		{"AYLIK DEĞİŞİM (son 12 ay)", services.DonemAy, 12},
+	}
+
+	for i, bolum := range bolumler {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, bolum.baslik)
+
+		donemler, err := portfoyGecmisi.GetDonemselDegisimler(bolum.donemTipi, bolum.adet)
+		if err != nil {
+			fmt.Fprintf(w, "HATA: %v\n", err)
+			continue
+		}
+		if len(donemler) == 0 {
+			fmt.Fprintln(w, "Henüz günlük özet yok")
+			continue
+		}
+
+		fmt.Fprintf(w, "%-10s %-10s %16s %16s %16s %9s\n", "DÖNEM", "SON GÜN", "ALIŞ ₺", "DEĞER ₺", "DEĞİŞİM ₺", "DEĞİŞİM")
+		for _, donem := range donemler {
+			fmt.Fprintf(w, "%-10s %-10s %16s %16s %16s %8s%%\n",
+				donem.Donem, donem.SonGun, donem.ToplamAlis.StringFixed(2), donem.GuncelTutar.StringFixed(2),
+				isaretli(donem.Degisim), isaretli(donem.DegisimYuzde))
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+	"altintakip/internal/services"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// alisTarihiFormatlari alış tarihi için kabul edilen formatlar
+var alisTarihiFormatlari = []string{time.RFC3339, "2006-01-02", "02.01.2006"}
+
+// envanterIstegi ekleme ve güncelleme isteklerinin gövdesi. Alan adları envanter
+// JSON'uyla aynıdır; güncellemede verilmeyen alanlar değişmez.
+type envanterIstegi struct {
+	Kod        *string          `json:"kod"`
+	Tur        *string          `json:"tur"`
+	Cins       *string          `json:"cins"`
+	Birim      *string          `json:"birim"`
+	Miktar     *decimal.Decimal `json:"miktar"`
+	AlisFiyati *decimal.Decimal `json:"alis_fiyati"`
+	AlisTarihi *string          `json:"alis_tarihi"` // RFC3339, YYYY-AA-GG veya GG.AA.YYYY
+	GirisTipi  *string          `json:"giris_tipi"`  // alis veya hediye_giris (yalnızca eklemede)
+	PortfoyID  *uint            `json:"portfoy_id"`
+	Notlar     *string          `json:"notlar"`
+}
+
+// uygula istekte verilen alanları envanter kaydına yazar
+func (istek *envanterIstegi) uygula(envanter *models.Envanter) error {
+	if istek.Kod != nil {
+		envanter.Kod = strings.ToUpper(strings.TrimSpace(*istek.Kod))
+	}
+	if istek.Tur != nil {
+		envanter.Tur = *istek.Tur
+	}
+	if istek.Cins != nil {
+		envanter.Cins = *istek.Cins
+	}
+	if istek.Birim != nil {
+		envanter.Birim = *istek.Birim
+	}
+	if istek.Miktar != nil {
+		envanter.Miktar = *istek.Miktar
+	}
+	if istek.AlisFiyati != nil {
+		envanter.AlisFiyati = *istek.AlisFiyati
+	}
+	if istek.AlisTarihi != nil {
+		tarih, err := alisTarihiCoz(*istek.AlisTarihi)
+		if err != nil {
+			return err
+		}
+		envanter.AlisTarihi = tarih
+	}
+	if istek.PortfoyID != nil {
+		envanter.PortfoyID = *istek.PortfoyID
+	}
+	if istek.Notlar != nil {
+		envanter.Notlar = *istek.Notlar
+	}
+	return nil
+}
+
+// alisTarihiCoz alış tarihini kabul edilen formatlardan biriyle çözer
+func alisTarihiCoz(metin string) (time.Time, error) {
+	for _, format := range alisTarihiFormatlari {
+		if tarih, err := time.ParseInLocation(format, metin, time.Local); err == nil {
+			return tarih, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("geçersiz alis_tarihi: %s (RFC3339, YYYY-AA-GG veya GG.AA.YYYY)", metin)
+}
+
+// envanterDogrula kaydedilecek envanterin alanlarını kontrol eder
+func envanterDogrula(envanter *models.Envanter) error {
+	if envanter.Kod == "" {
+		return fmt.Errorf("kod zorunlu")
+	}
+	if !envanter.Miktar.IsPositive() {
+		return fmt.Errorf("miktar sıfırdan büyük olmalı")
+	}
+	if envanter.AlisFiyati.IsNegative() || (envanter.AlisFiyati.IsZero() && envanter.GirisTipi != models.IslemHediyeGiris) {
+		return fmt.Errorf("alis_fiyati sıfırdan büyük olmalı")
+	}
+	if envanter.AlisTarihi.IsZero() {
+		return fmt.Errorf("alis_tarihi zorunlu")
+	}
+	if envanter.PortfoyID != 0 {
+		if _, err := services.NewPortfoyService().PortfoyBul(strconv.FormatUint(uint64(envanter.PortfoyID), 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// istegiOku istek gövdesindeki JSON'u çözer; bilinmeyen alanlar hatadır
+func istegiOku(w http.ResponseWriter, r *http.Request, hedef interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(hedef); err != nil {
+		return fmt.Errorf("geçersiz JSON: %w", err)
+	}
+	return nil
+}
+
+// envanterServisi ?portfoy= parametresiyle (ad veya ID) sınırlanmış envanter servisi oluşturur
+func envanterServisi(r *http.Request) (*services.EnvanterService, error) {
+	envanterService := services.NewEnvanterService()
+	if adVeyaID := strings.TrimSpace(r.URL.Query().Get("portfoy")); adVeyaID != "" {
+		portfoy, err := services.NewPortfoyService().PortfoyBul(adVeyaID)
+		if err != nil {
+			return nil, err
+		}
+		envanterService.SetPortfoy(portfoy.ID)
+	}
+	return envanterService, nil
+}
+
+// idCoz adresteki {id} değerini çözer
+func idCoz(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("geçersiz ID: %s", r.PathValue("id"))
+	}
+	return uint(id), nil
+}
+
+// envanterBul adresteki ID'ye ait envanter kaydını getirir; bulunamazsa 404 yazar
+func envanterBul(w http.ResponseWriter, r *http.Request) (*models.Envanter, bool) {
+	id, err := idCoz(r)
+	if err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	envanter, err := services.NewEnvanterService().GetEnvanterByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		hataYaz(w, http.StatusNotFound, fmt.Sprintf("envanter kaydı bulunamadı: ID %d", id))
+		return nil, false
+	}
+	if err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return envanter, true
+}
+
+// envanterListele açık envanter kayıtlarını döner (GET /api/envanter?portfoy=)
+func (s *Sunucu) envanterListele(w http.ResponseWriter, r *http.Request) {
+	envanterService, err := envanterServisi(r)
+	if err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	envanterler, err := envanterService.GetAllEnvanterFromDB()
+	if err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if envanterler == nil {
+		envanterler = []models.Envanter{}
+	}
+	jsonYaz(w, http.StatusOK, envanterler)
+}
+
+// envanterGetir tek envanter kaydını döner (GET /api/envanter/{id})
+func (s *Sunucu) envanterGetir(w http.ResponseWriter, r *http.Request) {
+	if envanter, ok := envanterBul(w, r); ok {
+		jsonYaz(w, http.StatusOK, envanter)
+	}
+}
+
+// envanterEkle yeni envanter kaydı ekler (POST /api/envanter). Tür, cins ve birim
+// verilmezse ürün kataloğundan bulunur; portfoy_id verilmezse ana portföye eklenir.
+func (s *Sunucu) envanterEkle(w http.ResponseWriter, r *http.Request) {
+	var istek envanterIstegi
+	if err := istegiOku(w, r, &istek); err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	envanter := models.Envanter{
+		GirisTipi:  models.IslemAlis,
+		AlisTarihi: time.Now(),
+	}
+	if err := istek.uygula(&envanter); err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if istek.GirisTipi != nil {
+		if *istek.GirisTipi != models.IslemAlis && *istek.GirisTipi != models.IslemHediyeGiris {
+			hataYaz(w, http.StatusBadRequest, fmt.Sprintf("geçersiz giris_tipi: %s (alis veya hediye_giris)", *istek.GirisTipi))
+			return
+		}
+		envanter.GirisTipi = *istek.GirisTipi
+	}
+
+	// Tür ve cins verilmemişse ürün kataloğundan bul
+	if envanter.Kod != "" && (envanter.Tur == "" || envanter.Cins == "") {
+		katalogTur, katalogCins, ok := models.UrunBul(envanter.Kod)
+		if !ok {
+			hataYaz(w, http.StatusBadRequest, fmt.Sprintf("bilinmeyen kod %s: tur ve cins belirtin", envanter.Kod))
+			return
+		}
+		if envanter.Tur == "" {
+			envanter.Tur = katalogTur
+		}
+		if envanter.Cins == "" {
+			envanter.Cins = katalogCins.Name
+		}
+	}
+	if envanter.Birim == "" {
+		envanter.Birim = models.VarsayilanBirim(envanter.Tur, envanter.Kod)
+	}
+
+	// Fiyatlar kilit tutulmadan çekilir; yeniden denemeler sürerken okumalar beklemez
+	fiyatlar := s.kayitFiyatlari()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := envanterDogrula(&envanter); err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := services.NewEnvanterService().AddEnvanterWithFiyatlar(&envanter, fiyatlar); err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonYaz(w, http.StatusCreated, envanter)
+}
+
+// envanterGuncelle envanter kaydını günceller (PUT /api/envanter/{id}); verilmeyen alanlar değişmez
+func (s *Sunucu) envanterGuncelle(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	envanter, ok := envanterBul(w, r)
+	s.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	var istek envanterIstegi
+	if err := istegiOku(w, r, &istek); err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Güncel fiyatı olmayan lot için fiyatlar kilit tutulmadan çekilir; kayıt kilit altında yeniden okunur
+	var fiyatlar *services.AltinFiyatlari
+	if envanter.GuncelFiyat.IsZero() {
+		fiyatlar = s.kayitFiyatlari()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if envanter, ok = envanterBul(w, r); !ok {
+		return
+	}
+	if istek.GirisTipi != nil && *istek.GirisTipi != envanter.GirisTipi {
+		hataYaz(w, http.StatusBadRequest, "giris_tipi değiştirilemez")
+		return
+	}
+	if err := istek.uygula(envanter); err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := envanterDogrula(envanter); err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := services.NewEnvanterService().UpdateEnvanterWithFiyatlar(envanter, fiyatlar); err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonYaz(w, http.StatusOK, envanter)
+}
+
+// kayitFiyatlari eklenen/düzenlenen lotun güncel fiyatı için fiyat görüntüsünü çeker; veritabanı kilidi
+// tutulmadan çağrılmalıdır. Liste modunda veya fiyatlar alınamazsa nil döner (lot fiyatsız kaydedilir).
+func (s *Sunucu) kayitFiyatlari() *services.AltinFiyatlari {
+	if s.isListMode {
+		return nil
+	}
+	fiyatlar, err := services.NewEnvanterService().GetFiyatlar()
+	if err != nil {
+		logger.Warnf("Güncel fiyat alınamadı, sadece alış bilgileri kaydediliyor: %v", err)
+		return nil
+	}
+	return fiyatlar
+}
+
+// envanterSil envanter kaydını siler (DELETE /api/envanter/{id})
+func (s *Sunucu) envanterSil(w http.ResponseWriter, r *http.Request) {
+	envanter, ok := envanterBul(w, r)
+	if !ok {
+		return
+	}
+	if err := services.NewEnvanterService().DeleteEnvanter(envanter.ID); err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonYaz(w, http.StatusOK, map[string]interface{}{"silinen": envanter})
+}
+
+// envanterGecmisi lotun denetim kaydındaki değişikliklerini eskiden yeniye döner (GET /api/envanter/{id}/gecmis).
+// Kalıcı silinen lotların geçmişi de korunduğundan lotun var olması gerekmez.
+func (s *Sunucu) envanterGecmisi(w http.ResponseWriter, r *http.Request) {
+	id, err := idCoz(r)
+	if err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	kayitlar, err := services.NewDegisiklikKaydiService().GetEnvanterGecmisi(id)
+	if err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	gorunumler, err := services.DegisiklikGorunumleri(kayitlar)
+	if err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonYaz(w, http.StatusOK, gorunumler)
+}
+
+// gruplar kod bazlı grup toplamlarını döner (GET /api/gruplar?portfoy=)
+func (s *Sunucu) gruplar(w http.ResponseWriter, r *http.Request) {
+	envanterService, err := envanterServisi(r)
+	if err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	gruplar, err := envanterService.GetKodBazliGruplar()
+	if err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonYaz(w, http.StatusOK, gruplar)
+}
+
+// ozet toplam değerleri döner (GET /api/ozet?portfoy=)
+func (s *Sunucu) ozet(w http.ResponseWriter, r *http.Request) {
+	envanterService, err := envanterServisi(r)
+	if err != nil {
+		hataYaz(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	toplamlar, err := envanterService.GetToplamDegerler()
+	if err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonYaz(w, http.StatusOK, toplamlar)
+}
+
+// fiyatlar her kodun veritabanındaki son fiyatını döner (GET /api/fiyatlar). Fiyatlar
+// güncelleme döngüsünde çekilir; istek başına fiyat sağlayıcısına gidilmez.
+func (s *Sunucu) fiyatlar(w http.ResponseWriter, r *http.Request) {
+	sonFiyatlar, err := services.NewFiyatGecmisiService().GetSonFiyatlar()
+	if err != nil {
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fiyatlar := make([]models.FiyatGecmisi, 0, len(sonFiyatlar))
+	for _, fiyat := range sonFiyatlar {
+		fiyatlar = append(fiyatlar, fiyat)
+	}
+	sort.Slice(fiyatlar, func(i, j int) bool { return fiyatlar[i].Kod < fiyatlar[j].Kod })
+
+	s.durumMu.Lock()
+	sonGuncelleme := s.durum.SonGuncelleme
+	s.durumMu.Unlock()
+
+	jsonYaz(w, http.StatusOK, map[string]interface{}{
+		"son_guncelleme": sonGuncelleme,
+		"fiyatlar":       fiyatlar,
+	})
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"altintakip/internal/logger"
+	"altintakip/internal/metrik"
+	"altintakip/internal/services"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fiyat güncelleme döngüsü metrikleri
+var guncellemeler = metrik.NewSayac("altintakip_guncellemeler_total",
+	"Fiyat güncelleme denemeleri; sonuc basarili veya hata", "sonuc")
+
+// metrikler Prometheus metin formatında fiyat API'si, güncelleme ve kod bazlı portföy
+// metriklerini döner (GET /metrics). Portföy değerleri istek anında veritabanından hesaplanır.
+func (s *Sunucu) metrikler(w http.ResponseWriter, r *http.Request) {
+	fiyat := metrik.NewGosterge("altintakip_fiyat_tl", "Kodun veritabanındaki son fiyatı; alan alis (kuyumcu alış) veya satis", "kod", "alan")
+	miktar := metrik.NewGosterge("altintakip_miktar", "Kodun elde tutulan toplam miktarı (gram veya adet)", "portfoy", "kod", "birim")
+	alisTutari := metrik.NewGosterge("altintakip_alis_tutari_tl", "Açık lotların toplam alış tutarı", "portfoy", "kod")
+	guncelDeger := metrik.NewGosterge("altintakip_guncel_deger_tl", "Açık lotların güncel değeri", "portfoy", "kod")
+	karZarar := metrik.NewGosterge("altintakip_kar_zarar_tl", "Açık lotların gerçekleşmemiş kar/zararı", "portfoy", "kod")
+	gerceklesen := metrik.NewGosterge("altintakip_gerceklesen_kar_zarar_tl", "Satışlardan gerçekleşen kar/zarar", "portfoy", "kod")
+
+	sonFiyatlar, err := services.NewFiyatGecmisiService().GetSonFiyatlar()
+	if err != nil {
+		logger.Errorf("Metrikler için fiyatlar okunamadı: %v", err)
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	kodlar := make([]string, 0, len(sonFiyatlar))
+	for kod := range sonFiyatlar {
+		kodlar = append(kodlar, kod)
+	}
+	sort.Strings(kodlar)
+	for _, kod := range kodlar {
+		fiyat.Ayarla(sonFiyatlar[kod].Alis, kod, "alis")
+		fiyat.Ayarla(sonFiyatlar[kod].Satis, kod, "satis")
+	}
+
+	portfoylar, err := services.NewPortfoyService().GetPortfoyler()
+	if err != nil {
+		logger.Errorf("Metrikler için portföyler okunamadı: %v", err)
+		hataYaz(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, portfoy := range portfoylar {
+		envanterService := services.NewEnvanterService()
+		envanterService.SetPortfoy(portfoy.ID)
+		gruplar, err := envanterService.GetKodBazliGruplar()
+		if err != nil {
+			logger.Errorf("Metrikler için %s portföyü okunamadı: %v", portfoy.Ad, err)
+			hataYaz(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		grupKodlari := make([]string, 0, len(gruplar))
+		for kod := range gruplar {
+			grupKodlari = append(grupKodlari, kod)
+		}
+		sort.Strings(grupKodlari)
+		for _, kod := range grupKodlari {
+			grup := gruplar[kod]
+			tutar := func(alan string) float64 { return grup[alan].(decimal.Decimal).InexactFloat64() }
+			miktar.Ayarla(tutar("toplam_miktar"), portfoy.Ad, kod, grup["birim"].(string))
+			alisTutari.Ayarla(tutar("toplam_alis_tutar"), portfoy.Ad, kod)
+			guncelDeger.Ayarla(tutar("toplam_guncel_tutar"), portfoy.Ad, kod)
+			karZarar.Ayarla(tutar("gerceklesmemis_kar_zarar"), portfoy.Ad, kod)
+			gerceklesen.Ayarla(tutar("gerceklesen_kar_zarar"), portfoy.Ad, kod)
+		}
+	}
+
+	s.durumMu.Lock()
+	durum := s.durum
+	s.durumMu.Unlock()
+	sonGuncelleme := metrik.NewGosterge("altintakip_son_guncelleme_timestamp_seconds", "Son başarılı fiyat güncellemesinin Unix zamanı (0: henüz yok)")
+	sonGuncellemeZamani := 0.0
+	if durum.SonGuncelleme != nil {
+		sonGuncellemeZamani = float64(durum.SonGuncelleme.Unix())
+	}
+	sonGuncelleme.Ayarla(sonGuncellemeZamani)
+	sonDenemeBasarili := metrik.NewGosterge("altintakip_son_guncelleme_basarili", "Son fiyat güncelleme denemesi başarılıysa 1, değilse 0")
+	basarili := 0.0
+	if durum.SonDeneme != nil && durum.SonHata == "" {
+		basarili = 1
+	}
+	sonDenemeBasarili.Ayarla(basarili)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrik.Yaz(w)
+	for _, gosterge := range []*metrik.Gosterge{fiyat, miktar, alisTutari, guncelDeger, karZarar, gerceklesen, sonGuncelleme, sonDenemeBasarili} {
+		gosterge.Yaz(w)
+	}
+}
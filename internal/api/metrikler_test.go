@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"altintakip/internal/models"
+)
+
+// ornekSatiri Prometheus metin formatında bir örnek satırı: ad, isteğe bağlı etiketler, değer
+var ornekSatiri = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{([a-zA-Z_][a-zA-Z0-9_]*="([^"\\\n]|\\[\\"n])*",?)*\})? (\S+)$`)
+
+func TestMetrikler(t *testing.T) {
+	h := testSunucusu(t)
+	if durum, govde := istekGonder(t, h, http.MethodPost, "/api/envanter",
+		`{"kod": "C", "miktar": "2", "alis_fiyati": "6500", "alis_tarihi": "2025-03-01"}`); durum != http.StatusCreated {
+		t.Fatalf("ekleme durumu = %d: %s", durum, govde)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("durum = %d: %s", w.Code, w.Body.String())
+	}
+	if tip := w.Header().Get("Content-Type"); !strings.HasPrefix(tip, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", tip)
+	}
+
+	// Her örnekten önce metriğin HELP ve TYPE satırları gelir; histogram örnekleri _bucket, _sum, _count ekiyle yazılır
+	tipler := map[string]string{}
+	yardimlar := map[string]bool{}
+	for _, satir := range strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n") {
+		if ad, ok := strings.CutPrefix(satir, "# HELP "); ok {
+			yardimlar[strings.SplitN(ad, " ", 2)[0]] = true
+			continue
+		}
+		if tanim, ok := strings.CutPrefix(satir, "# TYPE "); ok {
+			parcalar := strings.Fields(tanim)
+			if len(parcalar) != 2 || !yardimlar[parcalar[0]] {
+				t.Errorf("TYPE satırı HELP satırından önce veya bozuk: %q", satir)
+				continue
+			}
+			tipler[parcalar[0]] = parcalar[1]
+			continue
+		}
+		eslesme := ornekSatiri.FindStringSubmatch(satir)
+		if eslesme == nil {
+			t.Errorf("geçersiz örnek satırı: %q", satir)
+			continue
+		}
+		ad := eslesme[1]
+		if tip, ok := tipler[ad]; ok {
+			if tip == "histogram" {
+				t.Errorf("histogram örneği eksiz yazıldı: %q", satir)
+			}
+			continue
+		}
+		taban := ad
+		for _, ek := range []string{"_bucket", "_sum", "_count"} {
+			taban = strings.TrimSuffix(taban, ek)
+		}
+		if tipler[taban] != "histogram" {
+			t.Errorf("örnek satırının TYPE tanımı yok: %q", satir)
+		}
+	}
+
+	beklenenTipler := map[string]string{
+		"altintakip_guncellemeler_total":              "counter",
+		"altintakip_fiyat_istek_sure_seconds":         "histogram",
+		"altintakip_miktar":                           "gauge",
+		"altintakip_son_guncelleme_timestamp_seconds": "gauge",
+	}
+	for ad, tip := range beklenenTipler {
+		if tipler[ad] != tip {
+			t.Errorf("%s tipi = %q, beklenen %q", ad, tipler[ad], tip)
+		}
+	}
+	for _, satir := range []string{
+		`altintakip_miktar{portfoy="` + models.VarsayilanPortfoyAdi + `",kod="C",birim="adet"} 2`,
+		`altintakip_alis_tutari_tl{portfoy="` + models.VarsayilanPortfoyAdi + `",kod="C"} 13000`,
+		`altintakip_son_guncelleme_basarili 0`,
+	} {
+		if !strings.Contains(w.Body.String(), satir+"\n") {
+			t.Errorf("çıktıda %q yok:\n%s", satir, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"altintakip/internal/logger"
+	"altintakip/internal/services"
+)
+
+// Sunucu fiyat güncelleme döngüsünü çalıştıran ve EnvanterService'i yerel REST API olarak sunan
+// arka plan servisi. Veritabanına tek süreç eriştiğinden diğer araçlar SQLite dosyasını
+// doğrudan açmak yerine bu API'yi kullanır.
+type Sunucu struct {
+	token      string // Boş değilse istekler "Authorization: Bearer <token>" taşımalıdır
+	isListMode bool   // Fiyat güncellenmez, eklemede API'den fiyat çekilmez
+
+	// Okumalar paylaşımlı, yazmalar ve fiyat güncellemesi tek başına çalışır
+	mu sync.RWMutex
+
+	durumMu sync.Mutex
+	durum   Durum
+}
+
+// Durum servisin ve son fiyat güncellemesinin durumu
+type Durum struct {
+	Baslangic        time.Time             `json:"baslangic"`
+	Offline          bool                  `json:"offline"`
+	SonGuncelleme    *time.Time            `json:"son_guncelleme,omitempty"` // Son başarılı fiyat güncellemesi
+	SonDeneme        *time.Time            `json:"son_deneme,omitempty"`
+	SonHata          string                `json:"son_hata,omitempty"`      // Son denemenin hatası (başarılıysa boş)
+	FiyatZamani      *time.Time            `json:"fiyat_zamani,omitempty"`  // Gösterilen en eski fiyatın çekilme zamanı
+	EksikGruplar     []string              `json:"eksik_gruplar,omitempty"` // Son güncellemede alınamayıp önbellekten kullanılan gruplar
+	SonAlarmlar      []services.AlarmOlayi `json:"son_alarmlar,omitempty"`
+	GuncellemeSayisi int                   `json:"guncelleme_sayisi"`
+}
+
+// NewSunucu yeni API sunucusu oluşturur
+func NewSunucu(token string, isListMode bool) *Sunucu {
+	return &Sunucu{
+		token:      token,
+		isListMode: isListMode,
+		durum: Durum{
+			Baslangic: time.Now(),
+			Offline:   isListMode,
+		},
+	}
+}
+
+// Handler API ve Prometheus /metrics uç noktalarını içeren HTTP handler'ı döner
+func (s *Sunucu) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/envanter", s.okuma(s.envanterListele))
+	// Ekleme ve güncelleme fiyatları kilit tutulmadan çektiğinden kilidi kendileri alır
+	mux.HandleFunc("POST /api/envanter", s.envanterEkle)
+	mux.HandleFunc("GET /api/envanter/{id}", s.okuma(s.envanterGetir))
+	mux.HandleFunc("PUT /api/envanter/{id}", s.envanterGuncelle)
+	mux.HandleFunc("DELETE /api/envanter/{id}", s.yazma(s.envanterSil))
+	mux.HandleFunc("GET /api/envanter/{id}/gecmis", s.okuma(s.envanterGecmisi))
+	mux.HandleFunc("GET /api/gruplar", s.okuma(s.gruplar))
+	mux.HandleFunc("GET /api/ozet", s.okuma(s.ozet))
+	mux.HandleFunc("GET /api/fiyatlar", s.okuma(s.fiyatlar))
+	mux.HandleFunc("GET /api/durum", s.durumGetir)
+	mux.HandleFunc("GET /metrics", s.okuma(s.metrikler))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		hataYaz(w, http.StatusNotFound, "bilinmeyen adres: "+r.URL.Path)
+	})
+	return s.yetkiKontrolu(mux)
+}
+
+// GuncellemeDongusu fiyatları hemen ve ardından her aralıkta günceller; ctx iptal edilince döner
+func (s *Sunucu) GuncellemeDongusu(ctx context.Context, aralik time.Duration) {
+	if s.isListMode {
+		logger.Infof("Liste modu: fiyat güncelleme döngüsü çalışmayacak")
+		return
+	}
+
+	s.Guncelle()
+	ticker := time.NewTicker(aralik)
+	defer ticker.Stop()
+	logger.Infof("Otomatik fiyat güncelleme başlatıldı (%s aralıkla)", aralik)
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Guncelle()
+		case <-ctx.Done():
+			logger.Infof("Otomatik fiyat güncelleme durduruldu")
+			return
+		}
+	}
+}
+
+// Guncelle güncel fiyatları çekip kayıtlara yazar ve alarmları değerlendirir. Fiyatlar ve alarm
+// bildirimleri (komut, webhook) kilit tutulmadan çalışır; okumalar yalnızca fiyatlar ve alarm
+// durumları yazılırken bekler.
+func (s *Sunucu) Guncelle() {
+	envanterService := services.NewEnvanterService()
+	alarmService := services.NewAlarmService()
+	fiyatlar, err := envanterService.GuncelFiyatlariCek()
+	var olaylar []services.AlarmOlayi
+	if err == nil {
+		s.mu.Lock()
+		err = envanterService.GuncelFiyatlariUygula(fiyatlar)
+		if err == nil {
+			var alarmHatasi error
+			if olaylar, alarmHatasi = alarmService.DurumlariGuncelle(); alarmHatasi != nil {
+				logger.Errorf("Alarmlar değerlendirilemedi: %v", alarmHatasi)
+			}
+		}
+		s.mu.Unlock()
+		alarmService.Bildir(olaylar)
+	}
+
+	simdi := time.Now()
+	s.durumMu.Lock()
+	defer s.durumMu.Unlock()
+	s.durum.SonDeneme = &simdi
+	if err != nil {
+		logger.Warnf("Otomatik fiyat güncelleme başarısız: %v", err)
+		guncellemeler.Artir("hata")
+		s.durum.SonHata = err.Error()
+		return
+	}
+	s.durum.SonGuncelleme = &simdi
+	s.durum.SonHata = ""
+	if fiyatlar := envanterService.SonFiyatlar(); fiyatlar != nil {
+		fiyatZamani := fiyatlar.EnEskiZaman()
+		s.durum.FiyatZamani = &fiyatZamani
+		s.durum.EksikGruplar = fiyatlar.Eksikler
+	}
+	s.durum.GuncellemeSayisi++
+	guncellemeler.Artir("basarili")
+	if len(olaylar) > 0 {
+		s.durum.SonAlarmlar = olaylar
+	}
+}
+
+// okuma handler'ı diğer okumalarla eşzamanlı, yazmalar ve güncellemeyle sıralı çalıştırır
+func (s *Sunucu) okuma(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		handler(w, r)
+	}
+}
+
+// yazma handler'ı veritabanına tek başına erişerek çalıştırır
+func (s *Sunucu) yazma(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		handler(w, r)
+	}
+}
+
+// yetkiKontrolu token tanımlıysa Bearer token'ı doğrular ve istekleri loglar
+func (s *Sunucu) yetkiKontrolu(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debugf("API isteği: %s %s (%s)", r.Method, r.URL.RequestURI(), r.RemoteAddr)
+		if s.token != "" {
+			verilen := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(verilen), []byte(s.token)) != 1 {
+				hataYaz(w, http.StatusUnauthorized, "geçersiz veya eksik token")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// durumGetir servis ve son güncelleme durumunu döner
+func (s *Sunucu) durumGetir(w http.ResponseWriter, r *http.Request) {
+	s.durumMu.Lock()
+	durum := s.durum
+	s.durumMu.Unlock()
+	jsonYaz(w, http.StatusOK, durum)
+}
+
+// jsonYaz değeri verilen durum koduyla girintili JSON olarak yazar
+func jsonYaz(w http.ResponseWriter, durumKodu int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(durumKodu)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		logger.Warnf("API yanıtı yazılamadı: %v", err)
+	}
+}
+
+// hataYaz hatayı {"hata": "..."} gövdesiyle yazar
+func hataYaz(w http.ResponseWriter, durumKodu int, mesaj string) {
+	jsonYaz(w, durumKodu, map[string]string{"hata": mesaj})
+}
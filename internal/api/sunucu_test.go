@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"altintakip/internal/database"
+	"altintakip/internal/models"
+)
+
+// testToken testlerde sunucuya verilen Bearer token
+const testToken = "gizli-token"
+
+// testSunucusu geçici bir veritabanıyla liste modunda (fiyat çekmeyen) API handler'ı oluşturur
+func testSunucusu(t *testing.T) http.Handler {
+	t.Helper()
+	dizin := t.TempDir()
+	t.Setenv("APP_DATA_DIR", dizin)
+	t.Setenv("DB_PATH", filepath.Join(dizin, "test.db"))
+	t.Setenv("BACKUP_RETENTION", "0")
+	t.Setenv("VALUATION_MODE", "")
+
+	if err := database.Connect(); err != nil {
+		t.Fatalf("veritabanı açılamadı: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("migrasyon: %v", err)
+	}
+	return NewSunucu(testToken, true).Handler()
+}
+
+// istekGonder handler'a token'lı istek gönderir; durum kodunu ve gövdeyi döner
+func istekGonder(t *testing.T, h http.Handler, metot, yol, govde string) (int, string) {
+	t.Helper()
+	r := httptest.NewRequest(metot, yol, strings.NewReader(govde))
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code, w.Body.String()
+}
+
+func TestYetkiKontrolu(t *testing.T) {
+	h := testSunucusu(t)
+
+	testler := []struct {
+		ad, baslik string
+		durum      int
+	}{
+		{"token yok", "", http.StatusUnauthorized},
+		{"yanlış token", "Bearer baska-token", http.StatusUnauthorized},
+		{"Bearer öneki yok", testToken + "x", http.StatusUnauthorized},
+		{"doğru token", "Bearer " + testToken, http.StatusOK},
+	}
+	for _, yol := range []string{"/api/envanter", "/metrics"} {
+		for _, tt := range testler {
+			r := httptest.NewRequest(http.MethodGet, yol, nil)
+			if tt.baslik != "" {
+				r.Header.Set("Authorization", tt.baslik)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.durum {
+				t.Errorf("%s %s: durum = %d, beklenen %d", yol, tt.ad, w.Code, tt.durum)
+			}
+		}
+	}
+}
+
+func TestEnvanterUclari(t *testing.T) {
+	h := testSunucusu(t)
+
+	durum, govde := istekGonder(t, h, http.MethodPost, "/api/envanter",
+		`{"kod": "c", "miktar": "2", "alis_fiyati": "6500", "alis_tarihi": "2025-03-01"}`)
+	if durum != http.StatusCreated {
+		t.Fatalf("ekleme durumu = %d: %s", durum, govde)
+	}
+	var eklenen models.Envanter
+	if err := json.Unmarshal([]byte(govde), &eklenen); err != nil {
+		t.Fatal(err)
+	}
+	if eklenen.Kod != "C" || eklenen.Cins != "Çeyrek" || eklenen.Birim != "adet" || eklenen.ToplamAlis.String() != "13000" {
+		t.Errorf("eklenen lot beklenmedik: %+v", eklenen)
+	}
+	lotYolu := fmt.Sprintf("/api/envanter/%d", eklenen.ID)
+
+	testler := []struct {
+		ad, metot, yol, govde string
+		durum                 int
+		icerik                string
+	}{
+		{"liste", http.MethodGet, "/api/envanter", "", http.StatusOK, `"kod": "C"`},
+		{"tek kayıt", http.MethodGet, lotYolu, "", http.StatusOK, `"toplam_alis": "13000"`},
+		{"güncelleme", http.MethodPut, lotYolu, `{"miktar": "3", "notlar": "kasa"}`, http.StatusOK, `"toplam_alis": "19500"`},
+		{"geçmiş", http.MethodGet, lotYolu + "/gecmis", "", http.StatusOK, `"notlar"`},
+		{"gruplar", http.MethodGet, "/api/gruplar", "", http.StatusOK, `"C"`},
+		{"özet", http.MethodGet, "/api/ozet", "", http.StatusOK, `"toplam_alis"`},
+		{"portföy süzgeci", http.MethodGet, "/api/envanter?portfoy=" + url.QueryEscape(models.VarsayilanPortfoyAdi), "", http.StatusOK, `"kod": "C"`},
+		{"fiyatlar", http.MethodGet, "/api/fiyatlar", "", http.StatusOK, `"fiyatlar": []`},
+		{"durum", http.MethodGet, "/api/durum", "", http.StatusOK, `"offline": true`},
+
+		{"eklemede bilinmeyen alan", http.MethodPost, "/api/envanter", `{"kod": "C", "miktar": "1", "alis_fiyati": "1", "fiyat": "1"}`, http.StatusBadRequest, `unknown field \"fiyat\"`},
+		{"güncellemede bilinmeyen alan", http.MethodPut, lotYolu, `{"adet": "1"}`, http.StatusBadRequest, `unknown field \"adet\"`},
+		{"bozuk JSON", http.MethodPost, "/api/envanter", `{"kod": `, http.StatusBadRequest, "geçersiz JSON"},
+		{"bilinmeyen kod", http.MethodPost, "/api/envanter", `{"kod": "XYZ", "miktar": "1", "alis_fiyati": "1"}`, http.StatusBadRequest, "bilinmeyen kod"},
+		{"sıfır miktar", http.MethodPut, lotYolu, `{"miktar": "0"}`, http.StatusBadRequest, "miktar"},
+		{"giriş tipi değişmez", http.MethodPut, lotYolu, `{"giris_tipi": "hediye_giris"}`, http.StatusBadRequest, "giris_tipi"},
+		{"bilinmeyen portföy", http.MethodGet, "/api/envanter?portfoy=yok", "", http.StatusBadRequest, `"hata"`},
+		{"geçersiz ID", http.MethodGet, "/api/envanter/abc", "", http.StatusBadRequest, "geçersiz ID"},
+
+		{"olmayan kayıt", http.MethodGet, "/api/envanter/999", "", http.StatusNotFound, "bulunamadı"},
+		{"olmayan kaydı güncelleme", http.MethodPut, "/api/envanter/999", `{"notlar": "x"}`, http.StatusNotFound, "bulunamadı"},
+		{"olmayan kaydı silme", http.MethodDelete, "/api/envanter/999", "", http.StatusNotFound, "bulunamadı"},
+		{"bilinmeyen adres", http.MethodGet, "/api/yok", "", http.StatusNotFound, "bilinmeyen adres"},
+	}
+	for _, tt := range testler {
+		t.Run(tt.ad, func(t *testing.T) {
+			durum, govde := istekGonder(t, h, tt.metot, tt.yol, tt.govde)
+			if durum != tt.durum {
+				t.Fatalf("durum = %d, beklenen %d: %s", durum, tt.durum, govde)
+			}
+			if !strings.Contains(govde, tt.icerik) {
+				t.Errorf("yanıt %q içermiyor: %s", tt.icerik, govde)
+			}
+		})
+	}
+
+	if durum, govde := istekGonder(t, h, http.MethodDelete, lotYolu, ""); durum != http.StatusOK || !strings.Contains(govde, `"silinen"`) {
+		t.Fatalf("silme durumu = %d: %s", durum, govde)
+	}
+	if durum, _ := istekGonder(t, h, http.MethodGet, lotYolu, ""); durum != http.StatusNotFound {
+		t.Errorf("silinen kayıt durumu = %d, beklenen 404", durum)
+	}
+	if durum, govde := istekGonder(t, h, http.MethodGet, "/api/envanter", ""); durum != http.StatusOK || strings.TrimSpace(govde) != "[]" {
+		t.Errorf("silme sonrası liste = %d: %s", durum, govde)
+	}
+}
@@ -1,34 +1,56 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
-	"altintakip/internal/models"
+	"altintakip/internal/logger"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 // DB global veritabanı bağlantısı
 var DB *gorm.DB
 
-// Connect veritabanı bağlantısını kurar
-func Connect() error {
+// dbYolu veritabanı dosyasının yapılandırılan yolu (yedek adları ve dizini buna göre belirlenir)
+var dbYolu string
+
+// acikYol SQLite'ın açtığı dosya; şifreli modda çözülmüş çalışma kopyası, aksi halde dbYolu
+var acikYol string
+
+// VeriDizini uygulama veri dizinini döner (APP_DATA_DIR, varsayılan: ~/altintakip)
+func VeriDizini() (string, error) {
 	// Kullanıcı dizininde altintakip klasörünü varsayılan yol olarak kullan
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("kullanıcı dizini alınamadı: %w", err)
+		return "", fmt.Errorf("kullanıcı dizini alınamadı: %w", err)
 	}
 
-	appDataDir := getEnv("APP_DATA_DIR", filepath.Join(homeDir, "altintakip"))
-	defaultDBPath := filepath.Join(appDataDir, "altintakip.db")
+	return getEnv("APP_DATA_DIR", filepath.Join(homeDir, "altintakip")), nil
+}
+
+// veritabaniYolu SQLite veritabanı dosyasının yolunu döner (DB_PATH, varsayılan: veri dizininde altintakip.db)
+func veritabaniYolu() (string, error) {
+	appDataDir, err := VeriDizini()
+	if err != nil {
+		return "", err
+	}
+	return getEnv("DB_PATH", filepath.Join(appDataDir, "altintakip.db")), nil
+}
 
-	// SQLite veritabanı dosyasının yolu
-	dbPath := getEnv("DB_PATH", defaultDBPath)
+// Connect veritabanı bağlantısını kurar. SetParola ile parola verildiyse şifreli veritabanı
+// geçici bir çalışma kopyasına çözülerek açılır.
+func Connect() error {
+	dbPath, err := veritabaniYolu()
+	if err != nil {
+		return err
+	}
 
 	// Veritabanı dosyasının dizinini oluştur
 	dbDir := filepath.Dir(dbPath)
@@ -37,40 +59,70 @@ func Connect() error {
 			return fmt.Errorf("veritabanı dizini oluşturulamadı: %w", err)
 		}
 	}
+	dbYolu = dbPath
 
-	var dbErr error
-	DB, dbErr = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Error),
-	})
-	if dbErr != nil {
-		return fmt.Errorf("SQLite veritabanı bağlantısı kurulamadı: %w", dbErr)
+	yol := dbPath
+	if sifre != nil {
+		if yol, err = sifreliAc(); err != nil {
+			calismaKopyasiniSil()
+			return err
+		}
+	} else if _, err := os.Stat(dbPath + sifreliUzanti); err == nil {
+		return fmt.Errorf("veritabanı şifreli, parola verilmedi: %s", dbPath+sifreliUzanti)
 	}
 
-	log.Printf("SQLite veritabanı bağlantısı başarılı: %s", dbPath)
+	if err := ac(yol); err != nil {
+		calismaKopyasiniSil()
+		return err
+	}
+	logger.Infof("SQLite veritabanı bağlantısı başarılı: %s", dbPath)
 	return nil
 }
 
-// Migrate veritabanı tablolarını oluşturur/günceller
-func Migrate() error {
-	if DB == nil {
-		return fmt.Errorf("veritabanı bağlantısı kurulmamış")
-	}
-
-	err := DB.AutoMigrate(&models.Envanter{})
-	if err != nil {
-		return fmt.Errorf("veritabanı migrasyonu başarısız: %w", err)
+// ac verilen SQLite dosyasına bağlanır
+func ac(yol string) error {
+	var dbErr error
+	DB, dbErr = gorm.Open(sqlite.Open(yol), &gorm.Config{
+		// SQL hataları stdout yerine uygulama log dosyasına yazılır (CLI JSON çıktısını bozmamak için)
+		Logger: gormlogger.New(log.New(log.Writer(), "\r\n", log.LstdFlags), gormlogger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormlogger.Error,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
+	if dbErr != nil {
+		return fmt.Errorf("SQLite veritabanı bağlantısı kurulamadı: %w", dbErr)
 	}
 
-	log.Println("Veritabanı migrasyonu tamamlandı")
+	acikYol = yol
 	return nil
 }
 
-// Close veritabanı bağlantısını kapatır
+// Close veritabanı bağlantısını kapatır. Şifreli modda önce değişiklikler şifreli dosyaya
+// yazılır, sonra çözülmüş çalışma kopyası silinir; değişiklikler hiçbir yere yazılamadıysa
+// kopya silinmez ve yolu hatada bildirilir.
 func Close() error {
 	if DB == nil {
 		return nil
 	}
 
+	if err := sifreliKaydet(false); err != nil {
+		baglantiyiKapat()
+		if errors.Is(err, ErrSifreliDosyaDegisti) {
+			// Değişiklikler çakışma dosyasına yazıldı
+			calismaKopyasiniSil()
+			return err
+		}
+		calismaKopyasiniKoru()
+		return fmt.Errorf("%w (çözülmüş kopya: %s)", err, acikYol)
+	}
+	err := baglantiyiKapat()
+	calismaKopyasiniSil()
+	return err
+}
+
+// baglantiyiKapat SQLite bağlantısını kapatır
+func baglantiyiKapat() error {
 	sqlDB, err := DB.DB()
 	if err != nil {
 		return err
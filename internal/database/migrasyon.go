@@ -0,0 +1,304 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Migrasyon numaralı bir şema adımı. Uygulanmış adımlar değiştirilmez; şemadaki her
+// değişiklik (yeni tablo, alan, veri dönüşümü) listenin sonuna yeni bir adım olarak eklenir.
+// Adımlar models paketindeki güncel yapıları değil, migrasyon_semalari.go'daki dondurulmuş
+// görüntüleri kullanır.
+type Migrasyon struct {
+	Surum    int
+	Aciklama string
+	Yikici   bool // Mevcut veriyi değiştiren/silen adımlardan önce veritabanı yedeklenir
+	Up       func(tx *gorm.DB) error
+}
+
+// migrasyonlar sürüm sırasına göre tüm şema adımları
+var migrasyonlar = []Migrasyon{
+	{
+		Surum:    1,
+		Aciklama: "Temel şema: envanter, islemler, maliyet_yontemleri, fiyat_gecmisi, portfoy_gunluk",
+		Up: func(tx *gorm.DB) error {
+			// Sürüm takibinden önceki son şema. Yeni dosyada tablolar oluşturulur; daha eski sürümlerin
+			// dosyalarına eksik tablo ve sütunlar (ör. giris_tipi, islemler) eklenir
+			return tx.AutoMigrate(&envanterV1{}, &islemV1{}, &maliyetYontemiV1{}, &fiyatGecmisiV1{}, &portfoyGunlukV1{})
+		},
+	},
+	{
+		Surum:    2,
+		Aciklama: "Tutar ve miktar sütunları kayan noktadan tam ondalıklı metne",
+		Yikici:   true,
+		Up: func(tx *gorm.DB) error {
+			if err := tabloyuYenidenKur(tx, &envanterV2{}, "envanter",
+				[]string{"id", "created_at", "updated_at", "deleted_at", "tur", "cins", "kod", "miktar", "birim",
+					"giris_tipi", "alis_tarihi", "alis_fiyati", "toplam_alis", "guncel_fiyat", "guncel_tutar",
+					"guncel_alis_fiyati", "guncel_satis_fiyati", "kar_zarar", "kar_zarar_yuzde", "api_kaynak", "notlar"},
+				[]string{"miktar", "alis_fiyati", "toplam_alis", "guncel_fiyat", "guncel_tutar",
+					"guncel_alis_fiyati", "guncel_satis_fiyati", "kar_zarar", "kar_zarar_yuzde"}); err != nil {
+				return err
+			}
+			if err := tabloyuYenidenKur(tx, &islemV2{}, "islemler",
+				[]string{"id", "created_at", "updated_at", "deleted_at", "envanter_id", "kod", "tip", "tarih",
+					"miktar", "birim_fiyat", "tutar", "maliyet", "gerceklesen_kar_zarar", "referans", "yontem", "notlar"},
+				[]string{"miktar", "birim_fiyat", "tutar", "maliyet", "gerceklesen_kar_zarar"}); err != nil {
+				return err
+			}
+			return tabloyuYenidenKur(tx, &portfoyGunlukV2{}, "portfoy_gunluk",
+				[]string{"id", "created_at", "updated_at", "tarih", "seviye", "anahtar", "toplam_alis", "guncel_tutar", "kar_zarar"},
+				[]string{"toplam_alis", "guncel_tutar", "kar_zarar"})
+		},
+	},
+	{
+		Surum:    3,
+		Aciklama: "Portföyler: portfoyler tablosu ve envanter.portfoy_id",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&portfoyV3{}); err != nil {
+				return err
+			}
+			var sayi int64
+			if err := tx.Model(&portfoyV3{}).Count(&sayi).Error; err != nil {
+				return err
+			}
+			if sayi == 0 {
+				varsayilan := portfoyV3{ID: models.VarsayilanPortfoyID, Ad: models.VarsayilanPortfoyAdi}
+				if err := tx.Create(&varsayilan).Error; err != nil {
+					return err
+				}
+			}
+
+			// Mevcut lotlar varsayılan portföye atanır. 1. adımı güncel modellerle uygulayan eski
+			// sürümlerin dosyalarında sütun zaten bulunabilir
+			if !tx.Migrator().HasColumn(&envanterV3{}, "PortfoyID") {
+				if err := tx.Migrator().AddColumn(&envanterV3{}, "PortfoyID"); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasIndex(&envanterV3{}, "PortfoyID") {
+				if err := tx.Migrator().CreateIndex(&envanterV3{}, "PortfoyID"); err != nil {
+					return err
+				}
+			}
+			return tx.Exec("UPDATE envanter SET portfoy_id = ? WHERE portfoy_id IS NULL OR portfoy_id = 0", models.VarsayilanPortfoyID).Error
+		},
+	},
+	{
+		Surum:    4,
+		Aciklama: "Fiyat ve portföy alarmları: alarmlar tablosu",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&alarmV4{})
+		},
+	},
+	{
+		Surum:    5,
+		Aciklama: "Envanter değişikliklerinin denetim kaydı: degisiklik_kaydi tablosu",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&degisiklikKaydiV5{})
+		},
+	},
+	{
+		Surum:    6,
+		Aciklama: "Giriş işlemi olmayan eski lotlara alış/hediye girişi işlemi",
+		Up:       acilisIslemleriniTamamla,
+	},
+}
+
+// acilisIslemleriniTamamla giriş işlemi kaydedilmeden eklenmiş (silinmişler dahil) lotlar için
+// lotun alış bilgileriyle giriş işlemi oluşturur. İşlem miktarı kalan ile çıkan miktarın, tutarı
+// lotun kayıtlı toplam alışı ile çıkışlarda düşülen maliyetin toplamıdır. Tutar miktar × fiyat
+// olarak yeniden hesaplanmaz; 2. adımda yuvarlanan toplam alışla lot ve işlem aynı maliyeti gösterir.
+func acilisIslemleriniTamamla(tx *gorm.DB) error {
+	var lotlar []envanterV3
+	err := tx.Unscoped().
+		Where("id NOT IN (SELECT envanter_id FROM islemler WHERE tip IN ?)", []string{models.IslemAlis, models.IslemHediyeGiris}).
+		Find(&lotlar).Error
+	if err != nil {
+		return fmt.Errorf("giriş işlemi olmayan lotlar getirilemedi: %w", err)
+	}
+
+	for _, lot := range lotlar {
+		var cikislar []islemV2
+		if err := tx.Where("envanter_id = ? AND tip IN ?", lot.ID, []string{models.IslemSatis, models.IslemHediyeCikis}).Find(&cikislar).Error; err != nil {
+			return fmt.Errorf("lotun çıkış işlemleri getirilemedi: ID %d: %w", lot.ID, err)
+		}
+		miktar, tutar := lot.Miktar, lot.ToplamAlis
+		for _, cikis := range cikislar {
+			miktar = miktar.Add(cikis.Miktar)
+			tutar = tutar.Add(cikis.Maliyet)
+		}
+		tip := models.IslemAlis
+		if lot.GirisTipi == models.IslemHediyeGiris {
+			tip = models.IslemHediyeGiris
+		}
+		islem := islemV2{
+			EnvanterID: lot.ID,
+			Kod:        lot.Kod,
+			Tip:        tip,
+			Tarih:      lot.AlisTarihi,
+			Miktar:     miktar,
+			BirimFiyat: lot.AlisFiyati,
+			Tutar:      tutar,
+		}
+		if err := tx.Create(&islem).Error; err != nil {
+			return fmt.Errorf("lotun giriş işlemi oluşturulamadı: ID %d: %w", lot.ID, err)
+		}
+	}
+	if len(lotlar) > 0 {
+		logger.Infof("%d eski lot için giriş işlemi oluşturuldu", len(lotlar))
+	}
+	return nil
+}
+
+// ondalikHassasiyeti kayan noktalı eski değerlerin metne çevrilirken yuvarlandığı ondalık basamak sayısı.
+// Kayan nokta artıkları (ör. 2450.7500000000005) bu basamaktan sonra kalır ve atılır.
+const ondalikHassasiyeti = 8
+
+// tabloyuYenidenKur tabloyu modelin güncel şemasıyla yeniden oluşturup verileri aktarır. SQLite
+// sütun tipini değiştiremediğinden (REAL sütuna yazılan metin tekrar sayıya çevrilir) tablo
+// kopyalanır, silinir ve indeksleriyle birlikte yeniden yaratılır. Sayısal sütunlar
+// ondalikHassasiyeti basamağa yuvarlanmış metne çevrilir.
+func tabloyuYenidenKur(tx *gorm.DB, model interface{}, tablo string, sutunlar, sayisalSutunlar []string) error {
+	sayisal := make(map[string]bool, len(sayisalSutunlar))
+	for _, sutun := range sayisalSutunlar {
+		sayisal[sutun] = true
+	}
+
+	secimler := make([]string, len(sutunlar))
+	for i, sutun := range sutunlar {
+		secimler[i] = sutun
+		if sayisal[sutun] {
+			// 12.50000000 -> 12.5, 100.00000000 -> 100
+			secimler[i] = fmt.Sprintf("rtrim(rtrim(printf('%%.%df', COALESCE(%s, 0)), '0'), '.')", ondalikHassasiyeti, sutun)
+		}
+	}
+
+	eski := tablo + "_eski"
+	adimlar := []func() error{
+		func() error { return tx.Exec(fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM %s", eski, tablo)).Error },
+		func() error { return tx.Migrator().DropTable(tablo) },
+		func() error { return tx.Migrator().CreateTable(model) },
+		func() error {
+			return tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+				tablo, strings.Join(sutunlar, ", "), strings.Join(secimler, ", "), eski)).Error
+		},
+		func() error { return tx.Migrator().DropTable(eski) },
+	}
+	for _, adim := range adimlar {
+		if err := adim(); err != nil {
+			return fmt.Errorf("%s tablosu yeniden oluşturulamadı: %w", tablo, err)
+		}
+	}
+	return nil
+}
+
+// MigrasyonDurumu bir migrasyon adımının uygulanma durumu
+type MigrasyonDurumu struct {
+	Surum           int        `json:"version"`
+	Aciklama        string     `json:"aciklama"`
+	Yikici          bool       `json:"yikici"`
+	UygulanmaZamani *time.Time `json:"applied_at"`
+}
+
+// Migrate bekleyen şema migrasyonlarını sırayla uygular
+func Migrate() error {
+	if DB == nil {
+		return fmt.Errorf("veritabanı bağlantısı kurulmamış")
+	}
+
+	if err := DB.AutoMigrate(&models.SemaMigrasyonu{}); err != nil {
+		return fmt.Errorf("schema_migrations tablosu oluşturulamadı: %w", err)
+	}
+
+	mevcutSurum, err := SemaSurumu()
+	if err != nil {
+		return err
+	}
+
+	sonSurum := migrasyonlar[len(migrasyonlar)-1].Surum
+	if mevcutSurum > sonSurum {
+		return fmt.Errorf("veritabanı şema sürümü (%d) bu uygulamanın desteklediği sürümden (%d) yeni", mevcutSurum, sonSurum)
+	}
+
+	var bekleyenler []Migrasyon
+	yikiciVar := false
+	for _, m := range migrasyonlar {
+		if m.Surum > mevcutSurum {
+			bekleyenler = append(bekleyenler, m)
+			yikiciVar = yikiciVar || m.Yikici
+		}
+	}
+	if len(bekleyenler) == 0 {
+		logger.Debugf("Veritabanı şeması güncel (sürüm %d)", mevcutSurum)
+		return nil
+	}
+
+	// Yıkıcı bir adım varsa ve dosyada veri varsa önce yedek alınır
+	if yikiciVar && DB.Migrator().HasTable(&models.Envanter{}) {
+		yedek, err := YedekAl(fmt.Sprintf("v%d-migrasyon-oncesi", mevcutSurum))
+		if err != nil {
+			return fmt.Errorf("yıkıcı migrasyon öncesi yedek alınamadı: %w", err)
+		}
+		logger.Infof("Migrasyon öncesi yedek alındı: %s", yedek)
+	}
+
+	for _, m := range bekleyenler {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&models.SemaMigrasyonu{
+				Surum:           m.Surum,
+				Aciklama:        m.Aciklama,
+				UygulanmaZamani: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migrasyon %d (%s) başarısız: %w", m.Surum, m.Aciklama, err)
+		}
+		logger.Infof("Migrasyon uygulandı: %d - %s", m.Surum, m.Aciklama)
+	}
+
+	logger.Infof("Veritabanı migrasyonu tamamlandı (sürüm %d)", sonSurum)
+	return nil
+}
+
+// SemaSurumu veritabanına uygulanmış en yüksek migrasyon sürümünü döner (hiç yoksa 0)
+func SemaSurumu() (int, error) {
+	var surum int
+	err := DB.Model(&models.SemaMigrasyonu{}).Select("COALESCE(MAX(version), 0)").Scan(&surum).Error
+	if err != nil {
+		return 0, fmt.Errorf("şema sürümü okunamadı: %w", err)
+	}
+	return surum, nil
+}
+
+// GetMigrasyonDurumlari bilinen tüm migrasyonları uygulanma zamanlarıyla döner
+func GetMigrasyonDurumlari() ([]MigrasyonDurumu, error) {
+	var uygulananlar []models.SemaMigrasyonu
+	if err := DB.Find(&uygulananlar).Error; err != nil {
+		return nil, fmt.Errorf("uygulanan migrasyonlar okunamadı: %w", err)
+	}
+
+	zamanlar := make(map[int]time.Time, len(uygulananlar))
+	for _, u := range uygulananlar {
+		zamanlar[u.Surum] = u.UygulanmaZamani
+	}
+
+	durumlar := make([]MigrasyonDurumu, 0, len(migrasyonlar))
+	for _, m := range migrasyonlar {
+		durum := MigrasyonDurumu{Surum: m.Surum, Aciklama: m.Aciklama, Yikici: m.Yikici}
+		if zaman, ok := zamanlar[m.Surum]; ok {
+			durum.UygulanmaZamani = &zaman
+		}
+		durumlar = append(durumlar, durum)
+	}
+	return durumlar, nil
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Migrasyon adımlarının kullandığı şema görüntüleri. Adımlar models paketindeki güncel yapılar
+// yerine bu dondurulmuş yapılarla çalışır; böylece modeller değişse de uygulanmış bir sürüm
+// her zaman aynı şemayı ifade eder. Uygulanmış bir adımın yapısı değiştirilmez; şema değişikliği
+// yeni bir adım ve gerekiyorsa yeni bir görüntüyle yapılır.
+
+// envanterV1 1. sürümdeki envanter tablosu (tutarlar kayan noktalı)
+type envanterV1 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Tur    string  `gorm:"not null"`
+	Cins   string  `gorm:"not null"`
+	Kod    string  `gorm:"not null"`
+	Miktar float64 `gorm:"not null"`
+	Birim  string  `gorm:"not null"`
+
+	GirisTipi  string    `gorm:"not null;default:alis"`
+	AlisTarihi time.Time `gorm:"not null"`
+	AlisFiyati float64   `gorm:"not null"`
+	ToplamAlis float64   `gorm:"not null"`
+
+	GuncelFiyat       float64
+	GuncelTutar       float64
+	GuncelAlisFiyati  float64
+	GuncelSatisFiyati float64
+	KarZarar          float64
+	KarZararYuzde     float64
+
+	APIKaynak string
+	Notlar    string
+}
+
+func (envanterV1) TableName() string { return "envanter" }
+
+// islemV1 1. sürümdeki islemler tablosu
+type islemV1 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	EnvanterID uint   `gorm:"not null;index"`
+	Kod        string `gorm:"not null;index"`
+	Tip        string `gorm:"not null"`
+
+	Tarih      time.Time `gorm:"not null"`
+	Miktar     float64   `gorm:"not null"`
+	BirimFiyat float64
+	Tutar      float64
+
+	Maliyet             float64
+	GerceklesenKarZarar float64
+
+	Referans string `gorm:"index"`
+	Yontem   string
+	Notlar   string
+}
+
+func (islemV1) TableName() string { return "islemler" }
+
+// maliyetYontemiV1 1. sürümdeki maliyet_yontemleri tablosu
+type maliyetYontemiV1 struct {
+	Kod       string `gorm:"primaryKey"`
+	Yontem    string `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (maliyetYontemiV1) TableName() string { return "maliyet_yontemleri" }
+
+// fiyatGecmisiV1 1. sürümdeki fiyat_gecmisi tablosu (API fiyatları olduğu gibi saklanır)
+type fiyatGecmisiV1 struct {
+	ID uint `gorm:"primaryKey"`
+
+	Kod     string  `gorm:"not null;index:idx_fiyat_gecmisi_kod_zaman,priority:1"`
+	Alis    float64 `gorm:"not null"`
+	Satis   float64 `gorm:"not null"`
+	Degisim *float64
+
+	GuncellenmeZamani string
+	CekilmeZamani     time.Time `gorm:"not null;index:idx_fiyat_gecmisi_kod_zaman,priority:2"`
+	Kaynak            string
+}
+
+func (fiyatGecmisiV1) TableName() string { return "fiyat_gecmisi" }
+
+// portfoyGunlukV1 1. sürümdeki portfoy_gunluk tablosu
+type portfoyGunlukV1 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Tarih   string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:1"`
+	Seviye  string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:2"`
+	Anahtar string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:3"`
+
+	ToplamAlis  float64
+	GuncelTutar float64
+	KarZarar    float64
+}
+
+func (portfoyGunlukV1) TableName() string { return "portfoy_gunluk" }
+
+// envanterV2 2. sürümdeki envanter tablosu (tutarlar tam ondalıklı metin)
+type envanterV2 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Tur    string          `gorm:"not null"`
+	Cins   string          `gorm:"not null"`
+	Kod    string          `gorm:"not null"`
+	Miktar decimal.Decimal `gorm:"type:text;not null"`
+	Birim  string          `gorm:"not null"`
+
+	GirisTipi  string          `gorm:"not null;default:alis"`
+	AlisTarihi time.Time       `gorm:"not null"`
+	AlisFiyati decimal.Decimal `gorm:"type:text;not null"`
+	ToplamAlis decimal.Decimal `gorm:"type:text;not null"`
+
+	GuncelFiyat       decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GuncelTutar       decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GuncelAlisFiyati  decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GuncelSatisFiyati decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	KarZarar          decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	KarZararYuzde     decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+
+	APIKaynak string
+	Notlar    string
+}
+
+func (envanterV2) TableName() string { return "envanter" }
+
+// islemV2 2. sürümdeki islemler tablosu
+type islemV2 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	EnvanterID uint   `gorm:"not null;index"`
+	Kod        string `gorm:"not null;index"`
+	Tip        string `gorm:"not null"`
+
+	Tarih      time.Time       `gorm:"not null"`
+	Miktar     decimal.Decimal `gorm:"type:text;not null"`
+	BirimFiyat decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	Tutar      decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+
+	Maliyet             decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GerceklesenKarZarar decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+
+	Referans string `gorm:"index"`
+	Yontem   string
+	Notlar   string
+}
+
+func (islemV2) TableName() string { return "islemler" }
+
+// portfoyGunlukV2 2. sürümdeki portfoy_gunluk tablosu
+type portfoyGunlukV2 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Tarih   string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:1"`
+	Seviye  string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:2"`
+	Anahtar string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:3"`
+
+	ToplamAlis  decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GuncelTutar decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	KarZarar    decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+}
+
+func (portfoyGunlukV2) TableName() string { return "portfoy_gunluk" }
+
+// portfoyV3 3. sürümde eklenen portfoyler tablosu
+type portfoyV3 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Ad     string `gorm:"not null;uniqueIndex"`
+	Notlar string
+}
+
+func (portfoyV3) TableName() string { return "portfoyler" }
+
+// envanterV3 3. sürümdeki envanter tablosu (portfoy_id eklendi)
+type envanterV3 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	PortfoyID uint `gorm:"not null;default:1;index"`
+
+	Tur    string          `gorm:"not null"`
+	Cins   string          `gorm:"not null"`
+	Kod    string          `gorm:"not null"`
+	Miktar decimal.Decimal `gorm:"type:text;not null"`
+	Birim  string          `gorm:"not null"`
+
+	GirisTipi  string          `gorm:"not null;default:alis"`
+	AlisTarihi time.Time       `gorm:"not null"`
+	AlisFiyati decimal.Decimal `gorm:"type:text;not null"`
+	ToplamAlis decimal.Decimal `gorm:"type:text;not null"`
+
+	GuncelFiyat       decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GuncelTutar       decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GuncelAlisFiyati  decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	GuncelSatisFiyati decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	KarZarar          decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+	KarZararYuzde     decimal.Decimal `gorm:"type:text;not null;default:'0'"`
+
+	APIKaynak string
+	Notlar    string
+}
+
+func (envanterV3) TableName() string { return "envanter" }
+
+// alarmV4 4. sürümde eklenen alarmlar tablosu
+type alarmV4 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Ad   string
+	Tip  string          `gorm:"not null"`
+	Kod  string          `gorm:"index"`
+	Alan string          `gorm:"not null;default:alis"`
+	Esik decimal.Decimal `gorm:"type:text;not null"`
+
+	PortfoyID uint `gorm:"not null;default:0"`
+
+	Komut   string
+	Webhook string
+
+	Aktif         bool `gorm:"not null;default:true"`
+	Tetiklendi    bool `gorm:"not null;default:false"`
+	SonTetiklenme *time.Time
+	SonDeger      *decimal.Decimal `gorm:"type:text"`
+}
+
+func (alarmV4) TableName() string { return "alarmlar" }
+
+// degisiklikKaydiV5 5. sürümde eklenen degisiklik_kaydi tablosu
+type degisiklikKaydiV5 struct {
+	ID    uint      `gorm:"primaryKey"`
+	Zaman time.Time `gorm:"not null;index"`
+
+	EnvanterID uint   `gorm:"not null;index"`
+	Tur        string `gorm:"not null"`
+
+	Kullanici string
+	Kaynak    string
+
+	Onceki  string `gorm:"type:text"`
+	Sonraki string `gorm:"type:text"`
+}
+
+func (degisiklikKaydiV5) TableName() string { return "degisiklik_kaydi" }
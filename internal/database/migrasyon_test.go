@@ -0,0 +1,146 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// surumOncesiEnvanter sürüm takibinden önceki ilk sürümün oluşturduğu envanter tablosu
+// (tutarlar REAL, giris_tipi ve portfoy_id yok)
+const surumOncesiEnvanter = "CREATE TABLE `envanter` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime," +
+	"`deleted_at` datetime,`tur` text NOT NULL,`cins` text NOT NULL,`kod` text NOT NULL,`miktar` real NOT NULL,`birim` text NOT NULL," +
+	"`alis_tarihi` datetime NOT NULL,`alis_fiyati` real NOT NULL,`toplam_alis` real NOT NULL,`guncel_fiyat` real,`guncel_tutar` real," +
+	"`kar_zarar` real,`kar_zarar_yuzde` real,`api_kaynak` text,`notlar` text)"
+
+func TestMigrateSurumOncesiVeritabani(t *testing.T) {
+	testVeritabani(t, "")
+	adimlar := []string{
+		surumOncesiEnvanter,
+		"CREATE INDEX `idx_envanter_deleted_at` ON `envanter`(`deleted_at`)",
+		"INSERT INTO envanter (id, created_at, updated_at, tur, cins, kod, miktar, birim, alis_tarihi, alis_fiyati, toplam_alis, guncel_fiyat) " +
+			"VALUES (1, '2024-05-01 10:00:00+00:00', '2024-05-01 10:00:00+00:00', 'Altın', 'Gram Altın', 'GAT', 3.2123456789, 'gram', " +
+			"'2024-05-01 00:00:00+00:00', 762.9, 2450.7500000000005, 3000.125)",
+		"INSERT INTO envanter (id, created_at, updated_at, deleted_at, tur, cins, kod, miktar, birim, alis_tarihi, alis_fiyati, toplam_alis) " +
+			"VALUES (2, '2024-06-01 10:00:00+00:00', '2024-06-01 10:00:00+00:00', '2024-07-01 10:00:00+00:00', 'Döviz', 'Dolar', 'USD', 1, 'adet', " +
+			"'2024-06-01 00:00:00+00:00', 3.2123456789, 3.2123456789)",
+	}
+	for _, adim := range adimlar {
+		if err := DB.Exec(adim).Error; err != nil {
+			t.Fatalf("eski şema kurulamadı: %v", err)
+		}
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrasyon: %v", err)
+	}
+
+	for _, sutun := range []string{"miktar", "alis_fiyati", "toplam_alis", "guncel_fiyat", "kar_zarar"} {
+		var tip, degerTipi string
+		DB.Raw("SELECT type FROM pragma_table_info('envanter') WHERE name = ?", sutun).Scan(&tip)
+		DB.Raw("SELECT typeof(" + sutun + ") FROM envanter WHERE id = 1").Scan(&degerTipi)
+		if !strings.EqualFold(tip, "text") || degerTipi != "text" {
+			t.Errorf("envanter.%s tipi = %q, değer tipi %q, beklenen text", sutun, tip, degerTipi)
+		}
+	}
+
+	type lot struct {
+		ID         uint
+		Miktar     string
+		AlisFiyati string
+		ToplamAlis string
+		GirisTipi  string
+		PortfoyID  uint
+		DeletedAt  *string
+	}
+	var lotlar []lot
+	if err := DB.Raw("SELECT id, miktar, alis_fiyati, toplam_alis, giris_tipi, portfoy_id, deleted_at FROM envanter ORDER BY id").Scan(&lotlar).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(lotlar) != 2 {
+		t.Fatalf("lot sayısı = %d, beklenen 2 (silinmiş lot dahil)", len(lotlar))
+	}
+	beklenenler := []lot{
+		{ID: 1, Miktar: "3.21234568", AlisFiyati: "762.9", ToplamAlis: "2450.75", GirisTipi: "alis", PortfoyID: 1},
+		{ID: 2, Miktar: "1", AlisFiyati: "3.21234568", ToplamAlis: "3.21234568", GirisTipi: "alis", PortfoyID: 1},
+	}
+	for i, beklenen := range beklenenler {
+		got := lotlar[i]
+		got.DeletedAt = nil
+		if got != beklenen {
+			t.Errorf("lot %d = %+v, beklenen %+v", beklenen.ID, got, beklenen)
+		}
+	}
+	if lotlar[1].DeletedAt == nil {
+		t.Error("silinmiş lotun deleted_at değeri kayboldu")
+	}
+
+	// 6. adım: açılış işleminin tutarı lotun yuvarlanmış toplam alışıyla aynı
+	type islem struct {
+		EnvanterID uint
+		Tip        string
+		Miktar     string
+		Tutar      string
+	}
+	var islemler []islem
+	if err := DB.Raw("SELECT envanter_id, tip, miktar, tutar FROM islemler ORDER BY envanter_id").Scan(&islemler).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(islemler) != len(lotlar) {
+		t.Fatalf("giriş işlemi sayısı = %d, beklenen %d", len(islemler), len(lotlar))
+	}
+	for i, l := range lotlar {
+		beklenen := islem{EnvanterID: l.ID, Tip: "alis", Miktar: l.Miktar, Tutar: l.ToplamAlis}
+		if islemler[i] != beklenen {
+			t.Errorf("lot %d giriş işlemi = %+v, beklenen %+v", l.ID, islemler[i], beklenen)
+		}
+	}
+
+	var surumler []int
+	DB.Raw("SELECT version FROM schema_migrations ORDER BY version").Scan(&surumler)
+	if len(surumler) != len(migrasyonlar) {
+		t.Fatalf("schema_migrations = %v, beklenen %d adım", surumler, len(migrasyonlar))
+	}
+	for i, surum := range surumler {
+		if surum != migrasyonlar[i].Surum {
+			t.Errorf("schema_migrations[%d] = %d, beklenen %d", i, surum, migrasyonlar[i].Surum)
+		}
+	}
+
+	// Migrasyon öncesi yedek eski şemayı ve kayan noktalı değerleri olduğu gibi saklar
+	yedekler, err := Yedekler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(yedekler) != 1 || !strings.Contains(yedekler[0].Yol, "v0-migrasyon-oncesi") {
+		t.Fatalf("yedekler = %+v, beklenen tek bir v0-migrasyon-oncesi yedeği", yedekler)
+	}
+	yedekDB, err := gorm.Open(sqlite.Open(yedekler[0].Yol), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := yedekDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	var miktar float64
+	var tip string
+	yedekDB.Raw("SELECT miktar, typeof(miktar) FROM envanter WHERE id = 1").Row().Scan(&miktar, &tip)
+	if miktar != 3.2123456789 || tip != "real" {
+		t.Errorf("yedekteki miktar = %v (%s), beklenen 3.2123456789 (real)", miktar, tip)
+	}
+	if yedekDB.Migrator().HasTable("islemler") || yedekDB.Migrator().HasColumn("envanter", "giris_tipi") {
+		t.Error("yedek migrasyon sonrası şemayı içeriyor")
+	}
+
+	// Güncel şemada tekrar çalıştırmak bir şey değiştirmez
+	if err := Migrate(); err != nil {
+		t.Fatalf("ikinci migrasyon: %v", err)
+	}
+	var islemSayisi int64
+	DB.Table("islemler").Count(&islemSayisi)
+	if islemSayisi != int64(len(lotlar)) {
+		t.Errorf("ikinci migrasyondan sonra işlem sayısı = %d, beklenen %d", islemSayisi, len(lotlar))
+	}
+}
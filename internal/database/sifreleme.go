@@ -0,0 +1,568 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"altintakip/internal/logger"
+)
+
+// Şifreli dosya biçimi:
+//
+//	"ATSIFRE1" | tuz (16 bayt) | PBKDF2 tekrar sayısı (4 bayt) | nonce (12 bayt) | AES-256-GCM şifreli veri
+//
+// Anahtar parola ve tuzdan PBKDF2-HMAC-SHA256 ile türetilir. Başlık GCM'e ek veri olarak
+// verildiğinden tuz veya tekrar sayısı değiştirilmiş bir dosya da çözülemez.
+const (
+	sifreliImza     = "ATSIFRE1"
+	tuzUzunlugu     = 16
+	nonceUzunlugu   = 12
+	anahtarUzunlugu = 32
+	pbkdf2Tekrari   = 600000
+	baslikUzunlugu  = len(sifreliImza) + tuzUzunlugu + 4 + nonceUzunlugu
+
+	// sifreliUzanti şifreli veritabanı ve yedek dosyalarına eklenen uzantı
+	sifreliUzanti = ".enc"
+
+	// calismaDiziniOneki çözülmüş çalışma kopyası dizinlerinin geçici dizindeki ad öneki
+	calismaDiziniOneki = "altintakip-"
+	// surecDosyasi çalışma dizininde dizini kullanan sürecin numarasını tutan dosya
+	surecDosyasi = "surec"
+	// korunanKopyaDosyasi kapanışta kaydedilemeyip bilerek bırakılan çalışma dizinlerini işaretler
+	korunanKopyaDosyasi = "kaydedilemedi"
+)
+
+// ErrYanlisParola parola yanlış olduğunda veya şifreli dosya bozulduğunda döner
+var ErrYanlisParola = errors.New("parola yanlış veya şifreli dosya bozuk")
+
+// ErrSifreliDosyaDegisti şifreli veritabanı bu oturum açıkken başka bir süreç tarafından değiştirildiğinde döner
+var ErrSifreliDosyaDegisti = errors.New("şifreli veritabanı başka bir süreç tarafından değiştirilmiş")
+
+// sifreleyici parolayla dosya şifreler ve çözer. Türetilen anahtarlar tuz başına saklanır;
+// oturum boyunca yazılan dosyalar aynı tuzu kullandığından PBKDF2 bir kez hesaplanır.
+type sifreleyici struct {
+	parola     []byte
+	tuz        []byte
+	anahtarlar map[string][]byte
+}
+
+// Şifreli mod durumu; parola verilmediyse sifre nil'dir ve veritabanı düz dosya olarak açılır
+var (
+	sifre         *sifreleyici
+	calismaDizini string   // çözülmüş çalışma kopyasının bulunduğu geçici dizin
+	calismaOzeti  [32]byte // çalışma kopyasının son yüklendiği/kaydedildiği andaki özeti
+	sifreliOzet   [32]byte // diskteki şifreli dosyanın son okunduğu/yazıldığı andaki özeti
+
+	calismaUyarilari []string // son açılışta kullanıcıya gösterilecek çalışma kopyası uyarıları
+)
+
+// yeniSifreleyici parola için yeni bir oturum tuzu üretir
+func yeniSifreleyici(parola []byte) (*sifreleyici, error) {
+	tuz := make([]byte, tuzUzunlugu)
+	if _, err := rand.Read(tuz); err != nil {
+		return nil, fmt.Errorf("rastgele tuz üretilemedi: %w", err)
+	}
+	return &sifreleyici{parola: parola, tuz: tuz, anahtarlar: map[string][]byte{}}, nil
+}
+
+// anahtar tuz ve tekrar sayısı için anahtarı türetir (önbellekten)
+func (s *sifreleyici) anahtar(tuz []byte, tekrar int) []byte {
+	kimlik := string(tuz) + strconv.Itoa(tekrar)
+	if a, ok := s.anahtarlar[kimlik]; ok {
+		return a
+	}
+	a := pbkdf2SHA256(s.parola, tuz, tekrar, anahtarUzunlugu)
+	s.anahtarlar[kimlik] = a
+	return a
+}
+
+// sifrele düz veriyi oturum tuzu ve rastgele bir nonce ile şifreler
+func (s *sifreleyici) sifrele(duz []byte) ([]byte, error) {
+	baslik := make([]byte, 0, baslikUzunlugu)
+	baslik = append(baslik, sifreliImza...)
+	baslik = append(baslik, s.tuz...)
+	baslik = binary.BigEndian.AppendUint32(baslik, pbkdf2Tekrari)
+	nonce := make([]byte, nonceUzunlugu)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("rastgele nonce üretilemedi: %w", err)
+	}
+	baslik = append(baslik, nonce...)
+
+	gcm, err := gcmOlustur(s.anahtar(s.tuz, pbkdf2Tekrari))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(baslik, nonce, duz, baslik), nil
+}
+
+// coz şifreli veriyi başlığındaki tuz ve tekrar sayısıyla çözer
+func (s *sifreleyici) coz(veri []byte) ([]byte, error) {
+	if len(veri) < baslikUzunlugu || string(veri[:len(sifreliImza)]) != sifreliImza {
+		return nil, fmt.Errorf("şifreli altintakip dosyası değil")
+	}
+	baslik := veri[:baslikUzunlugu]
+	tuz := baslik[len(sifreliImza) : len(sifreliImza)+tuzUzunlugu]
+	tekrar := binary.BigEndian.Uint32(baslik[len(sifreliImza)+tuzUzunlugu:])
+	nonce := baslik[baslikUzunlugu-nonceUzunlugu:]
+	if tekrar == 0 || tekrar > 10*pbkdf2Tekrari {
+		return nil, fmt.Errorf("şifreli dosya başlığı geçersiz")
+	}
+
+	gcm, err := gcmOlustur(s.anahtar(tuz, int(tekrar)))
+	if err != nil {
+		return nil, err
+	}
+	duz, err := gcm.Open(nil, nonce, veri[baslikUzunlugu:], baslik)
+	if err != nil {
+		return nil, ErrYanlisParola
+	}
+	return duz, nil
+}
+
+// gcmOlustur 256 bit anahtarla AES-GCM oluşturur
+func gcmOlustur(anahtar []byte) (cipher.AEAD, error) {
+	blok, err := aes.NewCipher(anahtar)
+	if err != nil {
+		return nil, fmt.Errorf("şifreleyici oluşturulamadı: %w", err)
+	}
+	return cipher.NewGCM(blok)
+}
+
+// pbkdf2SHA256 RFC 8018'deki PBKDF2'yi HMAC-SHA256 ile uygular
+func pbkdf2SHA256(parola, tuz []byte, tekrar, uzunluk int) []byte {
+	prf := hmac.New(sha256.New, parola)
+	boy := prf.Size()
+	blokSayisi := (uzunluk + boy - 1) / boy
+
+	sonuc := make([]byte, 0, blokSayisi*boy)
+	u := make([]byte, 0, boy)
+	t := make([]byte, boy)
+	for blok := 1; blok <= blokSayisi; blok++ {
+		prf.Reset()
+		prf.Write(tuz)
+		prf.Write(binary.BigEndian.AppendUint32(nil, uint32(blok)))
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < tekrar; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		sonuc = append(sonuc, t...)
+	}
+	return sonuc[:uzunluk]
+}
+
+// SifrelemeDurumu şifreli modun etkin olup olmadığını (DB_ENCRYPTION veya diskte şifreli
+// veritabanı varsa) ve şifreli dosyanın zaten var olup olmadığını döner. Dosya yoksa
+// girilecek parola yeni parola olarak kullanılır.
+func SifrelemeDurumu() (etkin bool, mevcut bool, err error) {
+	yol, err := veritabaniYolu()
+	if err != nil {
+		return false, false, err
+	}
+	if _, err := os.Stat(yol + sifreliUzanti); err == nil {
+		return true, true, nil
+	}
+
+	deger := strings.TrimSpace(os.Getenv("DB_ENCRYPTION"))
+	if deger == "" {
+		return false, false, nil
+	}
+	etkin, err = strconv.ParseBool(deger)
+	if err != nil {
+		return false, false, fmt.Errorf("geçersiz DB_ENCRYPTION değeri: %s", deger)
+	}
+	return etkin, false, nil
+}
+
+// SifreliMi veritabanının şifreli modda açılıp açılmadığını döner
+func SifreliMi() bool {
+	return sifre != nil
+}
+
+// SetParola Connect öncesinde şifreli veritabanının parolasını ayarlar
+func SetParola(parola []byte) error {
+	s, err := yeniSifreleyici(parola)
+	if err != nil {
+		return err
+	}
+	sifre = s
+	return nil
+}
+
+// sifreliYol şifreli veritabanı dosyasının yolu
+func sifreliYol() string {
+	return dbYolu + sifreliUzanti
+}
+
+// sifreliAc şifreli veritabanını yalnızca kullanıcının erişebildiği geçici bir dizine çözer ve
+// çalışma kopyasının yolunu döner. Şifreli dosya yoksa ve düz veritabanı varsa önce düz
+// veritabanı ve yedekleri şifrelenip düz halleri silinir.
+func sifreliAc() (string, error) {
+	if _, err := os.Stat(sifreliYol()); os.IsNotExist(err) {
+		if err := duzVeritabaniniSifrele(); err != nil {
+			return "", err
+		}
+	}
+
+	calismaUyarilari = nil
+	dizin, bellekte, err := calismaDiziniOlustur()
+	if err != nil {
+		return "", err
+	}
+	calismaDizini = dizin
+	if !bellekte {
+		calismaUyarisi("bellek tabanlı geçici dizin (/dev/shm) yok; çözülmüş veritabanı oturum boyunca diskte duruyor: %s", dizin)
+	}
+	yol := filepath.Join(dizin, filepath.Base(dbYolu))
+
+	veri, err := os.ReadFile(sifreliYol())
+	if os.IsNotExist(err) {
+		// Yeni veritabanı; ilk kayıtta şifreli dosya oluşturulur
+		calismaOzeti, sifreliOzet = [32]byte{}, [32]byte{}
+		return yol, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("şifreli veritabanı okunamadı: %w", err)
+	}
+
+	duz, err := sifre.coz(veri)
+	if err != nil {
+		return "", err
+	}
+	// Çözülen dosyanın tuzu oturum tuzu olur; böylece kayıtlarda anahtar yeniden türetilmez
+	sifre.tuz = append([]byte(nil), veri[len(sifreliImza):len(sifreliImza)+tuzUzunlugu]...)
+	if err := os.WriteFile(yol, duz, 0600); err != nil {
+		return "", fmt.Errorf("çalışma kopyası yazılamadı: %w", err)
+	}
+
+	calismaOzeti = sha256.Sum256(duz)
+	sifreliOzet = sha256.Sum256(veri)
+	logger.Infof("Şifreli veritabanı çözüldü: %s", sifreliYol())
+	return yol, nil
+}
+
+// calismaDiziniOlustur çözülmüş kopya için yalnızca kullanıcının erişebildiği geçici dizin açar;
+// varsa bellekte tutulan /dev/shm tercih edilir, böylece düz veri diske yazılmaz. Önce çöken
+// oturumlardan kalan çalışma dizinleri silinir; dizine oturumun süreç numarası yazılır.
+// Dizinin bellekte olup olmadığı da döner.
+func calismaDiziniOlustur() (string, bool, error) {
+	kok := os.TempDir()
+	bellekte := false
+	if bilgi, err := os.Stat("/dev/shm"); err == nil && bilgi.IsDir() {
+		kok, bellekte = "/dev/shm", true
+	}
+	artikCalismaDizinleriniSil(kok)
+
+	dizin, err := os.MkdirTemp(kok, calismaDiziniOneki)
+	if err != nil {
+		return "", false, fmt.Errorf("çalışma dizini oluşturulamadı: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dizin, surecDosyasi), []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+		os.RemoveAll(dizin)
+		return "", false, fmt.Errorf("çalışma dizini oluşturulamadı: %w", err)
+	}
+	return dizin, bellekte, nil
+}
+
+// artikCalismaDizinleriniSil kökteki, sahibi süreç artık çalışmayan çalışma dizinlerini siler.
+// Kapanışta şifreli dosyaya yazılamadığı için bırakılan kopyalar silinmez, kullanıcıya bildirilir.
+func artikCalismaDizinleriniSil(kok string) {
+	dizinler, err := filepath.Glob(filepath.Join(kok, calismaDiziniOneki+"*"))
+	if err != nil {
+		return
+	}
+	for _, dizin := range dizinler {
+		bilgi, err := os.Stat(dizin)
+		if err != nil || !bilgi.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dizin, korunanKopyaDosyasi)); err == nil {
+			calismaUyarisi("önceki bir oturumda kaydedilemeyen çözülmüş kopya duruyor: %s; verileri kurtardıktan sonra dizini silin", dizin)
+			continue
+		}
+		if veri, err := os.ReadFile(filepath.Join(dizin, surecDosyasi)); err == nil {
+			if pid, err := strconv.Atoi(strings.TrimSpace(string(veri))); err == nil && surecCalisiyor(pid) {
+				continue
+			}
+		} else if time.Since(bilgi.ModTime()) < time.Minute {
+			// Süreç dosyası henüz yazılmamış olabilir
+			continue
+		}
+		if err := os.RemoveAll(dizin); err != nil {
+			logger.Warnf("Artık çalışma dizini silinemedi (%s): %v", dizin, err)
+			continue
+		}
+		logger.Infof("Önceki oturumdan kalan çalışma dizini silindi: %s", dizin)
+	}
+}
+
+// surecCalisiyor verilen numaralı sürecin çalışıp çalışmadığını döner. Windows'ta süreç yoksa
+// FindProcess hata verir; diğer sistemlerde 0 sinyaliyle yoklanır.
+func surecCalisiyor(pid int) bool {
+	surec, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		surec.Release()
+		return true
+	}
+	err = surec.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// calismaUyarisi kullanıcıya gösterilecek bir çalışma kopyası uyarısını kaydeder ve loglar
+func calismaUyarisi(format string, args ...interface{}) {
+	mesaj := fmt.Sprintf(format, args...)
+	logger.Warnf("%s", mesaj)
+	calismaUyarilari = append(calismaUyarilari, mesaj)
+}
+
+// CalismaUyarilari son Connect'te çözülmüş çalışma kopyasıyla ilgili oluşan uyarıları döner
+// (düz kopyanın diskte tutulması, önceki oturumdan kalan kopyalar)
+func CalismaUyarilari() []string {
+	return calismaUyarilari
+}
+
+// duzVeritabaniniSifrele şifreli moda geçişte düz veritabanını ve düz yedekleri şifreler
+func duzVeritabaniniSifrele() error {
+	if _, err := os.Stat(dbYolu); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(dbYolu + "-journal"); err == nil {
+		return fmt.Errorf("veritabanının tamamlanmamış bir işlemi var; önce DB_ENCRYPTION olmadan açıp kapatın")
+	}
+
+	if err := dosyaSifrele(dbYolu, sifreliYol()); err != nil {
+		return fmt.Errorf("veritabanı şifrelenemedi: %w", err)
+	}
+	if err := os.Remove(dbYolu); err != nil {
+		return fmt.Errorf("düz veritabanı silinemedi: %w", err)
+	}
+	logger.Infof("Veritabanı şifrelendi: %s", sifreliYol())
+
+	yedekler, err := Yedekler()
+	if err != nil {
+		return err
+	}
+	for _, yedek := range yedekler {
+		if yedek.Sifreli {
+			continue
+		}
+		if err := dosyaSifrele(yedek.Yol, yedek.Yol+sifreliUzanti); err != nil {
+			return fmt.Errorf("yedek şifrelenemedi (%s): %w", yedek.Yol, err)
+		}
+		// Yedekler zamana göre sıralanıp döndürüldüğünden alınma zamanı korunur
+		os.Chtimes(yedek.Yol+sifreliUzanti, yedek.Zaman, yedek.Zaman)
+		if err := os.Remove(yedek.Yol); err != nil {
+			return fmt.Errorf("düz yedek silinemedi (%s): %w", yedek.Yol, err)
+		}
+	}
+	return nil
+}
+
+// sifreliKaydet çalışma kopyası değiştiyse tutarlı bir anlık görüntüsünü şifreleyip şifreli
+// dosyanın yerine koyar. Şifreli dosya bu oturumda başka bir süreç tarafından değiştirildiyse
+// üzerine yazılmaz; değişiklikler yanına çakışma dosyası olarak kaydedilir.
+func sifreliKaydet(zorla bool) error {
+	if sifre == nil || DB == nil {
+		return nil
+	}
+
+	calisma, err := os.ReadFile(acikYol)
+	if err != nil {
+		return fmt.Errorf("çalışma kopyası okunamadı: %w", err)
+	}
+	ozet := sha256.Sum256(calisma)
+	if !zorla && ozet == calismaOzeti {
+		return nil
+	}
+
+	hedef := sifreliYol()
+	if mevcut, err := os.ReadFile(hedef); err == nil {
+		if sha256.Sum256(mevcut) != sifreliOzet {
+			hedef = fmt.Sprintf("%s.cakisma-%s", sifreliYol(), time.Now().Format("20060102_150405"))
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("şifreli veritabanı okunamadı: %w", err)
+	} else if sifreliOzet != ([32]byte{}) {
+		hedef = fmt.Sprintf("%s.cakisma-%s", sifreliYol(), time.Now().Format("20060102_150405"))
+	}
+
+	anlik := filepath.Join(calismaDizini, "kayit.db")
+	os.Remove(anlik)
+	if err := DB.Exec("VACUUM INTO ?", anlik).Error; err != nil {
+		return fmt.Errorf("veritabanı anlık görüntüsü alınamadı: %w", err)
+	}
+	defer os.Remove(anlik)
+
+	duz, err := os.ReadFile(anlik)
+	if err != nil {
+		return fmt.Errorf("anlık görüntü okunamadı: %w", err)
+	}
+	sifreli, err := sifre.sifrele(duz)
+	if err != nil {
+		return err
+	}
+	if err := atomikYaz(hedef, sifreli); err != nil {
+		return fmt.Errorf("şifreli veritabanı yazılamadı: %w", err)
+	}
+
+	if hedef != sifreliYol() {
+		return fmt.Errorf("%w; bu oturumdaki değişiklikler %s dosyasına kaydedildi", ErrSifreliDosyaDegisti, hedef)
+	}
+	calismaOzeti = ozet
+	sifreliOzet = sha256.Sum256(sifreli)
+	logger.Debugf("Şifreli veritabanı kaydedildi: %s", hedef)
+	return nil
+}
+
+// calismaKopyasiniSil çözülmüş çalışma kopyasını ve geçici dizinini siler
+func calismaKopyasiniSil() {
+	if calismaDizini == "" {
+		return
+	}
+	if err := os.RemoveAll(calismaDizini); err != nil {
+		logger.Errorf("Çalışma dizini silinemedi: %v", err)
+	}
+	calismaDizini = ""
+}
+
+// calismaKopyasiniKoru çalışma dizinini, sonraki açılışlarda artık dizin olarak silinmemesi için işaretler
+func calismaKopyasiniKoru() {
+	if calismaDizini == "" {
+		return
+	}
+	os.WriteFile(filepath.Join(calismaDizini, korunanKopyaDosyasi), nil, 0600)
+}
+
+// dosyaSifrele düz dosyayı şifreleyip hedefe yazar
+func dosyaSifrele(kaynak, hedef string) error {
+	duz, err := os.ReadFile(kaynak)
+	if err != nil {
+		return err
+	}
+	sifreli, err := sifre.sifrele(duz)
+	if err != nil {
+		return err
+	}
+	return atomikYaz(hedef, sifreli)
+}
+
+// dosyaSifresiniCoz şifreli dosyayı çözüp hedefe yazar
+func dosyaSifresiniCoz(kaynak, hedef string) error {
+	veri, err := os.ReadFile(kaynak)
+	if err != nil {
+		return err
+	}
+	duz, err := sifre.coz(veri)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(hedef, duz, 0600)
+}
+
+// atomikYaz veriyi hedefin yanındaki geçici dosyaya yazıp diske aktarır ve tek adımda yerine taşır
+func atomikYaz(hedef string, veri []byte) error {
+	gecici := hedef + ".yaziliyor"
+	dosya, err := os.OpenFile(gecici, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := dosya.Write(veri); err != nil {
+		dosya.Close()
+		os.Remove(gecici)
+		return err
+	}
+	if err := dosya.Sync(); err != nil {
+		dosya.Close()
+		os.Remove(gecici)
+		return err
+	}
+	if err := dosya.Close(); err != nil {
+		os.Remove(gecici)
+		return err
+	}
+	return os.Rename(gecici, hedef)
+}
+
+// ParolaDegistir şifreli veritabanını ve şifreli yedekleri yeni parolayla yeniden şifreler.
+// Önce veritabanı yazılır; yeniden şifrelenemeyen yedekler eski parolayla kalır ve hata
+// olarak bildirilir. Yeniden şifrelenen yedek sayısı döner.
+func ParolaDegistir(yeniParola []byte) (int, error) {
+	if sifre == nil {
+		return 0, fmt.Errorf("veritabanı şifreli değil; DB_ENCRYPTION=true ile açın")
+	}
+
+	eski := sifre
+	yeni, err := yeniSifreleyici(yeniParola)
+	if err != nil {
+		return 0, err
+	}
+	sifre = yeni
+	if err := sifreliKaydet(true); err != nil {
+		sifre = eski
+		return 0, fmt.Errorf("veritabanı yeni parolayla şifrelenemedi: %w", err)
+	}
+	logger.Infof("Veritabanı parolası değiştirildi")
+
+	yedekler, err := Yedekler()
+	if err != nil {
+		return 0, err
+	}
+	sayi := 0
+	var basarisizlar []string
+	for _, yedek := range yedekler {
+		if !yedek.Sifreli {
+			continue
+		}
+		if err := yedegiYenidenSifrele(yedek, eski); err != nil {
+			logger.Errorf("Yedek yeniden şifrelenemedi (%s): %v", yedek.Yol, err)
+			basarisizlar = append(basarisizlar, filepath.Base(yedek.Yol))
+			continue
+		}
+		sayi++
+	}
+	if len(basarisizlar) > 0 {
+		return sayi, fmt.Errorf("%d yedek eski parolayla kaldı: %s", len(basarisizlar), strings.Join(basarisizlar, ", "))
+	}
+	return sayi, nil
+}
+
+// yedegiYenidenSifrele yedeği eski şifreleyiciyle çözüp güncel parolayla şifreler; alınma zamanı korunur
+func yedegiYenidenSifrele(yedek YedekBilgisi, eski *sifreleyici) error {
+	veri, err := os.ReadFile(yedek.Yol)
+	if err != nil {
+		return err
+	}
+	duz, err := eski.coz(veri)
+	if err != nil {
+		return err
+	}
+	yeniVeri, err := sifre.sifrele(duz)
+	if err != nil {
+		return err
+	}
+	if err := atomikYaz(yedek.Yol, yeniVeri); err != nil {
+		return err
+	}
+	return os.Chtimes(yedek.Yol, yedek.Zaman, yedek.Zaman)
+}
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// testVeritabani geçici bir dizinde veritabanı açar; parola verilirse şifreli modda açılır
+func testVeritabani(t *testing.T, parola string) {
+	t.Helper()
+	dizin := t.TempDir()
+	t.Setenv("APP_DATA_DIR", dizin)
+	t.Setenv("DB_PATH", filepath.Join(dizin, "test.db"))
+	t.Setenv("BACKUP_RETENTION", "0")
+
+	t.Cleanup(func() {
+		Close()
+		sifre = nil
+	})
+	if parola != "" {
+		if err := SetParola([]byte(parola)); err != nil {
+			t.Fatalf("parola ayarlanamadı: %v", err)
+		}
+	}
+	if err := Connect(); err != nil {
+		t.Fatalf("veritabanı açılamadı: %v", err)
+	}
+}
+
+func TestSifreleCoz(t *testing.T) {
+	s, err := yeniSifreleyici([]byte("dogru-parola"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	duz := []byte("SQLite format 3\x00 envanter verisi")
+	sifreli, err := s.sifrele(duz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sifreli, duz) {
+		t.Fatal("şifreli veri düz veriyi içeriyor")
+	}
+
+	cozulen, err := s.coz(sifreli)
+	if err != nil {
+		t.Fatalf("çözülemedi: %v", err)
+	}
+	if !bytes.Equal(cozulen, duz) {
+		t.Fatalf("çözülen veri = %q, beklenen %q", cozulen, duz)
+	}
+
+	yanlis, err := yeniSifreleyici([]byte("yanlis-parola"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := yanlis.coz(sifreli); !errors.Is(err, ErrYanlisParola) {
+		t.Fatalf("yanlış parola hatası = %v, beklenen ErrYanlisParola", err)
+	}
+}
+
+func TestSifreliDosyaDegistirilirseCozulmez(t *testing.T) {
+	s, err := yeniSifreleyici([]byte("dogru-parola"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sifreli, err := s.sifrele([]byte("envanter verisi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tekrarKonumu := len(sifreliImza) + tuzUzunlugu
+	testler := []struct {
+		ad    string
+		konum int
+	}{
+		{"tuz", len(sifreliImza)},
+		{"tekrar sayısı", tekrarKonumu + 3},
+		{"nonce", baslikUzunlugu - 1},
+		{"şifreli veri", baslikUzunlugu},
+		{"doğrulama etiketi", len(sifreli) - 1},
+	}
+	for _, tt := range testler {
+		t.Run(tt.ad, func(t *testing.T) {
+			bozuk := append([]byte(nil), sifreli...)
+			bozuk[tt.konum] ^= 0x01
+			if _, err := s.coz(bozuk); !errors.Is(err, ErrYanlisParola) {
+				t.Fatalf("hata = %v, beklenen ErrYanlisParola", err)
+			}
+		})
+	}
+
+	bozuk := append([]byte(nil), sifreli...)
+	copy(bozuk[tekrarKonumu:], []byte{0, 0, 0, 0})
+	if _, err := s.coz(bozuk); err == nil || errors.Is(err, ErrYanlisParola) {
+		t.Fatalf("sıfır tekrar sayısı hatası = %v, beklenen geçersiz başlık", err)
+	}
+	if _, err := s.coz([]byte("SQLite format 3")); err == nil {
+		t.Fatal("şifreli olmayan dosya çözüldü")
+	}
+}
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// RFC 7914 bölüm 11 ve yaygın kullanılan PBKDF2-HMAC-SHA256 test vektörleri
+	testler := []struct {
+		parola, tuz string
+		tekrar      int
+		beklenen    string
+	}{
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9"},
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	}
+	for _, tt := range testler {
+		beklenen, _ := hex.DecodeString(tt.beklenen)
+		got := pbkdf2SHA256([]byte(tt.parola), []byte(tt.tuz), tt.tekrar, len(beklenen))
+		if !bytes.Equal(got, beklenen) {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d) = %x, beklenen %s", tt.parola, tt.tuz, tt.tekrar, got, tt.beklenen)
+		}
+	}
+}
+
+func TestParolaDegistir(t *testing.T) {
+	testVeritabani(t, "eski-parola")
+	if err := DB.Exec("CREATE TABLE notlar (metin TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := DB.Exec("INSERT INTO notlar (metin) VALUES ('gizli')").Error; err != nil {
+		t.Fatal(err)
+	}
+	yedek, err := YedekAl("test")
+	if err != nil {
+		t.Fatalf("yedek alınamadı: %v", err)
+	}
+
+	sayi, err := ParolaDegistir([]byte("yeni-parola"))
+	if err != nil {
+		t.Fatalf("parola değiştirilemedi: %v", err)
+	}
+	if sayi != 1 {
+		t.Fatalf("yeniden şifrelenen yedek = %d, beklenen 1", sayi)
+	}
+	if err := Close(); err != nil {
+		t.Fatalf("kapatılamadı: %v", err)
+	}
+	if _, err := os.Stat(dbYolu); !os.IsNotExist(err) {
+		t.Fatal("düz veritabanı diskte kaldı")
+	}
+
+	if err := SetParola([]byte("eski-parola")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Connect(); !errors.Is(err, ErrYanlisParola) {
+		t.Fatalf("eski parolayla açılış hatası = %v, beklenen ErrYanlisParola", err)
+	}
+
+	if err := SetParola([]byte("yeni-parola")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Connect(); err != nil {
+		t.Fatalf("yeni parolayla açılamadı: %v", err)
+	}
+	var metin string
+	if err := DB.Raw("SELECT metin FROM notlar").Scan(&metin).Error; err != nil || metin != "gizli" {
+		t.Fatalf("yeniden açılan veritabanında kayıt = %q (%v), beklenen gizli", metin, err)
+	}
+
+	veri, err := os.ReadFile(yedek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sifre.coz(veri); err != nil {
+		t.Fatalf("yedek yeni parolayla çözülemedi: %v", err)
+	}
+}
+
+func TestArtikCalismaDizinleriniSil(t *testing.T) {
+	kok := t.TempDir()
+	dizin := func(ad string, dosyalar map[string]string) string {
+		yol := filepath.Join(kok, calismaDiziniOneki+ad)
+		if err := os.Mkdir(yol, 0700); err != nil {
+			t.Fatal(err)
+		}
+		for dosya, icerik := range dosyalar {
+			if err := os.WriteFile(filepath.Join(yol, dosya), []byte(icerik), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return yol
+	}
+	// Süreç numaraları bu kadar büyümediğinden çökmüş oturum olarak kullanılabilir
+	cokmus := dizin("cokmus", map[string]string{surecDosyasi: "2147483646", "test.db": "düz veri"})
+	canli := dizin("canli", map[string]string{surecDosyasi: strconv.Itoa(os.Getpid())})
+	korunan := dizin("korunan", map[string]string{korunanKopyaDosyasi: ""})
+
+	calismaUyarilari = nil
+	t.Cleanup(func() { calismaUyarilari = nil })
+	artikCalismaDizinleriniSil(kok)
+
+	if _, err := os.Stat(cokmus); !os.IsNotExist(err) {
+		t.Error("çöken oturumun çalışma dizini silinmedi")
+	}
+	if _, err := os.Stat(canli); err != nil {
+		t.Errorf("çalışan oturumun dizini silindi: %v", err)
+	}
+	if _, err := os.Stat(korunan); err != nil {
+		t.Errorf("kaydedilemeyen kopya silindi: %v", err)
+	}
+	if len(calismaUyarilari) != 1 {
+		t.Errorf("uyarılar = %v, beklenen korunan kopya için bir uyarı", calismaUyarilari)
+	}
+}
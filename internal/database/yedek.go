@@ -0,0 +1,397 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// otomatikYedekOneki açılışta ve yazmalardan sonra alınan, döndürülen yedeklerin etiket öneki.
+// Migrasyon ve geri yükleme öncesi yedekler döndürülmez, elle silinene kadar saklanır.
+const otomatikYedekOneki = "oto-"
+
+// varsayilanYedekSayisi BACKUP_RETENTION verilmediğinde saklanan otomatik yedek sayısı
+const varsayilanYedekSayisi = 10
+
+// YedekBilgisi yedek dizinindeki bir yedek dosyası
+type YedekBilgisi struct {
+	Yol      string    `json:"yol"`
+	Boyut    int64     `json:"boyut"`
+	Zaman    time.Time `json:"zaman"`
+	Otomatik bool      `json:"otomatik"`
+	Sifreli  bool      `json:"sifreli"`
+}
+
+// yedekDizini yedeklerin tutulduğu dizin (veritabanı dosyasının yanında "yedekler")
+func yedekDizini() string {
+	return filepath.Join(filepath.Dir(dbYolu), "yedekler")
+}
+
+// yedekTabani yedek dosya adlarının öneki (veritabanı dosyasının uzantısız adı)
+func yedekTabani() string {
+	return strings.TrimSuffix(filepath.Base(dbYolu), filepath.Ext(dbYolu))
+}
+
+// YedekSaklamaSayisi BACKUP_RETENTION ayarını döner (0: otomatik yedek alınmaz)
+func YedekSaklamaSayisi() int {
+	deger := strings.TrimSpace(os.Getenv("BACKUP_RETENTION"))
+	if deger == "" {
+		return varsayilanYedekSayisi
+	}
+	sayi, err := strconv.Atoi(deger)
+	if err != nil || sayi < 0 {
+		logger.Warnf("Geçersiz BACKUP_RETENTION değeri: %s, varsayılan kullanılıyor: %d", deger, varsayilanYedekSayisi)
+		return varsayilanYedekSayisi
+	}
+	return sayi
+}
+
+// YedekAl veritabanının tutarlı bir kopyasını VACUUM INTO ile yedek dizinine yazar ve yolunu döner.
+// Şifreli modda kopya çalışma dizinine alınıp şifrelenir; yedek dizinine düz veri yazılmaz.
+func YedekAl(etiket string) (string, error) {
+	if DB == nil || dbYolu == "" {
+		return "", fmt.Errorf("veritabanı bağlantısı kurulmamış")
+	}
+
+	dizin := yedekDizini()
+	if err := os.MkdirAll(dizin, 0755); err != nil {
+		return "", fmt.Errorf("yedek dizini oluşturulamadı: %w", err)
+	}
+
+	// Aynı saniyede alınan yedekler sıra numarasıyla ayrılır
+	uzanti := ".db"
+	if sifre != nil {
+		uzanti += sifreliUzanti
+	}
+	ad := fmt.Sprintf("%s-%s-%s", yedekTabani(), time.Now().Format("20060102_150405"), etiket)
+	yol := filepath.Join(dizin, ad+uzanti)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(yol); os.IsNotExist(err) {
+			break
+		}
+		yol = filepath.Join(dizin, fmt.Sprintf("%s-%d%s", ad, i, uzanti))
+	}
+
+	// VACUUM INTO açık bağlantıyı kapatmadan tutarlı bir anlık görüntü üretir
+	anlik := yol
+	if sifre != nil {
+		anlik = filepath.Join(calismaDizini, "yedek.db")
+		os.Remove(anlik)
+		defer os.Remove(anlik)
+	}
+	if err := DB.Exec("VACUUM INTO ?", anlik).Error; err != nil {
+		return "", fmt.Errorf("veritabanı yedeklenemedi: %w", err)
+	}
+	if sifre != nil {
+		if err := dosyaSifrele(anlik, yol); err != nil {
+			return "", fmt.Errorf("yedek şifrelenemedi: %w", err)
+		}
+	}
+
+	logger.Infof("Veritabanı yedeklendi: %s", yol)
+	return yol, nil
+}
+
+// DegisiklikSonrasi kullanıcı verisini değiştiren bir işlemden sonra çağrılır: şifreli modda
+// değişiklikleri şifreli dosyaya yazar, ardından otomatik yedek alır. Hatalar yalnızca
+// loglanır; asıl işlemi başarısız saymaz.
+func DegisiklikSonrasi(olay string) {
+	if err := sifreliKaydet(false); err != nil {
+		logger.Errorf("Şifreli veritabanı kaydedilemedi (%s): %v", olay, err)
+	}
+	otomatikYedekAl(olay)
+}
+
+// otomatikYedekAl döndürülen bir yedek alır ve BACKUP_RETENTION sayısını aşan eski
+// otomatik yedekleri siler
+func otomatikYedekAl(olay string) {
+	saklanacak := YedekSaklamaSayisi()
+	if saklanacak == 0 {
+		return
+	}
+	if _, err := YedekAl(otomatikYedekOneki + olay); err != nil {
+		logger.Errorf("Otomatik yedek alınamadı (%s): %v", olay, err)
+		return
+	}
+	if err := eskiYedekleriSil(saklanacak); err != nil {
+		logger.Errorf("Eski yedekler silinemedi: %v", err)
+	}
+}
+
+// AcilisYedegiAl açılışta otomatik yedek alır; son otomatik yedekten sonra veritabanı
+// değişmediyse (ör. art arda çalıştırılan okuma komutları) yedek alınmaz
+func AcilisYedegiAl() {
+	if YedekSaklamaSayisi() == 0 {
+		return
+	}
+
+	dbBilgisi, err := os.Stat(veriDosyasi())
+	if err != nil {
+		return
+	}
+	yedekler, err := Yedekler()
+	if err != nil {
+		logger.Errorf("Yedekler listelenemedi: %v", err)
+		return
+	}
+	for i := len(yedekler) - 1; i >= 0; i-- {
+		if yedekler[i].Otomatik {
+			if !dbBilgisi.ModTime().After(yedekler[i].Zaman) {
+				logger.Debugf("Son yedekten sonra değişiklik yok, açılış yedeği alınmadı")
+				return
+			}
+			break
+		}
+	}
+
+	otomatikYedekAl("acilis")
+}
+
+// veriDosyasi diskte kalıcı olarak tutulan veritabanı dosyası (şifreli modda şifreli dosya)
+func veriDosyasi() string {
+	if sifre != nil {
+		return sifreliYol()
+	}
+	return dbYolu
+}
+
+// Yedekler yedek dizinindeki yedek dosyalarını eskiden yeniye döner
+func Yedekler() ([]YedekBilgisi, error) {
+	dosyalar, err := os.ReadDir(yedekDizini())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("yedek dizini okunamadı: %w", err)
+	}
+
+	onek := yedekTabani() + "-"
+	var yedekler []YedekBilgisi
+	for _, dosya := range dosyalar {
+		ad := dosya.Name()
+		sifreli := strings.HasSuffix(ad, ".db"+sifreliUzanti)
+		if dosya.IsDir() || !strings.HasPrefix(ad, onek) || (!sifreli && filepath.Ext(ad) != ".db") {
+			continue
+		}
+		bilgi, err := dosya.Info()
+		if err != nil {
+			continue
+		}
+		yedekler = append(yedekler, YedekBilgisi{
+			Yol:      filepath.Join(yedekDizini(), ad),
+			Boyut:    bilgi.Size(),
+			Zaman:    bilgi.ModTime(),
+			Otomatik: strings.Contains(ad, "-"+otomatikYedekOneki),
+			Sifreli:  sifreli,
+		})
+	}
+
+	sort.SliceStable(yedekler, func(i, j int) bool {
+		return yedekler[i].Zaman.Before(yedekler[j].Zaman)
+	})
+	return yedekler, nil
+}
+
+// eskiYedekleriSil en yeni "saklanacak" adet otomatik yedek dışındakileri siler
+func eskiYedekleriSil(saklanacak int) error {
+	yedekler, err := Yedekler()
+	if err != nil {
+		return err
+	}
+
+	var otomatikler []YedekBilgisi
+	for _, yedek := range yedekler {
+		if yedek.Otomatik {
+			otomatikler = append(otomatikler, yedek)
+		}
+	}
+	for len(otomatikler) > saklanacak {
+		if err := os.Remove(otomatikler[0].Yol); err != nil {
+			return err
+		}
+		logger.Debugf("Eski yedek silindi: %s", otomatikler[0].Yol)
+		otomatikler = otomatikler[1:]
+	}
+	return nil
+}
+
+// yedekYolunuCoz verilen yol yoksa aynı isimli dosyayı yedek dizininde arar
+func yedekYolunuCoz(yol string) (string, error) {
+	if _, err := os.Stat(yol); err == nil {
+		return filepath.Abs(yol)
+	}
+	if filepath.Base(yol) == yol {
+		adaylar := []string{
+			filepath.Join(yedekDizini(), yol),
+			filepath.Join(yedekDizini(), yol+".db"),
+			filepath.Join(yedekDizini(), yol+".db"+sifreliUzanti),
+		}
+		for _, aday := range adaylar {
+			if _, err := os.Stat(aday); err == nil {
+				return aday, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("yedek dosyası bulunamadı: %s", yol)
+}
+
+// YedekDogrula yedek dosyasının SQLite bütünlük kontrolünden geçtiğini, envanter tablosunu
+// içerdiğini ve şema sürümünün bu uygulamanın desteklediği sürümden yeni olmadığını kontrol eder.
+// Şifreli yedekler geçici bir dizine çözülerek kontrol edilir.
+func YedekDogrula(yol string) error {
+	yol, err := yedekYolunuCoz(yol)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(yol, sifreliUzanti) {
+		return duzYedegiDogrula(yol)
+	}
+
+	if sifre == nil {
+		return fmt.Errorf("yedek şifreli; veritabanı şifreli modda açılmadı")
+	}
+	dizin, _, err := calismaDiziniOlustur()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dizin)
+	duzYol := filepath.Join(dizin, "dogrulama.db")
+	if err := dosyaSifresiniCoz(yol, duzYol); err != nil {
+		return fmt.Errorf("yedek çözülemedi: %w", err)
+	}
+	return duzYedegiDogrula(duzYol)
+}
+
+// duzYedegiDogrula şifresiz bir yedek dosyasını kontrol eder
+func duzYedegiDogrula(yol string) error {
+	yedekDB, err := gorm.Open(sqlite.Open(yol), &gorm.Config{
+		Logger: gormlogger.New(log.New(log.Writer(), "\r\n", log.LstdFlags), gormlogger.Config{LogLevel: gormlogger.Silent}),
+	})
+	if err != nil {
+		return fmt.Errorf("yedek açılamadı: %w", err)
+	}
+	if sqlDB, err := yedekDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	var sonuclar []string
+	if err := yedekDB.Raw("PRAGMA integrity_check").Scan(&sonuclar).Error; err != nil {
+		return fmt.Errorf("yedek bütünlük kontrolü yapılamadı: %w", err)
+	}
+	if len(sonuclar) != 1 || sonuclar[0] != "ok" {
+		return fmt.Errorf("yedek bozuk: %s", strings.Join(sonuclar, "; "))
+	}
+
+	if !yedekDB.Migrator().HasTable(&models.Envanter{}) {
+		return fmt.Errorf("yedek altintakip veritabanı değil: envanter tablosu yok")
+	}
+	if yedekDB.Migrator().HasTable(&models.SemaMigrasyonu{}) {
+		var surum int
+		if err := yedekDB.Model(&models.SemaMigrasyonu{}).Select("COALESCE(MAX(version), 0)").Scan(&surum).Error; err != nil {
+			return fmt.Errorf("yedeğin şema sürümü okunamadı: %w", err)
+		}
+		if sonSurum := migrasyonlar[len(migrasyonlar)-1].Surum; surum > sonSurum {
+			return fmt.Errorf("yedeğin şema sürümü (%d) bu uygulamanın desteklediği sürümden (%d) yeni", surum, sonSurum)
+		}
+	}
+	return nil
+}
+
+// GeriYukle yedeği doğrulayıp mevcut veritabanının yerine koyar ve bağlantıyı yeniden kurar.
+// Değiştirmeden önce mevcut veritabanı da yedeklenir; bu yedeğin yolu döner. Yedek eski bir
+// şema sürümündeyse bekleyen migrasyonlar uygulanır.
+func GeriYukle(yol string) (string, error) {
+	if DB == nil || dbYolu == "" {
+		return "", fmt.Errorf("veritabanı bağlantısı kurulmamış")
+	}
+
+	kaynak, err := yedekYolunuCoz(yol)
+	if err != nil {
+		return "", err
+	}
+	if hedef, _ := filepath.Abs(veriDosyasi()); kaynak == hedef {
+		return "", fmt.Errorf("yedek dosyası kullanılan veritabanının kendisi")
+	}
+	if err := YedekDogrula(kaynak); err != nil {
+		return "", err
+	}
+
+	oncekiYedek, err := YedekAl("geri-yukleme-oncesi")
+	if err != nil {
+		return "", fmt.Errorf("geri yükleme öncesi yedek alınamadı: %w", err)
+	}
+
+	// Yedek önce açık dosyanın yanına kopyalanır, sonra tek adımda yerine taşınır.
+	// Şifreli modda açık dosya çözülmüş çalışma kopyasıdır; şifreli yedekler oraya çözülür.
+	gecici := acikYol + ".geri-yukleme"
+	kopyala := dosyaKopyala
+	if strings.HasSuffix(kaynak, sifreliUzanti) {
+		kopyala = dosyaSifresiniCoz
+	}
+	if err := kopyala(kaynak, gecici); err != nil {
+		os.Remove(gecici)
+		return "", fmt.Errorf("yedek kopyalanamadı: %w", err)
+	}
+
+	if err := baglantiyiKapat(); err != nil {
+		os.Remove(gecici)
+		return "", fmt.Errorf("veritabanı bağlantısı kapatılamadı: %w", err)
+	}
+	if err := os.Rename(gecici, acikYol); err != nil {
+		os.Remove(gecici)
+		return "", fmt.Errorf("veritabanı değiştirilemedi: %w", err)
+	}
+	// Eski dosyaya ait günlük dosyaları yeni dosyaya uygulanmamalı
+	for _, ek := range []string{"-journal", "-wal", "-shm"} {
+		os.Remove(acikYol + ek)
+	}
+
+	if err := ac(acikYol); err != nil {
+		return oncekiYedek, err
+	}
+	if err := Migrate(); err != nil {
+		return oncekiYedek, err
+	}
+	if err := sifreliKaydet(true); err != nil {
+		return oncekiYedek, err
+	}
+
+	logger.Infof("Veritabanı yedekten geri yüklendi: %s (önceki hali: %s)", kaynak, oncekiYedek)
+	return oncekiYedek, nil
+}
+
+// dosyaKopyala kaynak dosyayı hedefe kopyalayıp diske yazılmasını bekler
+func dosyaKopyala(kaynak, hedef string) error {
+	giris, err := os.Open(kaynak)
+	if err != nil {
+		return err
+	}
+	defer giris.Close()
+
+	cikis, err := os.OpenFile(hedef, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(cikis, giris); err != nil {
+		cikis.Close()
+		return err
+	}
+	if err := cikis.Sync(); err != nil {
+		cikis.Close()
+		return err
+	}
+	return cikis.Close()
+}
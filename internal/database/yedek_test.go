@@ -0,0 +1,162 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"altintakip/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// testLotuYaz servis katmanı olmadan envantere bir lot yazar
+func testLotuYaz(t *testing.T, kod string) {
+	t.Helper()
+	lot := models.Envanter{
+		Tur:        "Altın",
+		Cins:       kod,
+		Kod:        kod,
+		Miktar:     decimal.NewFromInt(1),
+		Birim:      "adet",
+		AlisTarihi: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		AlisFiyati: decimal.NewFromInt(1000),
+		ToplamAlis: decimal.NewFromInt(1000),
+	}
+	if err := DB.Create(&lot).Error; err != nil {
+		t.Fatalf("lot yazılamadı: %v", err)
+	}
+}
+
+// lotKodlari envanterdeki lotların kodlarını döner
+func lotKodlari(t *testing.T) []string {
+	t.Helper()
+	var kodlar []string
+	if err := DB.Model(&models.Envanter{}).Order("id").Pluck("kod", &kodlar).Error; err != nil {
+		t.Fatalf("lotlar okunamadı: %v", err)
+	}
+	return kodlar
+}
+
+func TestGeriYukle(t *testing.T) {
+	testVeritabani(t, "")
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrasyon: %v", err)
+	}
+	testLotuYaz(t, "C")
+	yedek, err := YedekAl("test")
+	if err != nil {
+		t.Fatalf("yedek alınamadı: %v", err)
+	}
+	testLotuYaz(t, "USD")
+
+	if err := YedekDogrula(filepath.Base(yedek)); err != nil {
+		t.Fatalf("sağlam yedek reddedildi: %v", err)
+	}
+	onceki, err := GeriYukle(yedek)
+	if err != nil {
+		t.Fatalf("geri yüklenemedi: %v", err)
+	}
+	if kodlar := lotKodlari(t); strings.Join(kodlar, ",") != "C" {
+		t.Errorf("geri yükleme sonrası lotlar = %v, beklenen [C]", kodlar)
+	}
+	if !strings.Contains(onceki, "geri-yukleme-oncesi") {
+		t.Errorf("önceki hal yedeği = %s", onceki)
+	}
+
+	// Geri yükleme öncesi yedek, değiştirilen veritabanını içerir
+	if _, err := GeriYukle(onceki); err != nil {
+		t.Fatalf("önceki hale dönülemedi: %v", err)
+	}
+	if kodlar := lotKodlari(t); strings.Join(kodlar, ",") != "C,USD" {
+		t.Errorf("önceki hale dönüş sonrası lotlar = %v, beklenen [C USD]", kodlar)
+	}
+}
+
+func TestGeriYukleGecersizYedegiReddeder(t *testing.T) {
+	testVeritabani(t, "")
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrasyon: %v", err)
+	}
+	testLotuYaz(t, "C")
+	saglam, err := YedekAl("test")
+	if err != nil {
+		t.Fatalf("yedek alınamadı: %v", err)
+	}
+	dizin := filepath.Dir(saglam)
+
+	veri, err := os.ReadFile(saglam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yarim := filepath.Join(dizin, "yarim.db")
+	if err := os.WriteFile(yarim, veri[:len(veri)/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	bozuk := filepath.Join(dizin, "bozuk.db")
+	if err := os.WriteFile(bozuk, []byte(strings.Repeat("veritabanı değil ", 512)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// envanter tablosu olmayan, başka bir uygulamanın SQLite dosyası
+	yabanci := filepath.Join(dizin, "yabanci.db")
+	yabanciDB, err := gorm.Open(sqlite.Open(yabanci), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	yabanciDB.Exec("CREATE TABLE notlar (metin TEXT)")
+	if sqlDB, err := yabanciDB.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	// uygulamanın bildiğinden yeni şema sürümündeki yedek
+	yeni := filepath.Join(dizin, "yeni.db")
+	if err := dosyaKopyala(saglam, yeni); err != nil {
+		t.Fatal(err)
+	}
+	yeniDB, err := gorm.Open(sqlite.Open(yeni), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	yeniDB.Create(&models.SemaMigrasyonu{Surum: migrasyonlar[len(migrasyonlar)-1].Surum + 1, UygulanmaZamani: time.Now()})
+	if sqlDB, err := yeniDB.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	testLotuYaz(t, "USD")
+	oncekiYedekler, err := Yedekler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testler := []struct {
+		ad, yol, hata string
+	}{
+		{"yarım dosya", yarim, ""},
+		{"bozuk dosya", bozuk, ""},
+		{"envanter tablosu yok", yabanci, "envanter tablosu yok"},
+		{"yeni şema sürümü", yeni, "şema sürümü"},
+	}
+	for _, tt := range testler {
+		t.Run(tt.ad, func(t *testing.T) {
+			if err := YedekDogrula(tt.yol); err == nil {
+				t.Fatal("geçersiz yedek doğrulandı")
+			}
+			_, err := GeriYukle(tt.yol)
+			if err == nil || !strings.Contains(err.Error(), tt.hata) {
+				t.Fatalf("geri yükleme hatası = %v, beklenen %q içeren hata", err, tt.hata)
+			}
+			// Reddedilen yedek veritabanına dokunmaz; geri yükleme öncesi yedek de alınmaz
+			if kodlar := lotKodlari(t); strings.Join(kodlar, ",") != "C,USD" {
+				t.Errorf("reddedilen yedekten sonra lotlar = %v, beklenen [C USD]", kodlar)
+			}
+			if yedekler, _ := Yedekler(); len(yedekler) != len(oncekiYedekler) {
+				t.Errorf("reddedilen yedek için önceki hal yedeği alındı: %d yedek, beklenen %d", len(yedekler), len(oncekiYedekler))
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ParsePrice fiyat stringini decimal'a çevirir (Türkçe format desteği)
+func ParsePrice(price string) decimal.Decimal {
+	// Para birimi sembollerini ve boşlukları temizle
+	price = strings.ReplaceAll(price, " ₺", "")
+	price = strings.ReplaceAll(price, "₺", "")
+	price = strings.TrimSpace(price)
+
+	// Boş string kontrolü
+	if price == "" {
+		return decimal.Zero
+	}
+
+	// Türkçe format: virgül ondalık ayırıcı, nokta binlik ayırıcı
+	// 1.234,56 -> 1234.56
+	// Basit yaklaşım: sadece virgülleri noktaya çevir
+	price = strings.ReplaceAll(price, ",", ".")
+
+	// Birden fazla nokta varsa (binlik ayırıcı durumu), son nokta hariç diğerlerini kaldır
+	dotCount := strings.Count(price, ".")
+	if dotCount > 1 {
+		// En son noktanın pozisyonunu bul
+		lastDotIndex := strings.LastIndex(price, ".")
+		beforeLastDot := price[:lastDotIndex]
+		afterLastDot := price[lastDotIndex:]
+
+		// Son nokta öncesindeki noktaları kaldır (binlik ayırıcı)
+		beforeLastDot = strings.ReplaceAll(beforeLastDot, ".", "")
+		price = beforeLastDot + afterLastDot
+	}
+
+	// Decimal ile parse et
+	result, err := decimal.NewFromString(price)
+	if err != nil {
+		return decimal.Zero
+	}
+	return result
+}
+
+// Money para birimini formatlar (decimal kullanarak hassas)
+func Money(d decimal.Decimal) string {
+	// 2 ondalık basamakla formatla
+	formatted := d.StringFixed(2)
+
+	// Türkçe format'a çevir: 1234.56 -> 1.234,56
+	return TurkishNumber(formatted)
+}
+
+// TurkishNumber sayıyı Türkçe formata çevirir (binlik: nokta, ondalık: virgül)
+func TurkishNumber(numberStr string) string {
+	// Ondalık kısmı ayır
+	parts := strings.Split(numberStr, ".")
+	intPart := parts[0]
+	decimalPart := ""
+
+	if len(parts) > 1 {
+		decimalPart = parts[1]
+		// Sondaki sıfırları kaldır
+		decimalPart = strings.TrimRight(decimalPart, "0")
+	}
+
+	// Binlik ayırıcı ekle (nokta)
+	result := AddThousandSeparator(intPart)
+
+	// Ondalık kısım varsa virgül ile ekle
+	if decimalPart != "" {
+		result = result + "," + decimalPart
+	}
+
+	return result
+}
+
+// Quantity miktarı formatlar (decimal kullanarak hassas)
+func Quantity(d decimal.Decimal, unit string) string {
+	if unit == "adet" {
+		// Adet ise tam sayı olarak göster
+		return d.Truncate(0).String()
+	}
+
+	// Diğer birimler için ondalıklı göster ama gereksiz sıfırları temizle
+	if d.Equal(d.Truncate(0)) {
+		// Tam sayı ise ondalık gösterme
+		return TurkishNumber(d.Truncate(0).String())
+	}
+
+	// 2 ondalık basamakla formatla
+	formatted := d.StringFixed(2)
+	return TurkishNumber(formatted)
+}
+
+// AddThousandSeparator sayıya binlik ayırıcı ekler
+func AddThousandSeparator(s string) string {
+	// Negatif sayıları kontrol et
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+
+	n := len(s)
+	if n <= 3 {
+		if negative {
+			return "-" + s
+		}
+		return s
+	}
+
+	var result strings.Builder
+	for i, digit := range s {
+		if i > 0 && (n-i)%3 == 0 {
+			result.WriteString(".")
+		}
+		result.WriteRune(digit)
+	}
+
+	if negative {
+		return "-" + result.String()
+	}
+	return result.String()
+}
@@ -0,0 +1,103 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAddThousandSeparator(t *testing.T) {
+	testler := map[string]string{
+		"":          "",
+		"0":         "0",
+		"123":       "123",
+		"1234":      "1.234",
+		"123456":    "123.456",
+		"1234567":   "1.234.567",
+		"-12":       "-12",
+		"-1234":     "-1.234",
+		"-9876543":  "-9.876.543",
+		"100000000": "100.000.000",
+	}
+
+	for girdi, want := range testler {
+		if got := AddThousandSeparator(girdi); got != want {
+			t.Errorf("AddThousandSeparator(%q) = %q, beklenen %q", girdi, got, want)
+		}
+	}
+}
+
+func TestTurkishNumber(t *testing.T) {
+	testler := map[string]string{
+		"1234":       "1.234",
+		"1234.56":    "1.234,56",
+		"1234.5000":  "1.234,5",
+		"1234.00":    "1.234",
+		"-4342.4900": "-4.342,49",
+		"0.25":       "0,25",
+	}
+
+	for girdi, want := range testler {
+		if got := TurkishNumber(girdi); got != want {
+			t.Errorf("TurkishNumber(%q) = %q, beklenen %q", girdi, got, want)
+		}
+	}
+}
+
+func TestMoney(t *testing.T) {
+	testler := map[string]string{
+		"0":           "0",
+		"1234.56":     "1.234,56",
+		"1234.5":      "1.234,5",
+		"1234":        "1.234",
+		"-9876543.21": "-9.876.543,21",
+		"0.005":       "0,01",
+		"14040.004":   "14.040",
+	}
+
+	for girdi, want := range testler {
+		if got := Money(decimal.RequireFromString(girdi)); got != want {
+			t.Errorf("Money(%s) = %q, beklenen %q", girdi, got, want)
+		}
+	}
+}
+
+func TestQuantity(t *testing.T) {
+	testler := []struct {
+		miktar string
+		birim  string
+		want   string
+	}{
+		{"2", "adet", "2"},
+		{"2.7", "adet", "2"},
+		{"1234", "gram", "1.234"},
+		{"10.50", "gram", "10,5"},
+		{"1.505", "gram", "1,51"},
+		{"0.25", "gram", "0,25"},
+	}
+
+	for _, tt := range testler {
+		if got := Quantity(decimal.RequireFromString(tt.miktar), tt.birim); got != tt.want {
+			t.Errorf("Quantity(%s, %s) = %q, beklenen %q", tt.miktar, tt.birim, got, tt.want)
+		}
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	testler := map[string]string{
+		"":            "0",
+		"abc":         "0",
+		"4342.49":     "4342.49",
+		"1,5":         "1.5",
+		"1.234,56":    "1234.56",
+		"1.234,56 ₺":  "1234.56",
+		"₺ 9.500,00":  "9500",
+		"1.234.567,8": "1234567.8",
+	}
+
+	for girdi, want := range testler {
+		if got := ParsePrice(girdi); !got.Equal(decimal.RequireFromString(want)) {
+			t.Errorf("ParsePrice(%q) = %s, beklenen %s", girdi, got, want)
+		}
+	}
+}
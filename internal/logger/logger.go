@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level günlükleme seviyesi
+type Level int32
+
+// Günlükleme seviyeleri (küçükten büyüğe)
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// seviyeEtiketleri log satırlarının başına eklenen seviye etiketleri
+var seviyeEtiketleri = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+// aktifSeviye bu seviyenin altındaki mesajlar yazılmaz (varsayılan: ERROR)
+var aktifSeviye atomic.Int32
+
+func init() {
+	aktifSeviye.Store(int32(LevelError))
+}
+
+// ParseLevel seviye adını Level'a çevirir (DEBUG, INFO, WARN, ERROR)
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	}
+	return LevelError, fmt.Errorf("geçersiz log seviyesi: %s", name)
+}
+
+// SetLevel aktif günlükleme seviyesini ayarlar
+func SetLevel(level Level) {
+	aktifSeviye.Store(int32(level))
+}
+
+// GetLevel aktif günlükleme seviyesini döner
+func GetLevel() Level {
+	return Level(aktifSeviye.Load())
+}
+
+// String seviye adını döner
+func (l Level) String() string {
+	if etiket, ok := seviyeEtiketleri[l]; ok {
+		return etiket
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// Debugf DEBUG seviyesinde log yazar
+func Debugf(format string, args ...interface{}) {
+	yaz(LevelDebug, format, args...)
+}
+
+// Infof INFO seviyesinde log yazar
+func Infof(format string, args ...interface{}) {
+	yaz(LevelInfo, format, args...)
+}
+
+// Warnf WARN seviyesinde log yazar
+func Warnf(format string, args ...interface{}) {
+	yaz(LevelWarn, format, args...)
+}
+
+// Errorf ERROR seviyesinde log yazar
+func Errorf(format string, args ...interface{}) {
+	yaz(LevelError, format, args...)
+}
+
+// yaz seviye filtresinden geçen mesajı standart log çıktısına yazar
+func yaz(level Level, format string, args ...interface{}) {
+	if level < GetLevel() {
+		return
+	}
+	// calldepth 3: yaz -> Debugf/Infof/... -> çağıran
+	_ = log.Output(3, "["+level.String()+"] "+fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,238 @@
+// Package metrik Prometheus metin formatında sayaç, histogram ve gösterge üretir.
+// Harici istemci kütüphanesi kullanılmaz; uygulamanın ihtiyaç duyduğu kadarı yazılmıştır.
+package metrik
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metrik Yaz ile dışa aktarılabilen metrik
+type metrik interface {
+	yaz(w io.Writer)
+}
+
+var (
+	kayitMu  sync.Mutex
+	kayitlar []metrik
+)
+
+// kaydet metriği Yaz çıktısına ekler
+func kaydet(m metrik) {
+	kayitMu.Lock()
+	defer kayitMu.Unlock()
+	kayitlar = append(kayitlar, m)
+}
+
+// Yaz kayıtlı tüm sayaç ve histogramları Prometheus metin formatında yazar
+func Yaz(w io.Writer) {
+	kayitMu.Lock()
+	liste := append([]metrik(nil), kayitlar...)
+	kayitMu.Unlock()
+
+	for _, m := range liste {
+		m.yaz(w)
+	}
+}
+
+// Sayac etiket değerlerine göre ayrılan, yalnızca artan sayaç
+type Sayac struct {
+	ad, aciklama string
+	etiketAdlari []string
+
+	mu      sync.Mutex
+	seriler map[string]*seri
+}
+
+// seri bir etiket kombinasyonunun değeri
+type seri struct {
+	etiketler []string
+	deger     float64
+}
+
+// NewSayac yeni sayaç oluşturur ve Yaz çıktısına kaydeder
+func NewSayac(ad, aciklama string, etiketAdlari ...string) *Sayac {
+	s := &Sayac{ad: ad, aciklama: aciklama, etiketAdlari: etiketAdlari, seriler: make(map[string]*seri)}
+	kaydet(s)
+	return s
+}
+
+// Artir etiket değerlerine ait sayacı bir artırır
+func (s *Sayac) Artir(etiketDegerleri ...string) {
+	s.Ekle(1, etiketDegerleri...)
+}
+
+// Ekle etiket değerlerine ait sayaca verilen (negatif olmayan) değeri ekler
+func (s *Sayac) Ekle(deger float64, etiketDegerleri ...string) {
+	if deger < 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	anahtar := strings.Join(etiketDegerleri, "\x00")
+	sr, ok := s.seriler[anahtar]
+	if !ok {
+		sr = &seri{etiketler: append([]string(nil), etiketDegerleri...)}
+		s.seriler[anahtar] = sr
+	}
+	sr.deger += deger
+}
+
+func (s *Sayac) yaz(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	basligiYaz(w, s.ad, s.aciklama, "counter")
+	for _, anahtar := range siraliAnahtarlar(s.seriler) {
+		sr := s.seriler[anahtar]
+		satirYaz(w, s.ad, etiketleriBirlestir(s.etiketAdlari, sr.etiketler), sr.deger)
+	}
+}
+
+// Histogram etiket değerlerine göre ayrılan, sabit sınırlı histogram
+type Histogram struct {
+	ad, aciklama string
+	etiketAdlari []string
+	sinirlar     []float64
+
+	mu      sync.Mutex
+	seriler map[string]*histogramSerisi
+}
+
+// histogramSerisi bir etiket kombinasyonunun kova sayıları (kümülatif olmayan), toplamı ve adedi
+type histogramSerisi struct {
+	etiketler []string
+	kovalar   []uint64
+	toplam    float64
+	adet      uint64
+}
+
+// NewHistogram artan sınırlarla yeni histogram oluşturur ve Yaz çıktısına kaydeder
+func NewHistogram(ad, aciklama string, sinirlar []float64, etiketAdlari ...string) *Histogram {
+	sirali := append([]float64(nil), sinirlar...)
+	sort.Float64s(sirali)
+	h := &Histogram{ad: ad, aciklama: aciklama, etiketAdlari: etiketAdlari, sinirlar: sirali, seriler: make(map[string]*histogramSerisi)}
+	kaydet(h)
+	return h
+}
+
+// Gozlemle değeri etiket değerlerine ait histograma ekler
+func (h *Histogram) Gozlemle(deger float64, etiketDegerleri ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	anahtar := strings.Join(etiketDegerleri, "\x00")
+	sr, ok := h.seriler[anahtar]
+	if !ok {
+		sr = &histogramSerisi{etiketler: append([]string(nil), etiketDegerleri...), kovalar: make([]uint64, len(h.sinirlar))}
+		h.seriler[anahtar] = sr
+	}
+	for i, sinir := range h.sinirlar {
+		if deger <= sinir {
+			sr.kovalar[i]++
+			break
+		}
+	}
+	sr.toplam += deger
+	sr.adet++
+}
+
+func (h *Histogram) yaz(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	basligiYaz(w, h.ad, h.aciklama, "histogram")
+	kovaEtiketAdlari := append(append([]string(nil), h.etiketAdlari...), "le")
+	for _, anahtar := range siraliAnahtarlar(h.seriler) {
+		sr := h.seriler[anahtar]
+		kovaEtiketleri := append(append([]string(nil), sr.etiketler...), "")
+		var kumulatif uint64
+		for i, sinir := range h.sinirlar {
+			kumulatif += sr.kovalar[i]
+			kovaEtiketleri[len(kovaEtiketleri)-1] = sayiMetni(sinir)
+			satirYaz(w, h.ad+"_bucket", etiketleriBirlestir(kovaEtiketAdlari, kovaEtiketleri), float64(kumulatif))
+		}
+		kovaEtiketleri[len(kovaEtiketleri)-1] = "+Inf"
+		satirYaz(w, h.ad+"_bucket", etiketleriBirlestir(kovaEtiketAdlari, kovaEtiketleri), float64(sr.adet))
+		satirYaz(w, h.ad+"_sum", etiketleriBirlestir(h.etiketAdlari, sr.etiketler), sr.toplam)
+		satirYaz(w, h.ad+"_count", etiketleriBirlestir(h.etiketAdlari, sr.etiketler), float64(sr.adet))
+	}
+}
+
+// Gosterge istek anında hesaplanan değerler için gösterge. Kaydedilmez; oluşturan kod Yaz ile yazar.
+type Gosterge struct {
+	ad, aciklama string
+	etiketAdlari []string
+	seriler      []seri
+}
+
+// NewGosterge yeni gösterge oluşturur
+func NewGosterge(ad, aciklama string, etiketAdlari ...string) *Gosterge {
+	return &Gosterge{ad: ad, aciklama: aciklama, etiketAdlari: etiketAdlari}
+}
+
+// Ayarla etiket değerlerine ait değeri ekler
+func (g *Gosterge) Ayarla(deger float64, etiketDegerleri ...string) {
+	g.seriler = append(g.seriler, seri{etiketler: etiketDegerleri, deger: deger})
+}
+
+// Yaz göstergeyi Prometheus metin formatında yazar
+func (g *Gosterge) Yaz(w io.Writer) {
+	basligiYaz(w, g.ad, g.aciklama, "gauge")
+	for _, sr := range g.seriler {
+		satirYaz(w, g.ad, etiketleriBirlestir(g.etiketAdlari, sr.etiketler), sr.deger)
+	}
+}
+
+// basligiYaz HELP ve TYPE satırlarını yazar
+func basligiYaz(w io.Writer, ad, aciklama, tip string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", ad, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(aciklama))
+	fmt.Fprintf(w, "# TYPE %s %s\n", ad, tip)
+}
+
+// satirYaz tek bir örnek satırı yazar
+func satirYaz(w io.Writer, ad, etiketler string, deger float64) {
+	fmt.Fprintf(w, "%s%s %s\n", ad, etiketler, sayiMetni(deger))
+}
+
+// etiketleriBirlestir {ad="değer",...} biçiminde etiket metni oluşturur
+func etiketleriBirlestir(adlar, degerler []string) string {
+	if len(adlar) == 0 {
+		return ""
+	}
+	kacis := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parcalar := make([]string, len(adlar))
+	for i, ad := range adlar {
+		deger := ""
+		if i < len(degerler) {
+			deger = degerler[i]
+		}
+		parcalar[i] = fmt.Sprintf(`%s="%s"`, ad, kacis.Replace(deger))
+	}
+	return "{" + strings.Join(parcalar, ",") + "}"
+}
+
+// sayiMetni değeri Prometheus'un kabul ettiği biçimde yazar
+func sayiMetni(deger float64) string {
+	switch {
+	case math.IsInf(deger, 1):
+		return "+Inf"
+	case math.IsInf(deger, -1):
+		return "-Inf"
+	case math.IsNaN(deger):
+		return "NaN"
+	}
+	return strconv.FormatFloat(deger, 'g', -1, 64)
+}
+
+// siraliAnahtarlar çıktının kararlı olması için seri anahtarlarını sıralı döner
+func siraliAnahtarlar[T any](seriler map[string]T) []string {
+	anahtarlar := make([]string, 0, len(seriler))
+	for anahtar := range seriler {
+		anahtarlar = append(anahtarlar, anahtar)
+	}
+	sort.Strings(anahtarlar)
+	return anahtarlar
+}
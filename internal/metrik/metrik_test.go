@@ -0,0 +1,62 @@
+package metrik
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestYaz(t *testing.T) {
+	sayac := NewSayac("test_istekler_total", "İstek sayısı\nikinci satır \\ ters bölü", "yol", "sonuc")
+	sayac.Artir(`/a"b`, "ok")
+	sayac.Artir(`/a"b`, "ok")
+	sayac.Ekle(-1, `/a"b`, "ok") // negatif değer yok sayılır
+	sayac.Ekle(0.5, "c:\\d\ne", "hata")
+
+	// Sınırlar sıralanır; her değer ilk sığdığı kovaya, çıktıda kümülatif olarak yazılır
+	histogram := NewHistogram("test_sure_seconds", "İstek süresi", []float64{1, 0.5}, "yol")
+	histogram.Gozlemle(0.25, "/x")
+	histogram.Gozlemle(0.75, "/x")
+	histogram.Gozlemle(3, "/x")
+
+	var b strings.Builder
+	Yaz(&b)
+	beklenen := `# HELP test_istekler_total İstek sayısı\nikinci satır \\ ters bölü
+# TYPE test_istekler_total counter
+test_istekler_total{yol="/a\"b",sonuc="ok"} 2
+test_istekler_total{yol="c:\\d\ne",sonuc="hata"} 0.5
+# HELP test_sure_seconds İstek süresi
+# TYPE test_sure_seconds histogram
+test_sure_seconds_bucket{yol="/x",le="0.5"} 1
+test_sure_seconds_bucket{yol="/x",le="1"} 2
+test_sure_seconds_bucket{yol="/x",le="+Inf"} 3
+test_sure_seconds_sum{yol="/x"} 4
+test_sure_seconds_count{yol="/x"} 3
+`
+	if b.String() != beklenen {
+		t.Errorf("çıktı:\n%s\nbeklenen:\n%s", b.String(), beklenen)
+	}
+}
+
+func TestGosterge(t *testing.T) {
+	gosterge := NewGosterge("test_deger", "Kodun değeri", "kod")
+	gosterge.Ayarla(1.5, "C")
+	gosterge.Ayarla(math.Inf(1), "X")
+	tekil := NewGosterge("test_tekil", "Etiketsiz gösterge")
+	tekil.Ayarla(1.7e9)
+
+	var b strings.Builder
+	gosterge.Yaz(&b)
+	tekil.Yaz(&b)
+	beklenen := `# HELP test_deger Kodun değeri
+# TYPE test_deger gauge
+test_deger{kod="C"} 1.5
+test_deger{kod="X"} +Inf
+# HELP test_tekil Etiketsiz gösterge
+# TYPE test_tekil gauge
+test_tekil 1.7e+09
+`
+	if b.String() != beklenen {
+		t.Errorf("çıktı:\n%s\nbeklenen:\n%s", b.String(), beklenen)
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Alarm tipleri
+const (
+	AlarmFiyatUstu = "fiyat_ustu" // Kodun fiyatı eşiğin üstüne çıktı
+	AlarmFiyatAlti = "fiyat_alti" // Kodun fiyatı eşiğin altına indi
+	AlarmDegisim   = "degisim"    // Kodun günlük değişimi (mutlak %) eşiği aştı
+	AlarmKarUstu   = "kar_ustu"   // Portföy kar/zararı eşiğin üstüne çıktı (TL)
+	AlarmKarAlti   = "kar_alti"   // Portföy kar/zararı eşiğin altına indi (TL)
+)
+
+// Fiyat alarmlarında karşılaştırılan kuyumcu fiyatı
+const (
+	AlarmAlanAlis  = "alis"  // Kuyumcunun alış fiyatı (bize ödeyeceği)
+	AlarmAlanSatis = "satis" // Kuyumcunun satış fiyatı (bizim ödeyeceğimiz)
+)
+
+// AlarmTipleri desteklenen alarm tipleri ve görünen isimleri (sıralı)
+var AlarmTipleri = []struct {
+	Kod  string
+	Isim string
+}{
+	{AlarmFiyatUstu, "Fiyat üstünde"},
+	{AlarmFiyatAlti, "Fiyat altında"},
+	{AlarmDegisim, "Günlük değişim %"},
+	{AlarmKarUstu, "Kar/zarar üstünde"},
+	{AlarmKarAlti, "Kar/zarar altında"},
+}
+
+// Alarm kullanıcı tanımlı fiyat veya portföy eşiği. Her fiyat yenilemesinde değerlendirilir;
+// koşul sağlandığında bir kez tetiklenir, koşul ortadan kalkınca yeniden kurulur.
+type Alarm struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Ad   string          `json:"ad,omitempty"`                      // Boşsa koşuldan üretilir
+	Tip  string          `gorm:"not null" json:"tip"`               // fiyat_ustu, fiyat_alti, degisim, kar_ustu, kar_alti
+	Kod  string          `gorm:"index" json:"kod,omitempty"`        // Fiyat ve değişim alarmlarında API kodu
+	Alan string          `gorm:"not null;default:alis" json:"alan"` // Fiyat alarmlarında alis veya satis
+	Esik decimal.Decimal `gorm:"type:text;not null" json:"esik"`    // TL veya yüzde
+
+	// Kar alarmlarında portföy (0: tüm portföyler)
+	PortfoyID uint `gorm:"not null;default:0" json:"portfoy_id"`
+
+	// Tetiklenince çalıştırılacak kabuk komutu ve/veya JSON POST atılacak adres
+	Komut   string `json:"komut,omitempty"`
+	Webhook string `json:"webhook,omitempty"`
+
+	Aktif         bool             `gorm:"not null;default:true" json:"aktif"`
+	Tetiklendi    bool             `gorm:"not null;default:false" json:"tetiklendi"` // Koşul sağlanıyor, tekrar tetiklenmez
+	SonTetiklenme *time.Time       `json:"son_tetiklenme,omitempty"`
+	SonDeger      *decimal.Decimal `gorm:"type:text" json:"son_deger,omitempty"` // Son değerlendirmede ölçülen değer
+}
+
+// TableName GORM için tablo adını belirtir
+func (Alarm) TableName() string {
+	return "alarmlar"
+}
+
+// AlarmTipiIsmi alarm tipinin görünen ismini döner
+func AlarmTipiIsmi(tip string) string {
+	for _, t := range AlarmTipleri {
+		if t.Kod == tip {
+			return t.Isim
+		}
+	}
+	return tip
+}
+
+// KodGerekli fiyat ve değişim alarmlarının bir API koduna bağlı olduğunu belirtir
+func (a *Alarm) KodGerekli() bool {
+	return a.Tip == AlarmFiyatUstu || a.Tip == AlarmFiyatAlti || a.Tip == AlarmDegisim
+}
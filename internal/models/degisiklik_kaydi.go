@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Değişiklik türleri
+const (
+	DegisiklikEkleme      = "ekleme"       // Yeni lot (form, add komutu veya API)
+	DegisiklikIceAktarim  = "ice_aktarim"  // CSV içe aktarımıyla eklenen lot
+	DegisiklikDuzenleme   = "duzenleme"    // Lot bilgilerinin düzenlenmesi
+	DegisiklikCikis       = "cikis"        // Satış veya hediye çıkışıyla miktarın azalması
+	DegisiklikSilme       = "silme"        // Çöp kutusuna taşıma
+	DegisiklikGeriAlma    = "geri_alma"    // Çöp kutusundan geri alma
+	DegisiklikKaliciSilme = "kalici_silme" // Çöp kutusundan kalıcı silme
+)
+
+// DegisiklikTurleri değişiklik türlerinin görünen isimleri
+var DegisiklikTurleri = map[string]string{
+	DegisiklikEkleme:      "Ekleme",
+	DegisiklikIceAktarim:  "İçe Aktarım",
+	DegisiklikDuzenleme:   "Düzenleme",
+	DegisiklikCikis:       "Çıkış",
+	DegisiklikSilme:       "Silme",
+	DegisiklikGeriAlma:    "Geri Alma",
+	DegisiklikKaliciSilme: "Kalıcı Silme",
+}
+
+// DegisiklikKaydi bir envanter kaydındaki kullanıcı değişikliğinin denetim kaydı. Lotun
+// değişiklik öncesi ve sonrası hali JSON olarak saklanır; kayıtlar lot kalıcı silinse de
+// korunur. Fiyat güncellemesinin yazdığı güncel değerler kaydedilmez.
+type DegisiklikKaydi struct {
+	ID    uint      `gorm:"primaryKey" json:"id"`
+	Zaman time.Time `gorm:"not null;index" json:"zaman"`
+
+	EnvanterID uint   `gorm:"not null;index" json:"envanter_id"`
+	Tur        string `gorm:"not null" json:"tur"` // ekleme, duzenleme, cikis, silme, ...
+
+	// Değişikliği yapan: işletim sistemi kullanıcısı ve arayüz (tui, cli, api)
+	Kullanici string `json:"kullanici"`
+	Kaynak    string `json:"kaynak"`
+
+	Onceki  string `gorm:"type:text" json:"onceki,omitempty"`  // Değişiklik öncesi Envanter JSON'u (eklemede boş)
+	Sonraki string `gorm:"type:text" json:"sonraki,omitempty"` // Değişiklik sonrası Envanter JSON'u (kalıcı silmede boş)
+}
+
+// TableName GORM için tablo adını belirtir
+func (DegisiklikKaydi) TableName() string {
+	return "degisiklik_kaydi"
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -13,25 +14,31 @@ type Envanter struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Lotun ait olduğu portföy
+	PortfoyID uint `gorm:"not null;default:1;index" json:"portfoy_id"`
+
 	// Ürün bilgileri
-	Tur    string  `gorm:"not null" json:"tur"`    // "Altın" veya "Döviz"
-	Cins   string  `gorm:"not null" json:"cins"`   // "22 Ayar Külçe", "Cumhuriyet Altını", "USD", "EUR" vb.
-	Kod    string  `gorm:"not null" json:"kod"`    // API'deki kod: "GAT", "C", "USD", "EUR" vb.
-	Miktar float64 `gorm:"not null" json:"miktar"` // Gram veya adet
-	Birim  string  `gorm:"not null" json:"birim"`  // "gram", "adet"
+	Tur    string          `gorm:"not null" json:"tur"`              // "Altın" veya "Döviz"
+	Cins   string          `gorm:"not null" json:"cins"`             // "22 Ayar Külçe", "Cumhuriyet Altını", "USD", "EUR" vb.
+	Kod    string          `gorm:"not null" json:"kod"`              // API'deki kod: "GAT", "C", "USD", "EUR" vb.
+	Miktar decimal.Decimal `gorm:"type:text;not null" json:"miktar"` // Kalan miktar, gram veya adet (satış/çıkışlarda azalır)
+	Birim  string          `gorm:"not null" json:"birim"`            // "gram", "adet"
 
 	// Alış bilgileri
-	AlisTarihi time.Time `gorm:"not null" json:"alis_tarihi"`
-	AlisFiyati float64   `gorm:"not null" json:"alis_fiyati"` // Birim başına alış fiyatı (TL)
-	ToplamAlis float64   `gorm:"not null" json:"toplam_alis"` // Toplam alış tutarı (TL)
+	GirisTipi  string          `gorm:"not null;default:alis" json:"giris_tipi"` // "alis" veya "hediye_giris"
+	AlisTarihi time.Time       `gorm:"not null" json:"alis_tarihi"`
+	AlisFiyati decimal.Decimal `gorm:"type:text;not null" json:"alis_fiyati"` // Birim başına alış fiyatı (TL)
+	ToplamAlis decimal.Decimal `gorm:"type:text;not null" json:"toplam_alis"` // Toplam alış tutarı (TL)
 
 	// Güncel değer bilgileri (her çalıştırmada güncellenir)
-	GuncelFiyat float64 `json:"guncel_fiyat"` // Birim başına güncel fiyat (TL)
-	GuncelTutar float64 `json:"guncel_tutar"` // Toplam güncel tutar (TL)
+	GuncelFiyat       decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"guncel_fiyat"`        // Birim başına güncel fiyat, değerleme moduna göre (TL)
+	GuncelTutar       decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"guncel_tutar"`        // Toplam güncel tutar (TL)
+	GuncelAlisFiyati  decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"guncel_alis_fiyati"`  // Kuyumcunun güncel alış fiyatı (TL)
+	GuncelSatisFiyati decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"guncel_satis_fiyati"` // Kuyumcunun güncel satış fiyatı (TL)
 
 	// Kar/Zarar
-	KarZarar      float64 `json:"kar_zarar"`       // Güncel tutar - Toplam alış
-	KarZararYuzde float64 `json:"kar_zarar_yuzde"` // (Kar/Zarar / Toplam alış) * 100
+	KarZarar      decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"kar_zarar"`       // Güncel tutar - Toplam alış
+	KarZararYuzde decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"kar_zarar_yuzde"` // (Kar/Zarar / Toplam alış) * 100
 
 	// API kaynak bilgisi
 	APIKaynak string `json:"api_kaynak,omitempty"` // Hangi API'den fiyat alındığı
@@ -47,9 +54,43 @@ func (Envanter) TableName() string {
 
 // GuncelDegerleriHesapla güncel fiyata göre değerleri hesaplar
 func (e *Envanter) GuncelDegerleriHesapla() {
-	e.GuncelTutar = e.Miktar * e.GuncelFiyat
-	e.KarZarar = e.GuncelTutar - e.ToplamAlis
-	if e.ToplamAlis > 0 {
-		e.KarZararYuzde = (e.KarZarar / e.ToplamAlis) * 100
+	e.GuncelTutar = e.Miktar.Mul(e.GuncelFiyat)
+	e.KarZarar = e.GuncelTutar.Sub(e.ToplamAlis)
+	e.KarZararYuzde = YuzdeHesapla(e.KarZarar, e.ToplamAlis)
+}
+
+// AcilisIslemi lotun giriş (alış veya hediye girişi) işlemini oluşturur. Lot miktarı çıkışlardan
+// sonra kalan miktar olduğundan işlem miktarı kalan ile çıkan miktarın toplamıdır.
+func (e *Envanter) AcilisIslemi(cikanMiktar decimal.Decimal) Islem {
+	tip := e.GirisTipi
+	if tip != IslemHediyeGiris {
+		tip = IslemAlis
+	}
+	miktar := e.Miktar.Add(cikanMiktar)
+	return Islem{
+		EnvanterID: e.ID,
+		Kod:        e.Kod,
+		Tip:        tip,
+		Tarih:      e.AlisTarihi,
+		Miktar:     miktar,
+		BirimFiyat: e.AlisFiyati,
+		Tutar:      miktar.Mul(e.AlisFiyati),
+	}
+}
+
+// MakasMaliyeti pozisyona gömülü alış-satış makası maliyetini döner
+// (satış fiyatı bilinmiyorsa 0)
+func (e *Envanter) MakasMaliyeti() decimal.Decimal {
+	if !e.GuncelAlisFiyati.IsPositive() || !e.GuncelSatisFiyati.IsPositive() {
+		return decimal.Zero
+	}
+	return e.GuncelSatisFiyati.Sub(e.GuncelAlisFiyati).Mul(e.Miktar)
+}
+
+// YuzdeHesapla pay/payda oranını yüzde olarak döner (4 ondalık); payda pozitif değilse 0
+func YuzdeHesapla(pay, payda decimal.Decimal) decimal.Decimal {
+	if !payda.IsPositive() {
+		return decimal.Zero
 	}
+	return pay.Mul(decimal.NewFromInt(100)).DivRound(payda, 4)
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// FiyatGecmisi API'den çekilen fiyatların geçmişini saklar. Her kod için yalnızca
+// fiyatın değiştiği çekimler kaydedilir.
+type FiyatGecmisi struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Kod     string   `gorm:"not null;index:idx_fiyat_gecmisi_kod_zaman,priority:1" json:"kod"`
+	Alis    float64  `gorm:"not null" json:"alis"`  // Kuyumcunun alış fiyatı (TL)
+	Satis   float64  `gorm:"not null" json:"satis"` // Kuyumcunun satış fiyatı (TL)
+	Degisim *float64 `json:"degisim,omitempty"`     // API'nin bildirdiği günlük değişim (%)
+
+	GuncellenmeZamani string    `json:"guncellenme_zamani"`                                                          // API'nin bildirdiği güncellenme zamanı
+	CekilmeZamani     time.Time `gorm:"not null;index:idx_fiyat_gecmisi_kod_zaman,priority:2" json:"cekilme_zamani"` // Fiyatın çekildiği zaman
+	Kaynak            string    `json:"kaynak"`                                                                      // Fiyat sağlayıcısı
+}
+
+// TableName GORM için tablo adını belirtir
+func (FiyatGecmisi) TableName() string {
+	return "fiyat_gecmisi"
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// İşlem tipleri
+const (
+	IslemAlis        = "alis"         // Satın alma
+	IslemSatis       = "satis"        // Satış
+	IslemHediyeGiris = "hediye_giris" // Hediye olarak gelen
+	IslemHediyeCikis = "hediye_cikis" // Hediye olarak verilen
+)
+
+// IslemTipleri işlem tiplerinin görünen isimleri
+var IslemTipleri = map[string]string{
+	IslemAlis:        "Alış",
+	IslemSatis:       "Satış",
+	IslemHediyeGiris: "Hediye Girişi",
+	IslemHediyeCikis: "Hediye Çıkışı",
+}
+
+// Islem bir envanter kalemine ait alış, satış ve hediye hareketlerini temsil eder
+type Islem struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	EnvanterID uint   `gorm:"not null;index" json:"envanter_id"` // İşlemin ait olduğu lot
+	Kod        string `gorm:"not null;index" json:"kod"`         // Lotun API kodu
+	Tip        string `gorm:"not null" json:"tip"`               // alis, satis, hediye_giris, hediye_cikis
+
+	Tarih      time.Time       `gorm:"not null" json:"tarih"`
+	Miktar     decimal.Decimal `gorm:"type:text;not null" json:"miktar"`                  // İşlem miktarı (gram veya adet)
+	BirimFiyat decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"birim_fiyat"` // Birim başına alış/satış fiyatı (TL)
+	Tutar      decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"tutar"`       // Miktar * BirimFiyat
+
+	// Çıkış işlemlerinde (satış, hediye çıkışı) lottan düşülen maliyet
+	Maliyet             decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"maliyet"`
+	GerceklesenKarZarar decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"gerceklesen_kar_zarar"` // Satışta Tutar - Maliyet
+
+	// Bir çıkış birden fazla lotu tüketebilir; aynı çıkışa ait işlemler aynı referansı taşır
+	Referans string `gorm:"index" json:"referans,omitempty"`
+	Yontem   string `json:"yontem,omitempty"` // Çıkışta kullanılan maliyet yöntemi
+
+	Notlar string `json:"notlar,omitempty"`
+}
+
+// TableName GORM için tablo adını belirtir
+func (Islem) TableName() string {
+	return "islemler"
+}
+
+// IsCikis işlemin lot miktarını azaltan bir işlem olup olmadığını döner
+func (i *Islem) IsCikis() bool {
+	return i.Tip == IslemSatis || i.Tip == IslemHediyeCikis
+}
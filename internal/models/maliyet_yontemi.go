@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Maliyet yöntemleri (satışta hangi lotların tüketileceğini belirler)
+const (
+	YontemFIFO     = "fifo"     // İlk giren ilk çıkar
+	YontemLIFO     = "lifo"     // Son giren ilk çıkar
+	YontemOrtalama = "ortalama" // Ağırlıklı ortalama maliyet
+)
+
+// MaliyetYontemleri desteklenen yöntemler ve görünen isimleri (sıralı)
+var MaliyetYontemleri = []struct {
+	Kod  string
+	Isim string
+}{
+	{YontemFIFO, "FIFO"},
+	{YontemLIFO, "LIFO"},
+	{YontemOrtalama, "Ağırlıklı Ortalama"},
+}
+
+// MaliyetYontemi bir ürün kodu için seçilmiş maliyet yöntemini saklar
+type MaliyetYontemi struct {
+	Kod       string    `gorm:"primaryKey" json:"kod"`
+	Yontem    string    `gorm:"not null" json:"yontem"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName GORM için tablo adını belirtir
+func (MaliyetYontemi) TableName() string {
+	return "maliyet_yontemleri"
+}
+
+// MaliyetYontemiIsmi yöntem kodunun görünen ismini döner
+func MaliyetYontemiIsmi(yontem string) string {
+	for _, y := range MaliyetYontemleri {
+		if y.Kod == yontem {
+			return y.Isim
+		}
+	}
+	return yontem
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// VarsayilanPortfoyID portföyler eklenmeden önceki kayıtların ve portföy belirtilmeden
+// eklenen lotların ait olduğu ilk portföy
+const VarsayilanPortfoyID = 1
+
+// VarsayilanPortfoyAdi migrasyonda oluşturulan ilk portföyün adı
+const VarsayilanPortfoyAdi = "Ana Portföy"
+
+// Portfoy aynı veritabanında ayrı takip edilen bir varlık sahibi veya hesap (ör. aile bireyleri)
+type Portfoy struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Ad     string `gorm:"not null;uniqueIndex" json:"ad"`
+	Notlar string `json:"notlar,omitempty"`
+}
+
+// TableName GORM için tablo adını belirtir
+func (Portfoy) TableName() string {
+	return "portfoyler"
+}
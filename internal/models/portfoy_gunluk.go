@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Günlük özet seviyeleri
+const (
+	SeviyeToplam = "toplam" // Tüm portföy
+	SeviyeTur    = "tur"    // Tür bazında (Altın, Gümüş, Döviz)
+	SeviyeKod    = "kod"    // Ürün kodu bazında
+)
+
+// PortfoyGunluk portföyün bir güne ait değer özetini saklar. Her gün için
+// toplam, her tür ve her kod ayrı bir satırdır.
+type PortfoyGunluk struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Tarih   string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:1" json:"tarih"`   // YYYY-AA-GG
+	Seviye  string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:2" json:"seviye"`  // toplam, tur, kod
+	Anahtar string `gorm:"not null;uniqueIndex:idx_portfoy_gunluk_anahtar,priority:3" json:"anahtar"` // Tür adı, kod veya "TOPLAM"
+
+	ToplamAlis  decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"toplam_alis"`
+	GuncelTutar decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"guncel_tutar"`
+	KarZarar    decimal.Decimal `gorm:"type:text;not null;default:'0'" json:"kar_zarar"`
+}
+
+// TableName GORM için tablo adını belirtir
+func (PortfoyGunluk) TableName() string {
+	return "portfoy_gunluk"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SemaMigrasyonu veritabanına uygulanmış bir şema migrasyonunu kaydeder
+type SemaMigrasyonu struct {
+	Surum           int       `gorm:"column:version;primaryKey;autoIncrement:false" json:"version"`
+	Aciklama        string    `gorm:"not null" json:"aciklama"`
+	UygulanmaZamani time.Time `gorm:"column:applied_at;not null" json:"applied_at"`
+}
+
+// TableName GORM için tablo adını belirtir
+func (SemaMigrasyonu) TableName() string {
+	return "schema_migrations"
+}
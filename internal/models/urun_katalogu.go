@@ -0,0 +1,92 @@
+package models
+
+// CinsItem her cins için hem görünen isim hem de API kodu tutar
+type CinsItem struct {
+	Name string // Görünen isim
+	Code string // API kodu
+}
+
+// UrunKatalogu türlere göre bilinen ürünler (REST API kodları ve MobilAciklama ile statik olarak kullanılıyor)
+var UrunKatalogu = map[string][]CinsItem{
+	"Altın": {
+		// Ana gram/külçe altınlar (DataGroup=2, Main=true)
+		{"Has Altın", "HH"},
+		{"Külçe / Kesme", "CH"},
+		{"24 Ayar Gram", "GA"},
+		{"22 Ayar Gram", "GAT22"},
+		{"22 Ayar Hurda / Bilezik", "B"},
+
+		// Ana sikkeler (DataGroup=8, Main=true)
+		{"Çeyrek", "C"},
+		{"Yarım", "Y"},
+		{"Tam (Teklik)", "T"},
+		{"Gremse", "G"},
+		{"Ata Cumhuriyet", "A"},
+		{"Reşat", "R"},
+		{"Hamit", "H"},
+
+		// Eski sikkeler (DataGroup=9)
+		{"Eski Çeyrek", "EC"},
+		{"Eski Yarım", "EY"},
+		{"Eski Tam", "ET"},
+		{"Eski Gremse", "EG"},
+		{"Eski Ata Cumhuriyet", "EA"},
+
+		// Diğer ayarlar
+		{"18 Ayar", "18"},
+		{"14 Ayar", "14"},
+	},
+	"Gümüş": {
+		// Gümüş ürünleri (DataGroup=7)
+		{"Granül Gümüş", "SG"},
+		{"Külçe (50 GR)", "AG50"},
+		{"Külçe (500 GR)", "AG500"},
+		{"Külçe (1 KG)", "AG1000"},
+	},
+	"Döviz": {
+		// Ana dövizler (DataGroup=1, Main=true)
+		{"Dolar (USD)", "USD"},
+		{"EURO", "EUR"},
+		{"Sterlin (GBP)", "GBP"},
+
+		// Diğer popüler dövizler
+		{"İsviçre Frangı", "CHF"},
+		{"Japon Yeni", "JPY"},
+		{"S. Arabistan Riyali", "SAR"},
+		{"Avustralya Doları", "AUD"},
+		{"Kanada Doları", "CAD"},
+		{"Rus Rublesi", "RUB"},
+		{"Azerbaycan Manatı", "AZN"},
+		{"Çin Yuanı", "CNY"},
+		{"Romanya Leyi", "RON"},
+		{"B.A.E. Dirhemi", "AED"},
+		{"Bulgar Levası", "BGN"},
+		{"Kuweyt Dinarı", "KWD"},
+	},
+}
+
+// adetliKodlar birimi adet olan sikke kodları
+var adetliKodlar = map[string]bool{
+	"C": true, "Y": true, "T": true, "G": true, "A": true, "R": true, "H": true,
+	"EC": true, "EY": true, "ET": true, "EG": true, "EA": true,
+}
+
+// VarsayilanBirim birim belirtilmediğinde kullanılacak birimi döner (döviz ve sikkeler adet, diğerleri gram)
+func VarsayilanBirim(tur, kod string) string {
+	if tur == "Döviz" || adetliKodlar[kod] {
+		return "adet"
+	}
+	return "gram"
+}
+
+// UrunBul API kodundan ürünün türünü ve cins bilgisini bulur
+func UrunBul(kod string) (tur string, cins CinsItem, ok bool) {
+	for tur, items := range UrunKatalogu {
+		for _, item := range items {
+			if item.Code == kod {
+				return tur, item, true
+			}
+		}
+	}
+	return "", CinsItem{}, false
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/format"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// alarmKancaZamanAsimi tetiklenen alarmın komutu ve webhook isteği için süre sınırı
+const alarmKancaZamanAsimi = 10 * time.Second
+
+// AlarmOlayi tetiklenen (veya test edilen) bir alarm. Webhook'a JSON gövde olarak gönderilir.
+type AlarmOlayi struct {
+	AlarmID uint            `json:"alarm_id"`
+	Ad      string          `json:"ad"`
+	Tip     string          `json:"tip"`
+	Kod     string          `json:"kod,omitempty"`
+	Esik    decimal.Decimal `json:"esik"`
+	Deger   decimal.Decimal `json:"deger"`
+	Mesaj   string          `json:"mesaj"`
+	Zaman   time.Time       `json:"zaman"`
+	Test    bool            `json:"test,omitempty"`
+	Hatalar []string        `json:"hatalar,omitempty"` // Komut veya webhook hataları
+
+	alarm *models.Alarm // Bildirimde çalıştırılacak komut ve webhook
+}
+
+// AlarmService kullanıcı tanımlı alarmları saklar, değerlendirir ve bildirimlerini çalıştırır
+type AlarmService struct {
+	client *http.Client
+}
+
+// NewAlarmService yeni alarm servisi oluşturur
+func NewAlarmService() *AlarmService {
+	return &AlarmService{
+		client: &http.Client{Timeout: alarmKancaZamanAsimi},
+	}
+}
+
+// GetAlarmlar tüm alarmları oluşturulma sırasıyla getirir
+func (s *AlarmService) GetAlarmlar() ([]models.Alarm, error) {
+	var alarmlar []models.Alarm
+	if err := database.GetDB().Order("id asc").Find(&alarmlar).Error; err != nil {
+		return nil, fmt.Errorf("alarmlar getirilemedi: %w", err)
+	}
+	return alarmlar, nil
+}
+
+// AddAlarm alarmı doğrulayıp ekler
+func (s *AlarmService) AddAlarm(alarm *models.Alarm) error {
+	alarm.Kod = strings.ToUpper(strings.TrimSpace(alarm.Kod))
+	alarm.Alan = strings.ToLower(strings.TrimSpace(alarm.Alan))
+	if alarm.Alan == "" {
+		alarm.Alan = models.AlarmAlanAlis
+	}
+
+	switch alarm.Tip {
+	case models.AlarmFiyatUstu, models.AlarmFiyatAlti, models.AlarmDegisim:
+		if alarm.Kod == "" {
+			return fmt.Errorf("%s alarmı için kod gerekli", models.AlarmTipiIsmi(alarm.Tip))
+		}
+		if alarm.Alan != models.AlarmAlanAlis && alarm.Alan != models.AlarmAlanSatis {
+			return fmt.Errorf("geçersiz fiyat alanı: %s (alis veya satis olmalı)", alarm.Alan)
+		}
+		if !alarm.Esik.IsPositive() {
+			return fmt.Errorf("eşik sıfırdan büyük olmalı")
+		}
+		alarm.PortfoyID = 0
+	case models.AlarmKarUstu, models.AlarmKarAlti:
+		alarm.Kod = ""
+		if alarm.PortfoyID != 0 {
+			if err := portfoyVarMi(database.GetDB(), alarm.PortfoyID); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("geçersiz alarm tipi: %s", alarm.Tip)
+	}
+
+	alarm.Aktif = true
+	alarm.Tetiklendi = false
+	if err := database.GetDB().Create(alarm).Error; err != nil {
+		return fmt.Errorf("alarm eklenemedi: %w", err)
+	}
+
+	logger.Infof("Alarm eklendi: ID %d, %s", alarm.ID, AlarmAciklamasi(alarm))
+	database.DegisiklikSonrasi("alarm")
+	return nil
+}
+
+// DeleteAlarm alarmı siler
+func (s *AlarmService) DeleteAlarm(id uint) error {
+	sonuc := database.GetDB().Delete(&models.Alarm{}, id)
+	if sonuc.Error != nil {
+		return fmt.Errorf("alarm silinemedi: %w", sonuc.Error)
+	}
+	if sonuc.RowsAffected == 0 {
+		return fmt.Errorf("alarm bulunamadı: ID %d", id)
+	}
+
+	logger.Infof("Alarm silindi: ID %d", id)
+	database.DegisiklikSonrasi("alarm")
+	return nil
+}
+
+// SetAlarmAktif alarmı açar veya kapatır; açılan alarm yeniden kurulur
+func (s *AlarmService) SetAlarmAktif(id uint, aktif bool) error {
+	sonuc := database.GetDB().Model(&models.Alarm{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"aktif": aktif, "tetiklendi": false})
+	if sonuc.Error != nil {
+		return fmt.Errorf("alarm güncellenemedi: %w", sonuc.Error)
+	}
+	if sonuc.RowsAffected == 0 {
+		return fmt.Errorf("alarm bulunamadı: ID %d", id)
+	}
+
+	logger.Infof("Alarm %d aktif=%t", id, aktif)
+	database.DegisiklikSonrasi("alarm")
+	return nil
+}
+
+// Degerlendir alarmların durumunu günceller (DurumlariGuncelle) ve tetiklenenlerin komut ve
+// webhook'larını çalıştırır (Bildir). Fiyat yenilemesinden sonra çağrılmalıdır.
+func (s *AlarmService) Degerlendir() ([]AlarmOlayi, error) {
+	olaylar, err := s.DurumlariGuncelle()
+	if err != nil {
+		return nil, err
+	}
+	s.Bildir(olaylar)
+	return olaylar, nil
+}
+
+// DurumlariGuncelle aktif alarmları son kaydedilen fiyatlar ve güncel envanter değerleriyle
+// karşılaştırır. Koşulu yeni sağlanan alarmlar tetiklenmiş, koşulu ortadan kalkanlar yeniden
+// kurulmuş olarak kaydedilir ve tetiklenenlerin olayları döner. Bildirimler çalıştırılmaz;
+// böylece veritabanı kilidi tutan çağıranlar Bildir'i kilidi bıraktıktan sonra çağırabilir.
+func (s *AlarmService) DurumlariGuncelle() ([]AlarmOlayi, error) {
+	var alarmlar []models.Alarm
+	if err := database.GetDB().Where("aktif = ?", true).Order("id asc").Find(&alarmlar).Error; err != nil {
+		return nil, fmt.Errorf("alarmlar getirilemedi: %w", err)
+	}
+	if len(alarmlar) == 0 {
+		return nil, nil
+	}
+
+	olcum := newAlarmOlcumu()
+	var olaylar []AlarmOlayi
+	for i := range alarmlar {
+		alarm := &alarmlar[i]
+		deger, err := olcum.olc(alarm)
+		if err != nil {
+			logger.Warnf("Alarm %d değerlendirilemedi: %v", alarm.ID, err)
+			continue
+		}
+
+		saglaniyor := alarmKosulu(alarm, deger)
+		guncelleme := map[string]interface{}{"son_deger": deger}
+		if saglaniyor && !alarm.Tetiklendi {
+			simdi := time.Now()
+			guncelleme["tetiklendi"] = true
+			guncelleme["son_tetiklenme"] = simdi
+
+			olay := alarmOlayiOlustur(alarm, deger, simdi)
+			olay.alarm = alarm
+			logger.Infof("Alarm tetiklendi: %s", olay.Mesaj)
+			olaylar = append(olaylar, olay)
+		} else if !saglaniyor && alarm.Tetiklendi {
+			guncelleme["tetiklendi"] = false
+			logger.Debugf("Alarm %d yeniden kuruldu", alarm.ID)
+		}
+
+		if err := database.GetDB().Model(alarm).Updates(guncelleme).Error; err != nil {
+			logger.Errorf("Alarm %d durumu kaydedilemedi: %v", alarm.ID, err)
+		}
+	}
+	return olaylar, nil
+}
+
+// Bildir DurumlariGuncelle'nin döndüğü olayların komut ve webhook'larını sırayla çalıştırır;
+// hatalar olayların Hatalar alanına yazılır. Veritabanına erişmez.
+func (s *AlarmService) Bildir(olaylar []AlarmOlayi) {
+	for i := range olaylar {
+		if olaylar[i].alarm != nil {
+			olaylar[i].Hatalar = s.bildir(olaylar[i].alarm, olaylar[i])
+		}
+	}
+}
+
+// Test alarmın komut ve webhook'unu koşula bakmadan, güncel değerle çalıştırır. Alarm durumu değişmez.
+func (s *AlarmService) Test(id uint) (*AlarmOlayi, error) {
+	var alarm models.Alarm
+	if err := database.GetDB().First(&alarm, id).Error; err != nil {
+		return nil, fmt.Errorf("alarm bulunamadı: ID %d", id)
+	}
+
+	deger, err := newAlarmOlcumu().olc(&alarm)
+	if err != nil {
+		logger.Warnf("Alarm %d için güncel değer ölçülemedi: %v", alarm.ID, err)
+		deger = decimal.Zero
+	}
+
+	olay := alarmOlayiOlustur(&alarm, deger, time.Now())
+	olay.Test = true
+	olay.Hatalar = s.bildir(&alarm, olay)
+	return &olay, nil
+}
+
+// AlarmAciklamasi alarmın adını, ad yoksa koşulunu okunur biçimde döner
+func AlarmAciklamasi(alarm *models.Alarm) string {
+	if alarm.Ad != "" {
+		return alarm.Ad
+	}
+
+	switch alarm.Tip {
+	case models.AlarmFiyatUstu:
+		return fmt.Sprintf("%s %s > %s ₺", alarm.Kod, alarm.Alan, format.Money(alarm.Esik))
+	case models.AlarmFiyatAlti:
+		return fmt.Sprintf("%s %s < %s ₺", alarm.Kod, alarm.Alan, format.Money(alarm.Esik))
+	case models.AlarmDegisim:
+		return fmt.Sprintf("%s günlük değişim ≥ %%%s", alarm.Kod, alarm.Esik.String())
+	case models.AlarmKarUstu:
+		return fmt.Sprintf("%s K/Z > %s ₺", alarmPortfoyAdi(alarm.PortfoyID), format.Money(alarm.Esik))
+	case models.AlarmKarAlti:
+		return fmt.Sprintf("%s K/Z < %s ₺", alarmPortfoyAdi(alarm.PortfoyID), format.Money(alarm.Esik))
+	}
+	return alarm.Tip
+}
+
+// alarmPortfoyAdi kar alarmının kapsadığı portföyün adını döner
+func alarmPortfoyAdi(portfoyID uint) string {
+	if portfoyID == 0 {
+		return "Portföy"
+	}
+	var portfoy models.Portfoy
+	if err := database.GetDB().First(&portfoy, portfoyID).Error; err != nil {
+		return fmt.Sprintf("Portföy #%d", portfoyID)
+	}
+	return portfoy.Ad
+}
+
+// alarmKosulu ölçülen değerin alarm koşulunu sağlayıp sağlamadığını döner
+func alarmKosulu(alarm *models.Alarm, deger decimal.Decimal) bool {
+	switch alarm.Tip {
+	case models.AlarmFiyatUstu, models.AlarmKarUstu:
+		return deger.GreaterThan(alarm.Esik)
+	case models.AlarmFiyatAlti, models.AlarmKarAlti:
+		return deger.LessThan(alarm.Esik)
+	case models.AlarmDegisim:
+		return deger.Abs().GreaterThanOrEqual(alarm.Esik)
+	}
+	return false
+}
+
+// alarmOlayiOlustur alarm ve ölçülen değerden bildirim olayı oluşturur
+func alarmOlayiOlustur(alarm *models.Alarm, deger decimal.Decimal, zaman time.Time) AlarmOlayi {
+	var olculen string
+	if alarm.Tip == models.AlarmDegisim {
+		olculen = "%" + deger.StringFixed(2)
+	} else {
+		olculen = format.Money(deger) + " ₺"
+	}
+
+	return AlarmOlayi{
+		AlarmID: alarm.ID,
+		Ad:      AlarmAciklamasi(alarm),
+		Tip:     alarm.Tip,
+		Kod:     alarm.Kod,
+		Esik:    alarm.Esik,
+		Deger:   deger,
+		Mesaj:   fmt.Sprintf("%s (şu an: %s)", AlarmAciklamasi(alarm), olculen),
+		Zaman:   zaman,
+	}
+}
+
+// bildir alarmın komutunu ve webhook'unu çalıştırır, oluşan hataları döner
+func (s *AlarmService) bildir(alarm *models.Alarm, olay AlarmOlayi) []string {
+	var hatalar []string
+	if alarm.Komut != "" {
+		if err := alarmKomutuCalistir(alarm.Komut, olay); err != nil {
+			logger.Errorf("Alarm %d komutu başarısız: %v", alarm.ID, err)
+			hatalar = append(hatalar, fmt.Sprintf("komut: %v", err))
+		}
+	}
+	if alarm.Webhook != "" {
+		if err := s.webhookGonder(alarm.Webhook, olay); err != nil {
+			logger.Errorf("Alarm %d webhook'u başarısız: %v", alarm.ID, err)
+			hatalar = append(hatalar, fmt.Sprintf("webhook: %v", err))
+		}
+	}
+	return hatalar
+}
+
+// alarmKomutuCalistir komutu kabukta çalıştırır; olay bilgileri ALARM_* ortam değişkenleriyle verilir
+func alarmKomutuCalistir(komut string, olay AlarmOlayi) error {
+	ctx, cancel := context.WithTimeout(context.Background(), alarmKancaZamanAsimi)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", komut)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", komut)
+	}
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("ALARM_ID=%d", olay.AlarmID),
+		"ALARM_AD="+olay.Ad,
+		"ALARM_TIP="+olay.Tip,
+		"ALARM_KOD="+olay.Kod,
+		"ALARM_ESIK="+olay.Esik.String(),
+		"ALARM_DEGER="+olay.Deger.String(),
+		"ALARM_MESAJ="+olay.Mesaj,
+	)
+
+	cikti, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s içinde tamamlanmadı", alarmKancaZamanAsimi)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(cikti)))
+	}
+	logger.Debugf("Alarm komutu çıktısı: %s", strings.TrimSpace(string(cikti)))
+	return nil
+}
+
+// webhookGonder olayı JSON olarak adrese POST eder; 2xx dışındaki yanıtlar hatadır
+func (s *AlarmService) webhookGonder(adres string, olay AlarmOlayi) error {
+	govde, err := json.Marshal(olay)
+	if err != nil {
+		return fmt.Errorf("olay JSON'a çevrilemedi: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", adres, bytes.NewReader(govde))
+	if err != nil {
+		return fmt.Errorf("istek oluşturulamadı: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "altintakip")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// alarmOlcumu bir değerlendirme turunda fiyatları ve portföy toplamlarını bir kez okur
+type alarmOlcumu struct {
+	sonFiyatlar map[string]models.FiyatGecmisi
+	karlar      map[uint]decimal.Decimal
+}
+
+// newAlarmOlcumu boş ölçüm önbelleği oluşturur
+func newAlarmOlcumu() *alarmOlcumu {
+	return &alarmOlcumu{karlar: map[uint]decimal.Decimal{}}
+}
+
+// olc alarmın karşılaştırılacağı güncel değeri döner (TL veya günlük değişim yüzdesi)
+func (o *alarmOlcumu) olc(alarm *models.Alarm) (decimal.Decimal, error) {
+	switch alarm.Tip {
+	case models.AlarmKarUstu, models.AlarmKarAlti:
+		return o.kar(alarm.PortfoyID)
+	case models.AlarmFiyatUstu, models.AlarmFiyatAlti, models.AlarmDegisim:
+	default:
+		return decimal.Zero, fmt.Errorf("geçersiz alarm tipi: %s", alarm.Tip)
+	}
+
+	if o.sonFiyatlar == nil {
+		sonFiyatlar, err := NewFiyatGecmisiService().GetSonFiyatlar()
+		if err != nil {
+			return decimal.Zero, err
+		}
+		o.sonFiyatlar = sonFiyatlar
+	}
+	son, exists := o.sonFiyatlar[alarm.Kod]
+	if !exists {
+		return decimal.Zero, fmt.Errorf("%s için kaydedilmiş fiyat yok", alarm.Kod)
+	}
+
+	fiyat := decimal.NewFromFloat(alarmFiyatAlani(son, alarm.Alan))
+	if alarm.Tip != models.AlarmDegisim {
+		return fiyat, nil
+	}
+
+	// Günlük değişim: bugün çekilmiş fiyatta API'nin bildirdiği değişim, yoksa dünkü son fiyata göre
+	bugun := time.Now()
+	gunBasi := time.Date(bugun.Year(), bugun.Month(), bugun.Day(), 0, 0, 0, 0, bugun.Location())
+	if son.Degisim != nil && !son.CekilmeZamani.Before(gunBasi) {
+		return decimal.NewFromFloat(*son.Degisim), nil
+	}
+	referans, err := NewFiyatGecmisiService().GetFiyatAt(alarm.Kod, gunBasi)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if referans == nil || alarmFiyatAlani(*referans, alarm.Alan) <= 0 {
+		return decimal.Zero, fmt.Errorf("%s için dünden fiyat yok", alarm.Kod)
+	}
+	dunku := decimal.NewFromFloat(alarmFiyatAlani(*referans, alarm.Alan))
+	return models.YuzdeHesapla(fiyat.Sub(dunku), dunku), nil
+}
+
+// kar portföyün (0: tüm portföyler) güncel gerçekleşmemiş kar/zararını döner
+func (o *alarmOlcumu) kar(portfoyID uint) (decimal.Decimal, error) {
+	if kar, exists := o.karlar[portfoyID]; exists {
+		return kar, nil
+	}
+
+	envanterService := NewEnvanterService()
+	envanterService.SetPortfoy(portfoyID)
+	toplamlar, err := envanterService.GetToplamDegerler()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	o.karlar[portfoyID] = toplamlar["toplam_kar"]
+	return toplamlar["toplam_kar"], nil
+}
+
+// alarmFiyatAlani fiyat kaydından alarmın karşılaştırdığı alanı döner
+func alarmFiyatAlani(fiyat models.FiyatGecmisi, alan string) float64 {
+	if alan == models.AlarmAlanSatis {
+		return fiyat.Satis
+	}
+	return fiyat.Alis
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"altintakip/internal/database"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAlarmDurumlariGuncelleVeBildir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("komut sh ile çalıştırılıyor")
+	}
+	testVeritabani(t)
+	s := NewAlarmService()
+
+	isaret := filepath.Join(t.TempDir(), "tetiklendi")
+	alarm := &models.Alarm{
+		Tip:   models.AlarmKarAlti,
+		Esik:  decimal.NewFromInt(1),
+		Komut: "touch " + isaret,
+	}
+	if err := s.AddAlarm(alarm); err != nil {
+		t.Fatalf("AddAlarm: %v", err)
+	}
+
+	// Durum kaydedilir ama komut çalıştırılmaz
+	olaylar, err := s.DurumlariGuncelle()
+	if err != nil {
+		t.Fatalf("DurumlariGuncelle: %v", err)
+	}
+	if len(olaylar) != 1 || olaylar[0].AlarmID != alarm.ID {
+		t.Fatalf("olaylar beklenmedik: %+v", olaylar)
+	}
+	if _, err := os.Stat(isaret); !os.IsNotExist(err) {
+		t.Error("komut DurumlariGuncelle içinde çalışmamalı")
+	}
+	var kayitli models.Alarm
+	if err := database.GetDB().First(&kayitli, alarm.ID).Error; err != nil || !kayitli.Tetiklendi {
+		t.Errorf("alarm tetiklenmiş olarak kaydedilmedi: %v", err)
+	}
+
+	// Bildir komutu çalıştırır
+	s.Bildir(olaylar)
+	if len(olaylar[0].Hatalar) != 0 {
+		t.Errorf("bildirim hataları: %v", olaylar[0].Hatalar)
+	}
+	if _, err := os.Stat(isaret); err != nil {
+		t.Errorf("komut çalışmadı: %v", err)
+	}
+
+	// Tetiklenmiş alarm koşul sürdükçe yeniden olay üretmez
+	if olaylar, err = s.DurumlariGuncelle(); err != nil || len(olaylar) != 0 {
+		t.Errorf("ikinci değerlendirme = %v, %v", olaylar, err)
+	}
+}
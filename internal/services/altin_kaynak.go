@@ -4,16 +4,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/metrik"
 )
 
 // AltinKaynakService altın kaynak API servisi
 type AltinKaynakService struct {
-	baseURL string
-	client  *http.Client
+	ad          string // Sağlayıcı adı (fiyat geçmişindeki kaynak ve metrik etiketi)
+	baseURL     string
+	client      *http.Client
+	kayitDizini string // Boş değilse başarılı yanıtlar bu dizine fixture olarak yazılır
+
+	// Geçici hatalarda (bağlantı, HTTP 5xx/429) endpoint başına yeniden deneme sayısı ve bekleme
+	yenidenDeneme int
+	bekleme       func(time.Duration)
+
+	onbellekYolu string // Boş değilse son başarılı fiyatlar bu dosyada saklanır (eksik gruplar buradan tamamlanır)
 }
 
 // RestPriceItem REST API'den gelen tek bir ürün fiyat bilgisi
@@ -33,81 +49,333 @@ type RestPriceItem struct {
 	WidgetAciklama    string   `json:"WidgetAciklama"`
 }
 
+// guncellenmeZamaniFormati API'nin GuncellenmeZamani alanının biçimi (İstanbul yerel saati)
+const guncellenmeZamaniFormati = "02.01.2006 15:04:05"
+
+// Zaman API'nin bildirdiği son fiyat zamanını çözer
+func (i *RestPriceItem) Zaman() (time.Time, error) {
+	return time.ParseInLocation(guncellenmeZamaniFormati, strings.TrimSpace(i.GuncellenmeZamani), time.Local)
+}
+
+// acikDurumlar ürünün işlemde olduğunu bildiren Durum değerleri (küçük harf); boş Durum da açık sayılır
+var acikDurumlar = map[string]bool{"açık": true, "acik": true, "aktif": true, "normal": true, "open": true, "active": true}
+
+// DurumUyarisi ürün kuyumcu tarafından kapatılmış veya askıya alınmışsa (Durum boş veya açık değilse)
+// API'nin bildirdiği durum metnini, aksi halde boş metin döner
+func (i *RestPriceItem) DurumUyarisi() string {
+	if i.Durum == nil {
+		return ""
+	}
+	durum := strings.TrimSpace(*i.Durum)
+	if durum == "" || acikDurumlar[strings.ToLower(durum)] {
+		return ""
+	}
+	return durum
+}
+
 // AltinFiyatlari REST API'den gelen fiyat bilgilerini tutar
 type AltinFiyatlari struct {
 	// REST API'den gelen tüm veriler
 	GoldItems        []RestPriceItem `json:"gold_items"`
 	CurrencyItems    []RestPriceItem `json:"currency_items"`
 	GuncellemeTarihi time.Time
+
+	// Grupların API'den son başarıyla çekildiği zamanlar; önbellekten tamamlanan grupta eski zaman kalır
+	AltinZamani time.Time `json:"altin_zamani"`
+	DovizZamani time.Time `json:"doviz_zamani"`
+
+	// Bu çekimde alınamayan gruplar (FiyatGrubuAltin, FiyatGrubuDoviz); önbellekte varsa oradan tamamlanır
+	Eksikler []string `json:"eksikler,omitempty"`
+}
+
+// Fiyat grupları (AltinFiyatlari.Eksikler)
+const (
+	FiyatGrubuAltin = "altın"
+	FiyatGrubuDoviz = "döviz"
+)
+
+// EnEskiZaman görüntüdeki en eski grubun çekilme zamanını döner; gösterilen fiyatların ne kadar bayat olduğunu belirler
+func (f *AltinFiyatlari) EnEskiZaman() time.Time {
+	enEski := f.GuncellemeTarihi
+	for _, zaman := range []time.Time{f.AltinZamani, f.DovizZamani} {
+		if !zaman.IsZero() && zaman.Before(enEski) {
+			enEski = zaman
+		}
+	}
+	return enEski
+}
+
+// Kalemler görüntüdeki ürünleri büyük harfli koda göre eşler (tablolarda satır başına arama için)
+func (f *AltinFiyatlari) Kalemler() map[string]*RestPriceItem {
+	kalemler := make(map[string]*RestPriceItem, len(f.GoldItems)+len(f.CurrencyItems))
+	for _, items := range [][]RestPriceItem{f.GoldItems, f.CurrencyItems} {
+		for i := range items {
+			kod := strings.ToUpper(items[i].Kod)
+			if _, exists := kalemler[kod]; !exists {
+				kalemler[kod] = &items[i]
+			}
+		}
+	}
+	return kalemler
 }
 
-// NewAltinKaynakService yeni servis instance'ı oluşturur
+// Taze yalnızca bu çekimde API'den gelen grupları içeren kopyayı döner (fiyat geçmişine yalnızca bunlar yazılır)
+func (f *AltinFiyatlari) Taze() *AltinFiyatlari {
+	taze := *f
+	for _, eksik := range f.Eksikler {
+		switch eksik {
+		case FiyatGrubuAltin:
+			taze.GoldItems = nil
+		case FiyatGrubuDoviz:
+			taze.CurrencyItems = nil
+		}
+	}
+	return &taze
+}
+
+// varsayilanAPIEndpoint API_ENDPOINT boş bırakıldığında kullanılan adres
+const varsayilanAPIEndpoint = "https://rest.altinkaynak.com"
+
+// İstek zaman aşımı ve yeniden deneme varsayılanları (API_TIMEOUT, API_RETRIES)
+const (
+	varsayilanIstekZamanAsimi = 10 * time.Second
+	varsayilanYenidenDeneme   = 2
+	yenidenDenemeTabani       = 500 * time.Millisecond
+	yenidenDenemeUstSiniri    = 8 * time.Second
+)
+
+// NewAltinKaynakService yeni servis instance'ı oluşturur (API_ENDPOINT, API_TIMEOUT, API_RETRIES ve
+// API_RECORD_DIR ayarlarını kullanır); son başarılı fiyatlar veri dizininde önbelleğe alınır
 func NewAltinKaynakService() *AltinKaynakService {
+	baseURL := varsayilanAPIEndpoint
+	if endpoint := strings.TrimSpace(os.Getenv("API_ENDPOINT")); endpoint != "" {
+		baseURL = endpoint
+	}
+	s := NewAltinKaynakServiceWithURL(baseURL)
+	s.SetKayitDizini(strings.TrimSpace(os.Getenv("API_RECORD_DIR")))
+
+	if deger := strings.TrimSpace(os.Getenv("API_TIMEOUT")); deger != "" {
+		if sure, err := time.ParseDuration(deger); err == nil && sure > 0 {
+			s.client.Timeout = sure
+		} else {
+			logger.Warnf("Geçersiz API_TIMEOUT değeri: %s, varsayılan kullanılıyor: %s", deger, varsayilanIstekZamanAsimi)
+		}
+	}
+	if deger := strings.TrimSpace(os.Getenv("API_RETRIES")); deger != "" {
+		if sayi, err := strconv.Atoi(deger); err == nil && sayi >= 0 {
+			s.yenidenDeneme = sayi
+		} else {
+			logger.Warnf("Geçersiz API_RETRIES değeri: %s, varsayılan kullanılıyor: %d", deger, varsayilanYenidenDeneme)
+		}
+	}
+
+	if dizin, err := database.VeriDizini(); err == nil {
+		s.SetOnbellekYolu(filepath.Join(dizin, fiyatOnbellegiDosyasi))
+	}
+	return s
+}
+
+// NewAltinKaynakServiceWithURL belirtilen adrese istek atan servis oluşturur (yerel ayna, test sunucusu vb.).
+// Önbellek kullanılmaz; gerekirse SetOnbellekYolu ile açılır.
+func NewAltinKaynakServiceWithURL(baseURL string) *AltinKaynakService {
 	return &AltinKaynakService{
-		baseURL: "https://rest.altinkaynak.com",
+		ad:      "altinkaynak",
+		baseURL: strings.TrimRight(baseURL, "/"),
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: varsayilanIstekZamanAsimi,
 		},
+		yenidenDeneme: varsayilanYenidenDeneme,
+		bekleme:       time.Sleep,
 	}
 }
 
-// GetFiyatlar altın ve döviz fiyatlarını REST API'den çeker
+// Name sağlayıcı adını döner
+func (s *AltinKaynakService) Name() string {
+	return s.ad
+}
+
+// SetKayitDizini başarılı API yanıtlarının ham halinin yazılacağı dizini ayarlar (boş: kayıt yapılmaz).
+// Yazılan Gold.json/Currency.json dosyaları fixture sağlayıcısıyla tekrar oynatılabilir.
+func (s *AltinKaynakService) SetKayitDizini(dizin string) {
+	s.kayitDizini = dizin
+}
+
+// SetOnbellekYolu son başarılı fiyatların saklanacağı dosyayı ayarlar (boş: önbellek kullanılmaz)
+func (s *AltinKaynakService) SetOnbellekYolu(yol string) {
+	s.onbellekYolu = yol
+}
+
+// GetFiyatlar altın ve döviz fiyatlarını REST API'den çeker. Gruplardan biri alınamazsa diğeri yine
+// döner; alınamayan grup Eksikler'e yazılır ve önbellekte varsa son başarılı fiyatlarla tamamlanır.
+// İki grup da alınamazsa önbellekteki fiyatlar iki grup da eksik olarak döner; önbellek yoksa hata döner.
 func (s *AltinKaynakService) GetFiyatlar() (*AltinFiyatlari, error) {
+	simdi := time.Now()
 	fiyatlar := &AltinFiyatlari{
-		GuncellemeTarihi: time.Now(),
+		GuncellemeTarihi: simdi,
 	}
 
-	// Altın fiyatlarını çek
-	goldItems, err := s.getRestData("/Gold.json")
-	if err != nil {
-		return nil, fmt.Errorf("altın fiyatları çekme hatası: %w", err)
+	goldItems, altinHatasi := s.getRestData("/Gold.json")
+	currencyItems, dovizHatasi := s.getRestData("/Currency.json")
+	if altinHatasi != nil && dovizHatasi != nil {
+		hata := fmt.Errorf("altın fiyatları çekme hatası: %w; döviz fiyatları çekme hatası: %w", altinHatasi, dovizHatasi)
+		onbellek, err := s.kayitliFiyatlar()
+		if err != nil {
+			logger.Warnf("%v", err)
+		}
+		if onbellek == nil {
+			return nil, hata
+		}
+		// Önbellek değişmediğinden yeniden yazılmaz; grupların zamanları son başarılı çekimi gösterir
+		fiyatlar.GoldItems, fiyatlar.AltinZamani = onbellek.GoldItems, onbellek.AltinZamani
+		fiyatlar.CurrencyItems, fiyatlar.DovizZamani = onbellek.CurrencyItems, onbellek.DovizZamani
+		fiyatlar.Eksikler = []string{FiyatGrubuAltin, FiyatGrubuDoviz}
+		logger.Warnf("Fiyatlar alınamadı, son başarılı fiyatlar kullanılıyor: %v", hata)
+		return fiyatlar, nil
 	}
-	fiyatlar.GoldItems = goldItems
 
-	// Döviz fiyatlarını çek
-	currencyItems, err := s.getRestData("/Currency.json")
-	if err != nil {
-		return nil, fmt.Errorf("döviz fiyatları çekme hatası: %w", err)
+	var onbellek *AltinFiyatlari
+	if (altinHatasi != nil || dovizHatasi != nil) && s.onbellekYolu != "" {
+		var err error
+		if onbellek, err = fiyatOnbelleginiOku(s.onbellekYolu); err != nil {
+			logger.Warnf("Fiyat önbelleği okunamadı: %v", err)
+		}
+	}
+
+	if altinHatasi != nil {
+		fiyatlar.Eksikler = append(fiyatlar.Eksikler, FiyatGrubuAltin)
+		if onbellek != nil {
+			fiyatlar.GoldItems, fiyatlar.AltinZamani = onbellek.GoldItems, onbellek.AltinZamani
+		}
+		logger.Warnf("Altın fiyatları alınamadı, döviz fiyatları güncelleniyor: %v", altinHatasi)
+	} else {
+		fiyatlar.GoldItems, fiyatlar.AltinZamani = goldItems, simdi
+	}
+	if dovizHatasi != nil {
+		fiyatlar.Eksikler = append(fiyatlar.Eksikler, FiyatGrubuDoviz)
+		if onbellek != nil {
+			fiyatlar.CurrencyItems, fiyatlar.DovizZamani = onbellek.CurrencyItems, onbellek.DovizZamani
+		}
+		logger.Warnf("Döviz fiyatları alınamadı, altın fiyatları güncelleniyor: %v", dovizHatasi)
+	} else {
+		fiyatlar.CurrencyItems, fiyatlar.DovizZamani = currencyItems, simdi
 	}
-	fiyatlar.CurrencyItems = currencyItems
 
+	if s.onbellekYolu != "" {
+		if err := fiyatOnbellegineYaz(s.onbellekYolu, fiyatlar); err != nil {
+			logger.Warnf("Fiyat önbelleği yazılamadı: %v", err)
+		}
+	}
 	return fiyatlar, nil
 }
 
-// getRestData REST API'den JSON veri çeker
+// Fiyat API'si istek metrikleri (serve komutunun /metrics çıktısında yer alır)
+var (
+	fiyatIstekSuresi = metrik.NewHistogram("altintakip_fiyat_istek_sure_seconds",
+		"Fiyat API isteklerinin yanıt okunana kadar geçen süresi",
+		[]float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, "saglayici", "endpoint")
+	fiyatIstekleri = metrik.NewSayac("altintakip_fiyat_istekleri_total",
+		"Fiyat API istekleri; durum HTTP durum kodu veya bağlantı hatasında \"hata\"", "saglayici", "endpoint", "durum")
+	fiyatParseHatalari = metrik.NewSayac("altintakip_fiyat_parse_hatalari_total",
+		"Çözülemeyen fiyat yanıtları (asama=json) ve sayıya çevrilemeyen fiyat alanları (asama=fiyat)", "saglayici", "endpoint", "asama")
+	fiyatYenidenDenemeleri = metrik.NewSayac("altintakip_fiyat_yeniden_denemeleri_total",
+		"Geçici hatadan (bağlantı, HTTP 5xx/429) sonra yapılan yeniden denemeler", "saglayici", "endpoint")
+)
+
+// getRestData endpoint'i geçici hatalarda üstel artan ve rastgele saptırılan beklemelerle yeniden dener
 func (s *AltinKaynakService) getRestData(endpoint string) ([]RestPriceItem, error) {
+	for deneme := 0; ; deneme++ {
+		items, gecici, err := s.istekYap(endpoint)
+		if err == nil || !gecici || deneme >= s.yenidenDeneme {
+			if err != nil && deneme > 0 {
+				err = fmt.Errorf("%d denemeden sonra: %w", deneme+1, err)
+			}
+			return items, err
+		}
+
+		bekleme := yenidenDenemeBeklemesi(deneme)
+		logger.Debugf("%s isteği başarısız (%v), %s sonra yeniden denenecek", endpoint, err, bekleme.Round(time.Millisecond))
+		fiyatYenidenDenemeleri.Artir(s.Name(), endpoint)
+		s.bekleme(bekleme)
+	}
+}
+
+// yenidenDenemeBeklemesi deneme sırasına göre üstel artan (500ms, 1s, 2s, ... en fazla 8s) sürenin
+// yarısıyla tamamı arasında rastgele bir bekleme döner; eşzamanlı istemciler aynı anda yeniden denemez
+func yenidenDenemeBeklemesi(deneme int) time.Duration {
+	ust := yenidenDenemeUstSiniri
+	if deneme < 5 {
+		ust = min(yenidenDenemeTabani<<deneme, yenidenDenemeUstSiniri)
+	}
+	return ust/2 + rand.N(ust/2+1)
+}
+
+// istekYap REST API'den JSON veri çeker; süre, durum kodu ve parse hatalarını metriklere yazar.
+// Bağlantı hataları ve HTTP 5xx/429 yanıtları geçici kabul edilir (gecici=true).
+func (s *AltinKaynakService) istekYap(endpoint string) (items []RestPriceItem, gecici bool, err error) {
 	url := s.baseURL + endpoint
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request oluşturma hatası: %w", err)
+		return nil, false, fmt.Errorf("HTTP request oluşturma hatası: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "AltinTakip/1.0")
 
+	baslangic := time.Now()
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("API çağrısı başarısız: %w", err)
+		fiyatIstekSuresi.Gozlemle(time.Since(baslangic).Seconds(), s.Name(), endpoint)
+		fiyatIstekleri.Artir(s.Name(), endpoint, "hata")
+		return nil, true, fmt.Errorf("API çağrısı başarısız: %w", err)
 	}
 	defer resp.Body.Close()
 
+	body, err := io.ReadAll(resp.Body)
+	fiyatIstekSuresi.Gozlemle(time.Since(baslangic).Seconds(), s.Name(), endpoint)
+	fiyatIstekleri.Artir(s.Name(), endpoint, strconv.Itoa(resp.StatusCode))
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API hatası: HTTP %d", resp.StatusCode)
+		gecici := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, gecici, fmt.Errorf("API hatası: HTTP %d", resp.StatusCode)
 	}
-
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("response okuma hatası: %w", err)
+		// Yanıt yarıda kesildi
+		return nil, true, fmt.Errorf("response okuma hatası: %w", err)
 	}
 
-	var items []RestPriceItem
 	err = json.Unmarshal(body, &items)
 	if err != nil {
-		return nil, fmt.Errorf("JSON parse hatası: %w", err)
+		fiyatParseHatalari.Artir(s.Name(), endpoint, "json")
+		return nil, false, fmt.Errorf("JSON parse hatası: %w", err)
+	}
+
+	if s.kayitDizini != "" {
+		s.yanitiKaydet(endpoint, body)
+	}
+
+	// parseFloat çevrilemeyen fiyatları sessizce 0 kabul ettiğinden bunlar burada sayılır
+	for _, item := range items {
+		if !fiyatMetniGecerli(item.Alis) || !fiyatMetniGecerli(item.Satis) {
+			fiyatParseHatalari.Artir(s.Name(), endpoint, "fiyat")
+		}
 	}
 
-	return items, nil
+	return items, false, nil
+}
+
+// yanitiKaydet ham yanıtı kayıt dizinine endpoint'in dosya adıyla yazar; hata fiyat çekmeyi engellemez
+func (s *AltinKaynakService) yanitiKaydet(endpoint string, body []byte) {
+	if err := os.MkdirAll(s.kayitDizini, 0755); err != nil {
+		logger.Warnf("Fixture dizini oluşturulamadı: %v", err)
+		return
+	}
+	yol := filepath.Join(s.kayitDizini, path.Base(endpoint))
+	if err := os.WriteFile(yol, body, 0644); err != nil {
+		logger.Warnf("API yanıtı kaydedilemedi: %v", err)
+		return
+	}
+	logger.Debugf("API yanıtı kaydedildi: %s", yol)
 }
 
 // GetFiyatByType belirli bir ürün kodu için fiyat döner
@@ -131,6 +399,15 @@ func (s *AltinKaynakService) GetFiyatByType(fiyatlar *AltinFiyatlari, kod string
 	return 0, fmt.Errorf("bilinmeyen ürün kodu: %s", kod)
 }
 
+// GetAlisSatis belirli bir ürün kodu için alış ve satış fiyatını döner
+func (s *AltinKaynakService) GetAlisSatis(fiyatlar *AltinFiyatlari, kod string) (float64, float64, error) {
+	item, err := s.GetItemByCode(fiyatlar, kod)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bilinmeyen ürün kodu: %s", strings.ToUpper(strings.TrimSpace(kod)))
+	}
+	return parseFloat(item.Alis), parseFloat(item.Satis), nil
+}
+
 // GetAllItems tüm mevcut ürünleri MobilAciklama ile birlikte döner
 func (s *AltinKaynakService) GetAllItems(fiyatlar *AltinFiyatlari) []RestPriceItem {
 	var allItems []RestPriceItem
@@ -160,6 +437,15 @@ func (s *AltinKaynakService) GetItemByCode(fiyatlar *AltinFiyatlari, kod string)
 	return nil, fmt.Errorf("ürün kodu bulunamadı: %s", kod)
 }
 
+// fiyatMetniGecerli fiyat metninin parseFloat ile sayıya çevrilebildiğini kontrol eder
+func fiyatMetniGecerli(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", "."), 64)
+	return err == nil
+}
+
 // parseFloat string'i float64'e çevirir
 func parseFloat(s string) float64 {
 	// API'den gelen veriler zaten İngilizce formatında (4342.4900)
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureDizini testdata altındaki kayıtlı API yanıtları
+const fixtureDizini = "testdata"
+
+// testSunucusu testdata dizinini API gibi sunan httptest sunucusu başlatır
+func testSunucusu(t *testing.T) *httptest.Server {
+	t.Helper()
+	sunucu := httptest.NewServer(http.FileServer(http.Dir(fixtureDizini)))
+	t.Cleanup(sunucu.Close)
+	return sunucu
+}
+
+func TestParseFloat(t *testing.T) {
+	testler := []struct {
+		girdi string
+		want  float64
+	}{
+		{"4342.4900", 4342.49},
+		{"41.6520", 41.652},
+		{"7020", 7020},
+		{"0", 0},
+		{"2.950,25", 2950.25},
+		{"1.234.567,8", 1234567.8},
+		{"12,5", 12.5},
+		{"", 0},
+		{"-", 0},
+		{"abc", 0},
+	}
+
+	for _, tt := range testler {
+		if got := parseFloat(tt.girdi); got != tt.want {
+			t.Errorf("parseFloat(%q) = %v, beklenen %v", tt.girdi, got, tt.want)
+		}
+		if gecerli := fiyatMetniGecerli(tt.girdi); gecerli != (tt.want != 0 || tt.girdi == "0") {
+			t.Errorf("fiyatMetniGecerli(%q) = %v", tt.girdi, gecerli)
+		}
+	}
+}
+
+func TestGetFiyatByType(t *testing.T) {
+	saglayicilar := map[string]*AltinKaynakService{
+		"fixture":  NewFixtureSaglayici(fixtureDizini),
+		"httptest": NewAltinKaynakServiceWithURL(testSunucusu(t).URL),
+	}
+
+	for ad, s := range saglayicilar {
+		t.Run(ad, func(t *testing.T) {
+			fiyatlar, err := s.GetFiyatlar()
+			if err != nil {
+				t.Fatalf("GetFiyatlar: %v", err)
+			}
+			if len(fiyatlar.GoldItems) != 4 || len(fiyatlar.CurrencyItems) != 2 {
+				t.Fatalf("beklenmeyen ürün sayısı: %d altın, %d döviz", len(fiyatlar.GoldItems), len(fiyatlar.CurrencyItems))
+			}
+
+			testler := []struct {
+				kod  string
+				want float64
+			}{
+				{"HH", 4342.49},
+				{"c", 7020},
+				{" usd ", 41.652},
+				{"EUR", 48.521},
+			}
+			for _, tt := range testler {
+				got, err := s.GetFiyatByType(fiyatlar, tt.kod)
+				if err != nil {
+					t.Errorf("GetFiyatByType(%q): %v", tt.kod, err)
+					continue
+				}
+				if got != tt.want {
+					t.Errorf("GetFiyatByType(%q) = %v, beklenen %v", tt.kod, got, tt.want)
+				}
+			}
+
+			if _, err := s.GetFiyatByType(fiyatlar, "XAU"); err == nil {
+				t.Error("bilinmeyen kod için hata bekleniyordu")
+			}
+
+			alis, satis, err := s.GetAlisSatis(fiyatlar, "GA")
+			if err != nil || alis != 4315 || satis != 4402 {
+				t.Errorf("GetAlisSatis(GA) = %v, %v, %v", alis, satis, err)
+			}
+		})
+	}
+}
+
+func TestGetFiyatlarHatalari(t *testing.T) {
+	testler := []struct {
+		ad      string
+		handler http.HandlerFunc
+	}{
+		{"http hatası", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+		{"bozuk json", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"Kod": "C"`))
+		}},
+	}
+
+	for _, tt := range testler {
+		t.Run(tt.ad, func(t *testing.T) {
+			sunucu := httptest.NewServer(tt.handler)
+			defer sunucu.Close()
+
+			s := NewAltinKaynakServiceWithURL(sunucu.URL)
+			s.bekleme = func(time.Duration) {}
+			if _, err := s.GetFiyatlar(); err == nil {
+				t.Error("hata bekleniyordu")
+			}
+		})
+	}
+
+	if _, err := NewFixtureSaglayici(t.TempDir()).GetFiyatlar(); err == nil {
+		t.Error("boş fixture dizini için hata bekleniyordu")
+	}
+	if _, err := NewFixtureSaglayici("").GetFiyatlar(); err == nil {
+		t.Error("tanımsız fixture dizini için hata bekleniyordu")
+	}
+}
+
+func TestYanitKaydiVeTekrarOynatma(t *testing.T) {
+	kayitDizini := filepath.Join(t.TempDir(), "kayit")
+
+	canli := NewAltinKaynakServiceWithURL(testSunucusu(t).URL)
+	canli.SetKayitDizini(kayitDizini)
+	beklenen, err := canli.GetFiyatlar()
+	if err != nil {
+		t.Fatalf("GetFiyatlar: %v", err)
+	}
+
+	for _, dosya := range []string{"Gold.json", "Currency.json"} {
+		kaydedilen, err := os.ReadFile(filepath.Join(kayitDizini, dosya))
+		if err != nil {
+			t.Fatalf("%s kaydedilmedi: %v", dosya, err)
+		}
+		orijinal, _ := os.ReadFile(filepath.Join(fixtureDizini, dosya))
+		if string(kaydedilen) != string(orijinal) {
+			t.Errorf("%s ham yanıtla aynı kaydedilmedi", dosya)
+		}
+	}
+
+	tekrar := NewFixtureSaglayici(kayitDizini)
+	if tekrar.Name() != "fixture" {
+		t.Errorf("Name() = %q", tekrar.Name())
+	}
+	got, err := tekrar.GetFiyatlar()
+	if err != nil {
+		t.Fatalf("kayıttan GetFiyatlar: %v", err)
+	}
+	if len(got.GoldItems) != len(beklenen.GoldItems) || len(got.CurrencyItems) != len(beklenen.CurrencyItems) {
+		t.Fatalf("kayıttan okunan ürünler farklı")
+	}
+	for i := range got.GoldItems {
+		if got.GoldItems[i].Kod != beklenen.GoldItems[i].Kod || got.GoldItems[i].Alis != beklenen.GoldItems[i].Alis {
+			t.Errorf("altın %d farklı: %+v, beklenen %+v", i, got.GoldItems[i], beklenen.GoldItems[i])
+		}
+	}
+}
+
+func TestNewPriceProvider(t *testing.T) {
+	t.Setenv("FIXTURE_DIR", fixtureDizini)
+
+	saglayici, err := NewPriceProvider(" Fixture ")
+	if err != nil {
+		t.Fatalf("NewPriceProvider: %v", err)
+	}
+	if _, err := saglayici.GetFiyatlar(); err != nil {
+		t.Errorf("FIXTURE_DIR ile GetFiyatlar: %v", err)
+	}
+
+	if saglayici, err := NewPriceProvider(""); err != nil || saglayici.Name() != "altinkaynak" {
+		t.Errorf("varsayılan sağlayıcı altinkaynak olmalı: %v", err)
+	}
+	if _, err := NewPriceProvider("tcmb"); err == nil {
+		t.Error("bilinmeyen sağlayıcı için hata bekleniyordu")
+	}
+}
+
+// arizaliSunucu testdata'yı sunar; endpoint başına verilen sayıda istek önce verilen durum koduyla yanıtlanır
+// (-1: her zaman). İstek sayıları endpoint yoluna göre döner.
+func arizaliSunucu(t *testing.T, arizalar map[string]int, durum int) (*httptest.Server, func(string) int) {
+	t.Helper()
+	var mu sync.Mutex
+	istekler := map[string]int{}
+	dosyalar := http.FileServer(http.Dir(fixtureDizini))
+
+	sunucu := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		istekler[r.URL.Path]++
+		sira := istekler[r.URL.Path]
+		mu.Unlock()
+		if ariza, exists := arizalar[r.URL.Path]; exists && (ariza < 0 || sira <= ariza) {
+			w.WriteHeader(durum)
+			return
+		}
+		dosyalar.ServeHTTP(w, r)
+	}))
+	t.Cleanup(sunucu.Close)
+	return sunucu, func(yol string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return istekler[yol]
+	}
+}
+
+func TestYenidenDeneme(t *testing.T) {
+	testler := []struct {
+		ad          string
+		durum       int
+		ariza       int
+		wantIstek   int
+		wantBasarim bool
+	}{
+		{"geçici hata sonrası başarı", http.StatusServiceUnavailable, 2, 3, true},
+		{"çok fazla istek", http.StatusTooManyRequests, 1, 2, true},
+		{"denemeler tükenir", http.StatusBadGateway, -1, 3, false},
+		{"kalıcı hata denenmez", http.StatusNotFound, -1, 1, false},
+	}
+
+	for _, tt := range testler {
+		t.Run(tt.ad, func(t *testing.T) {
+			sunucu, istekSayisi := arizaliSunucu(t, map[string]int{"/Gold.json": tt.ariza}, tt.durum)
+			s := NewAltinKaynakServiceWithURL(sunucu.URL)
+			var beklemeler []time.Duration
+			s.bekleme = func(d time.Duration) { beklemeler = append(beklemeler, d) }
+
+			items, err := s.getRestData("/Gold.json")
+			if (err == nil) != tt.wantBasarim {
+				t.Fatalf("getRestData hata = %v, başarı bekleniyor: %v", err, tt.wantBasarim)
+			}
+			if tt.wantBasarim && len(items) == 0 {
+				t.Error("ürün listesi boş")
+			}
+			if got := istekSayisi("/Gold.json"); got != tt.wantIstek {
+				t.Errorf("%d istek, beklenen %d", got, tt.wantIstek)
+			}
+			if len(beklemeler) != tt.wantIstek-1 {
+				t.Errorf("%d bekleme, beklenen %d", len(beklemeler), tt.wantIstek-1)
+			}
+		})
+	}
+}
+
+func TestYenidenDenemeBeklemesi(t *testing.T) {
+	for deneme := 0; deneme < 10; deneme++ {
+		ust := yenidenDenemeUstSiniri
+		if deneme < 5 {
+			ust = min(yenidenDenemeTabani<<deneme, yenidenDenemeUstSiniri)
+		}
+		for i := 0; i < 20; i++ {
+			if d := yenidenDenemeBeklemesi(deneme); d < ust/2 || d > ust {
+				t.Fatalf("deneme %d: bekleme %s, beklenen [%s, %s]", deneme, d, ust/2, ust)
+			}
+		}
+	}
+}
+
+func TestKismiFiyatlarVeOnbellek(t *testing.T) {
+	onbellekYolu := filepath.Join(t.TempDir(), fiyatOnbellegiDosyasi)
+	yeniServis := func(sunucu *httptest.Server) *AltinKaynakService {
+		s := NewAltinKaynakServiceWithURL(sunucu.URL)
+		s.SetOnbellekYolu(onbellekYolu)
+		s.bekleme = func(time.Duration) {}
+		return s
+	}
+
+	// Önbellek yokken döviz alınamazsa altın yine döner, döviz boş kalır
+	sunucu, _ := arizaliSunucu(t, map[string]int{"/Currency.json": -1}, http.StatusInternalServerError)
+	fiyatlar, err := yeniServis(sunucu).GetFiyatlar()
+	if err != nil {
+		t.Fatalf("kısmi sonuç bekleniyordu: %v", err)
+	}
+	if len(fiyatlar.GoldItems) == 0 || len(fiyatlar.CurrencyItems) != 0 {
+		t.Errorf("altın %d, döviz %d ürün", len(fiyatlar.GoldItems), len(fiyatlar.CurrencyItems))
+	}
+	if len(fiyatlar.Eksikler) != 1 || fiyatlar.Eksikler[0] != FiyatGrubuDoviz {
+		t.Errorf("eksikler = %v", fiyatlar.Eksikler)
+	}
+
+	// Tam başarılı çekim önbelleği doldurur
+	ilk, err := yeniServis(testSunucusu(t)).GetFiyatlar()
+	if err != nil {
+		t.Fatalf("GetFiyatlar: %v", err)
+	}
+	if len(ilk.Eksikler) != 0 || ilk.DovizZamani.IsZero() {
+		t.Fatalf("tam çekim beklenmedik: eksikler %v, döviz zamanı %v", ilk.Eksikler, ilk.DovizZamani)
+	}
+
+	// Döviz alınamayınca önbellekten ve eski zamanıyla tamamlanır; fiyat geçmişine yalnızca altın yazılır
+	time.Sleep(10 * time.Millisecond)
+	fiyatlar, err = yeniServis(sunucu).GetFiyatlar()
+	if err != nil {
+		t.Fatalf("kısmi sonuç bekleniyordu: %v", err)
+	}
+	if len(fiyatlar.CurrencyItems) != len(ilk.CurrencyItems) || !fiyatlar.DovizZamani.Equal(ilk.DovizZamani) {
+		t.Errorf("döviz önbellekten tamamlanmadı: %d ürün, zaman %v", len(fiyatlar.CurrencyItems), fiyatlar.DovizZamani)
+	}
+	if !fiyatlar.AltinZamani.After(ilk.AltinZamani) {
+		t.Error("altın zamanı güncellenmedi")
+	}
+	if !fiyatlar.EnEskiZaman().Equal(ilk.DovizZamani) {
+		t.Errorf("en eski zaman %v, beklenen %v", fiyatlar.EnEskiZaman(), ilk.DovizZamani)
+	}
+	if taze := fiyatlar.Taze(); len(taze.CurrencyItems) != 0 || len(taze.GoldItems) == 0 {
+		t.Error("taze görüntüde yalnızca altın olmalı")
+	}
+
+	// Önbellekteki döviz zamanı kısmi çekimle ilerlemez
+	onbellek, err := fiyatOnbelleginiOku(onbellekYolu)
+	if err != nil || onbellek == nil {
+		t.Fatalf("önbellek okunamadı: %v", err)
+	}
+	if !onbellek.DovizZamani.Equal(ilk.DovizZamani) || len(onbellek.Eksikler) != 0 {
+		t.Errorf("önbellek beklenmedik: döviz zamanı %v, eksikler %v", onbellek.DovizZamani, onbellek.Eksikler)
+	}
+
+	// İki grup da alınamazsa önbellekteki fiyatlar iki grup da eksik ve eski zamanlarıyla döner
+	tamamenArizali, _ := arizaliSunucu(t, map[string]int{"/Gold.json": -1, "/Currency.json": -1}, http.StatusInternalServerError)
+	fiyatlar, err = yeniServis(tamamenArizali).GetFiyatlar()
+	if err != nil {
+		t.Fatalf("önbellekten sonuç bekleniyordu: %v", err)
+	}
+	if len(fiyatlar.Eksikler) != 2 {
+		t.Errorf("iki grup da eksik olmalıydı: %v", fiyatlar.Eksikler)
+	}
+	if len(fiyatlar.GoldItems) != len(onbellek.GoldItems) || len(fiyatlar.CurrencyItems) != len(onbellek.CurrencyItems) {
+		t.Errorf("önbellek fiyatları dönmedi: altın %d, döviz %d ürün", len(fiyatlar.GoldItems), len(fiyatlar.CurrencyItems))
+	}
+	if !fiyatlar.AltinZamani.Equal(onbellek.AltinZamani) || !fiyatlar.DovizZamani.Equal(onbellek.DovizZamani) {
+		t.Errorf("grup zamanları korunmadı: altın %v, döviz %v", fiyatlar.AltinZamani, fiyatlar.DovizZamani)
+	}
+	if taze := fiyatlar.Taze(); len(taze.GoldItems) != 0 || len(taze.CurrencyItems) != 0 {
+		t.Error("taze görüntü boş olmalı")
+	}
+	if sonra, err := fiyatOnbelleginiOku(onbellekYolu); err != nil || !sonra.AltinZamani.Equal(onbellek.AltinZamani) {
+		t.Errorf("önbellek değişmemeliydi: %v", err)
+	}
+
+	// Önbellek de yoksa hata döner
+	onbelleksiz := NewAltinKaynakServiceWithURL(tamamenArizali.URL)
+	onbelleksiz.SetOnbellekYolu(filepath.Join(t.TempDir(), fiyatOnbellegiDosyasi))
+	onbelleksiz.bekleme = func(time.Duration) {}
+	if _, err := onbelleksiz.GetFiyatlar(); err == nil {
+		t.Error("önbellek yokken iki grup da alınamadığında hata bekleniyordu")
+	}
+}
+
+func TestOnbellekliSaglayici(t *testing.T) {
+	sunucu, istekSayisi := arizaliSunucu(t, map[string]int{"/Currency.json": 1}, http.StatusNotFound)
+	altinKaynak := NewAltinKaynakServiceWithURL(sunucu.URL)
+	altinKaynak.SetOnbellekYolu(filepath.Join(t.TempDir(), fiyatOnbellegiDosyasi))
+	simdi := time.Now()
+	s := newOnbellekliSaglayici(altinKaynak, time.Minute)
+	s.simdi = func() time.Time { return simdi }
+
+	// İlk çekimde döviz 404 döner; kısmi görüntü de süresince önbellekte tutulur
+	ilk, err := s.GetFiyatlar()
+	if err != nil {
+		t.Fatalf("GetFiyatlar: %v", err)
+	}
+	if len(ilk.Eksikler) != 1 {
+		t.Fatalf("ilk çekimde döviz eksik olmalıydı: %v", ilk.Eksikler)
+	}
+
+	// Süre dolmadan aynı görüntü döner, API'ye gidilmez
+	ikinci, err := s.GetFiyatlar()
+	if err != nil || ikinci != ilk || istekSayisi("/Gold.json") != 1 {
+		t.Fatalf("önbellek kullanılmadı: %d altın isteği", istekSayisi("/Gold.json"))
+	}
+
+	// Yenile süreye bakmadan yeniden çeker
+	yenilenen, err := s.Yenile()
+	if err != nil || yenilenen == ilk || istekSayisi("/Gold.json") != 2 {
+		t.Fatalf("Yenile yeniden çekmedi: %d altın isteği", istekSayisi("/Gold.json"))
+	}
+
+	// Süre dolunca yeniden çekilir
+	simdi = simdi.Add(time.Minute)
+	if _, err := s.GetFiyatlar(); err != nil || istekSayisi("/Gold.json") != 3 {
+		t.Fatalf("süre dolunca yeniden çekilmedi: %d altın isteği", istekSayisi("/Gold.json"))
+	}
+
+	// Yeni süreç: bellek boşken diskteki süresi dolmamış çekim kullanılır, dolmuşsa API'ye gidilir
+	yeni := newOnbellekliSaglayici(altinKaynak, time.Minute)
+	if _, err := yeni.GetFiyatlar(); err != nil || istekSayisi("/Gold.json") != 3 {
+		t.Fatalf("diskteki çekim kullanılmadı: %d altın isteği", istekSayisi("/Gold.json"))
+	}
+	eski := newOnbellekliSaglayici(altinKaynak, time.Minute)
+	eski.simdi = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := eski.GetFiyatlar(); err != nil || istekSayisi("/Gold.json") != 4 {
+		t.Fatalf("süresi dolmuş disk kaydı kullanıldı: %d altın isteği", istekSayisi("/Gold.json"))
+	}
+
+	// Hatalar önbelleğe alınmaz
+	arizali, arizaliIstekSayisi := arizaliSunucu(t, map[string]int{"/Gold.json": -1, "/Currency.json": -1}, http.StatusNotFound)
+	hatali := newOnbellekliSaglayici(NewAltinKaynakServiceWithURL(arizali.URL), time.Minute)
+	if _, err := hatali.GetFiyatlar(); err == nil {
+		t.Fatal("hata bekleniyordu")
+	}
+	if hatali.fiyatlar != nil {
+		t.Error("hatalı çekim önbelleğe alındı")
+	}
+
+	// İki grup da alınamayınca dönen bayat fiyatlar taze sayılıp önbellekte tutulmaz
+	bayatKaynak := NewAltinKaynakServiceWithURL(arizali.URL)
+	bayatKaynak.SetOnbellekYolu(altinKaynak.onbellekYolu)
+	bayat := newOnbellekliSaglayici(bayatKaynak, time.Minute)
+	bayat.simdi = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	ilkBayat, err := bayat.GetFiyatlar()
+	if err != nil || len(ilkBayat.Eksikler) != 2 {
+		t.Fatalf("son başarılı fiyatlar dönmedi: %v, %v", ilkBayat, err)
+	}
+	oncekiIstek := arizaliIstekSayisi("/Gold.json")
+	if _, err := bayat.GetFiyatlar(); err != nil || arizaliIstekSayisi("/Gold.json") == oncekiIstek {
+		t.Fatalf("bayat görüntü önbellekten verildi: %d altın isteği", arizaliIstekSayisi("/Gold.json"))
+	}
+}
+
+func TestFiyatKalemleri(t *testing.T) {
+	fiyatlar, err := NewFixtureSaglayici(fixtureDizini).GetFiyatlar()
+	if err != nil {
+		t.Fatalf("GetFiyatlar: %v", err)
+	}
+
+	kalemler := fiyatlar.Kalemler()
+	usd := kalemler["USD"]
+	if usd == nil || usd.Change == nil || *usd.Change != 0.05 {
+		t.Fatalf("USD kalemi beklenmedik: %+v", usd)
+	}
+	zaman, err := usd.Zaman()
+	if err != nil || zaman.Format("02.01.2006 15:04:05") != "17.10.2026 10:15:32" {
+		t.Errorf("USD zamanı = %v, %v", zaman, err)
+	}
+
+	durum := func(metin string) *string { return &metin }
+	for _, tt := range []struct {
+		durum *string
+		uyari string
+	}{
+		{nil, ""},
+		{durum(""), ""},
+		{durum("Açık"), ""},
+		{durum("aktif"), ""},
+		{durum("Kapalı"), "Kapalı"},
+		{durum(" Askıda "), "Askıda"},
+	} {
+		kalem := RestPriceItem{Durum: tt.durum}
+		if uyari := kalem.DurumUyarisi(); uyari != tt.uyari {
+			t.Errorf("Durum %v için uyarı %q, beklenen %q", tt.durum, uyari, tt.uyari)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// silinenler yalnızca silinmiş (çöp kutusundaki) envanter kayıtlarını seçer
+func silinenler(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("deleted_at IS NOT NULL")
+}
+
+// GetSilinenEnvanterler seçili portföyün (veya tüm portföylerin) silinmiş kayıtlarını
+// en son silinenden başlayarak getirir
+func (s *EnvanterService) GetSilinenEnvanterler() ([]models.Envanter, error) {
+	var envanterler []models.Envanter
+	err := database.GetDB().Scopes(silinenler, s.portfoyKapsami).Order("deleted_at desc, id desc").Find(&envanterler).Error
+	if err != nil {
+		return nil, fmt.Errorf("silinen kayıtlar getirilemedi: %w", err)
+	}
+	return envanterler, nil
+}
+
+// getSilinenEnvanter çöp kutusundaki tek bir kaydı getirir
+func getSilinenEnvanter(tx *gorm.DB, id uint) (*models.Envanter, error) {
+	var envanter models.Envanter
+	if err := tx.Scopes(silinenler).First(&envanter, id).Error; err != nil {
+		return nil, fmt.Errorf("çöp kutusunda kayıt bulunamadı: ID %d: %w", id, err)
+	}
+	return &envanter, nil
+}
+
+// RestoreEnvanter silinmiş kaydı çöp kutusundan geri alır (DeletedAt temizlenir)
+func (s *EnvanterService) RestoreEnvanter(id uint) (*models.Envanter, error) {
+	db := database.GetDB()
+	envanter, err := getSilinenEnvanter(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := portfoyVarMi(db, envanter.PortfoyID); err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(envanter).Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("kayıt geri alınamadı: %w", err)
+		}
+		envanter.DeletedAt = gorm.DeletedAt{}
+		return degisiklikKaydet(tx, models.DegisiklikGeriAlma, nil, envanter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Envanter kaydı çöp kutusundan geri alındı: ID %d", id)
+	database.DegisiklikSonrasi("geri_alma")
+	return envanter, nil
+}
+
+// PurgeEnvanter çöp kutusundaki kaydı işlemleriyle birlikte kalıcı olarak siler. Satış veya hediye
+// çıkışı olan kayıtlar gerçekleşen kâr/zarar geçmişini taşıdığından kalıcı olarak silinmez.
+func (s *EnvanterService) PurgeEnvanter(id uint) (*models.Envanter, error) {
+	var envanter *models.Envanter
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		if envanter, err = getSilinenEnvanter(tx, id); err != nil {
+			return err
+		}
+		cikislilar, err := cikisliLotlar(tx, []uint{id})
+		if err != nil {
+			return err
+		}
+		if cikislilar[id] {
+			return fmt.Errorf("kaydın satış/çıkış geçmişi var, kalıcı olarak silinemez: ID %d", id)
+		}
+		return kaliciSil(tx, []models.Envanter{*envanter})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Envanter kaydı kalıcı olarak silindi: ID %d", id)
+	database.DegisiklikSonrasi("kalici_silme")
+	return envanter, nil
+}
+
+// EmptyCopKutusu seçili portföyün (veya tüm portföylerin) çöp kutusunu boşaltır; kalıcı olarak silinen
+// ve satış/çıkış geçmişi olduğu için çöp kutusunda bırakılan kayıt sayılarını döner
+func (s *EnvanterService) EmptyCopKutusu() (silinen int, korunan int, err error) {
+	var envanterler []models.Envanter
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(silinenler, s.portfoyKapsami).Find(&envanterler).Error; err != nil {
+			return fmt.Errorf("silinen kayıtlar getirilemedi: %w", err)
+		}
+		idler := make([]uint, len(envanterler))
+		for i := range envanterler {
+			idler[i] = envanterler[i].ID
+		}
+		cikislilar, err := cikisliLotlar(tx, idler)
+		if err != nil {
+			return err
+		}
+		silinecekler := envanterler[:0]
+		for _, envanter := range envanterler {
+			if cikislilar[envanter.ID] {
+				korunan++
+				continue
+			}
+			silinecekler = append(silinecekler, envanter)
+		}
+		silinen = len(silinecekler)
+		return kaliciSil(tx, silinecekler)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if silinen > 0 {
+		logger.Infof("Çöp kutusu boşaltıldı: %d kayıt kalıcı olarak silindi, %d kayıt satış geçmişi nedeniyle korundu", silinen, korunan)
+		database.DegisiklikSonrasi("kalici_silme")
+	}
+	return silinen, korunan, nil
+}
+
+// cikisliLotlar verilen kayıtlardan satış veya hediye çıkışı işlemi olanları döner
+func cikisliLotlar(tx *gorm.DB, idler []uint) (map[uint]bool, error) {
+	sonuc := make(map[uint]bool)
+	if len(idler) == 0 {
+		return sonuc, nil
+	}
+	var cikisliIDler []uint
+	err := tx.Model(&models.Islem{}).
+		Where("envanter_id IN ? AND tip IN ?", idler, []string{models.IslemSatis, models.IslemHediyeCikis}).
+		Distinct().Pluck("envanter_id", &cikisliIDler).Error
+	if err != nil {
+		return nil, fmt.Errorf("kayıtların çıkış işlemleri getirilemedi: %w", err)
+	}
+	for _, id := range cikisliIDler {
+		sonuc[id] = true
+	}
+	return sonuc, nil
+}
+
+// kaliciSil kayıtları ve bağlı işlemlerini veritabanından tamamen kaldırır; denetim kayıtları korunur.
+// Çağıran, çıkış işlemi olan kayıtları (cikisliLotlar) önceden ayıklamalıdır.
+func kaliciSil(tx *gorm.DB, envanterler []models.Envanter) error {
+	if len(envanterler) == 0 {
+		return nil
+	}
+	idler := make([]uint, len(envanterler))
+	for i := range envanterler {
+		idler[i] = envanterler[i].ID
+		if err := degisiklikKaydet(tx, models.DegisiklikKaliciSilme, &envanterler[i], nil); err != nil {
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where("envanter_id IN ?", idler).Delete(&models.Islem{}).Error; err != nil {
+		return fmt.Errorf("kaydın işlemleri silinemedi: %w", err)
+	}
+	if err := tx.Unscoped().Delete(&models.Envanter{}, idler).Error; err != nil {
+		return fmt.Errorf("kayıt kalıcı olarak silinemedi: %w", err)
+	}
+	return nil
+}
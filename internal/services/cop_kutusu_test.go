@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestCopKutusu(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+
+	ikinci, err := NewPortfoyService().AddPortfoy("İkinci", "")
+	if err != nil {
+		t.Fatalf("portföy eklenemedi: %v", err)
+	}
+	ceyrek := testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+	dolar := testLotuEkle(t, s, 0, "Döviz", "USD", "adet", "1000", "40")
+	yarim := testLotuEkle(t, s, ikinci.ID, "Altın", "Y", "adet", "1", "13000")
+	if _, err := s.DisposeEnvanter(dolar.ID, models.IslemSatis, decimal.NewFromInt(100), decimal.NewFromInt(42), time.Now(), ""); err != nil {
+		t.Fatalf("satış: %v", err)
+	}
+
+	for _, id := range []uint{ceyrek.ID, dolar.ID, yarim.ID} {
+		if err := s.DeleteEnvanter(id); err != nil {
+			t.Fatalf("silme: %v", err)
+		}
+	}
+
+	silinenler, err := s.GetSilinenEnvanterler()
+	if err != nil {
+		t.Fatalf("GetSilinenEnvanterler: %v", err)
+	}
+	if len(silinenler) != 3 {
+		t.Fatalf("çöp kutusunda %d kayıt, beklenen 3", len(silinenler))
+	}
+
+	// Portföy seçiliyken yalnızca o portföyün silinenleri görünür
+	s.SetPortfoy(ikinci.ID)
+	if silinenler, _ = s.GetSilinenEnvanterler(); len(silinenler) != 1 || silinenler[0].ID != yarim.ID {
+		t.Fatalf("ikinci portföyün çöp kutusu beklenmedik: %v", silinenler)
+	}
+	s.SetPortfoy(0)
+
+	// Geri alınan kayıt yeniden açık lotlar arasında görünür
+	geri, err := s.RestoreEnvanter(ceyrek.ID)
+	if err != nil {
+		t.Fatalf("RestoreEnvanter: %v", err)
+	}
+	if geri.Kod != "C" || geri.DeletedAt.Valid {
+		t.Errorf("geri alınan kayıt beklenmedik: %+v", geri)
+	}
+	if _, err := s.GetEnvanterByID(ceyrek.ID); err != nil {
+		t.Errorf("geri alınan kayıt bulunamadı: %v", err)
+	}
+	if _, err := s.RestoreEnvanter(ceyrek.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("çöp kutusunda olmayan kayıt için ErrRecordNotFound bekleniyordu: %v", err)
+	}
+
+	// Satış geçmişi olan kayıt kalıcı olarak silinmez; gerçekleşen kar/zarar silinmiş lotta da sayılır
+	if _, err := s.PurgeEnvanter(dolar.ID); err == nil {
+		t.Error("satışı olan kayıt kalıcı olarak silinmemeli")
+	}
+	gerceklesenKontrol := func(asama string) {
+		t.Helper()
+		gerceklesenler, err := s.getGerceklesenKarZararlar()
+		if err != nil {
+			t.Fatalf("getGerceklesenKarZararlar: %v", err)
+		}
+		if len(gerceklesenler) != 1 || !gerceklesenler[0].KarZarar.Equal(decimal.NewFromInt(200)) {
+			t.Errorf("%s gerçekleşen kar/zarar beklenmedik: %+v", asama, gerceklesenler)
+		}
+	}
+	gerceklesenKontrol("silme sonrası")
+	if _, err := s.PurgeEnvanter(ceyrek.ID); err == nil {
+		t.Error("açık kayıt kalıcı olarak silinmemeli")
+	}
+
+	// Çöp kutusundaki kayıt portföyün silinmesini engeller; boşaltınca silinebilir
+	if err := NewPortfoyService().DeletePortfoy(ikinci.ID); err == nil {
+		t.Error("çöp kutusunda kaydı olan portföy silinmemeli")
+	}
+	silinen, korunan, err := s.EmptyCopKutusu()
+	if err != nil || silinen != 1 || korunan != 1 {
+		t.Fatalf("EmptyCopKutusu = %d, %d, %v", silinen, korunan, err)
+	}
+	var islemSayisi, kayitSayisi int64
+	database.GetDB().Unscoped().Model(&models.Islem{}).Where("envanter_id = ?", yarim.ID).Count(&islemSayisi)
+	database.GetDB().Unscoped().Model(&models.Envanter{}).Where("id = ?", yarim.ID).Count(&kayitSayisi)
+	if islemSayisi != 0 || kayitSayisi != 0 {
+		t.Errorf("kalıcı silme sonrası %d işlem, %d kayıt kaldı", islemSayisi, kayitSayisi)
+	}
+	gerceklesenKontrol("boşaltma sonrası")
+	if err := NewPortfoyService().DeletePortfoy(ikinci.ID); err != nil {
+		t.Errorf("boş portföy silinemedi: %v", err)
+	}
+	if silinenler, _ = s.GetSilinenEnvanterler(); len(silinenler) != 1 || silinenler[0].ID != dolar.ID {
+		t.Errorf("boşaltma sonrası çöp kutusunda yalnızca satışı olan kayıt kalmalı: %v", silinenler)
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"os"
+	"strings"
+
+	"altintakip/internal/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+// Değerleme modları: envanterin güncel değeri hangi fiyattan hesaplanır
+const (
+	DegerlemeAlis  = "alis"  // Kuyumcunun alış fiyatı (bugün satılırsa elde edilecek tutar)
+	DegerlemeOrta  = "orta"  // Alış ve satış fiyatının ortalaması
+	DegerlemeSatis = "satis" // Kuyumcunun satış fiyatı (bugün yeniden alma maliyeti)
+)
+
+// degerlemeModuIsimleri değerleme modlarının görünen isimleri
+var degerlemeModuIsimleri = map[string]string{
+	DegerlemeAlis:  "Alış (Likidasyon)",
+	DegerlemeOrta:  "Orta Fiyat",
+	DegerlemeSatis: "Satış (Yerine Koyma)",
+}
+
+// DegerlemeModu VALUATION_MODE ayarını döner (varsayılan: alis)
+func DegerlemeModu() string {
+	mod := strings.ToLower(strings.TrimSpace(os.Getenv("VALUATION_MODE")))
+	if _, exists := degerlemeModuIsimleri[mod]; exists {
+		return mod
+	}
+	if mod != "" {
+		logger.Warnf("Geçersiz VALUATION_MODE değeri: %s, alış fiyatı kullanılıyor", mod)
+	}
+	return DegerlemeAlis
+}
+
+// DegerlemeModuIsmi değerleme modunun görünen ismini döner
+func DegerlemeModuIsmi(mod string) string {
+	if isim, exists := degerlemeModuIsimleri[mod]; exists {
+		return isim
+	}
+	return mod
+}
+
+// DegerlemeFiyati alış/satış fiyatından değerleme moduna göre birim fiyatı seçer.
+// Satış fiyatı bilinmiyorsa alış fiyatı kullanılır.
+func DegerlemeFiyati(alis, satis decimal.Decimal, mod string) decimal.Decimal {
+	if !satis.IsPositive() {
+		return alis
+	}
+	switch mod {
+	case DegerlemeOrta:
+		return alis.Add(satis).Div(decimal.NewFromInt(2))
+	case DegerlemeSatis:
+		return satis
+	}
+	return alis
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// degisiklikKaynagi değişikliklerin hangi arayüzden yapıldığı (SetDegisiklikKaynagi ile ayarlanır)
+var degisiklikKaynagi = "cli"
+
+// SetDegisiklikKaynagi denetim kaydına yazılacak arayüz adını ayarlar (tui, cli, api)
+func SetDegisiklikKaynagi(kaynak string) {
+	degisiklikKaynagi = kaynak
+}
+
+var (
+	kullaniciOnce sync.Once
+	kullaniciAdi  string
+)
+
+// degisiklikKullanicisi değişikliği yapan işletim sistemi kullanıcısını döner
+func degisiklikKullanicisi() string {
+	kullaniciOnce.Do(func() {
+		if u, err := user.Current(); err == nil && u.Username != "" {
+			kullaniciAdi = u.Username
+		} else {
+			kullaniciAdi = os.Getenv("USER")
+		}
+		if host, err := os.Hostname(); err == nil && host != "" && kullaniciAdi != "" {
+			kullaniciAdi += "@" + host
+		}
+	})
+	return kullaniciAdi
+}
+
+// degisiklikKaydet lotun değişiklik öncesi ve sonrası halini verilen işlem içinde denetim kaydına yazar.
+// Değişiklikle aynı işlemde yazıldığından kayıt yazılamazsa değişiklik de geri alınır.
+func degisiklikKaydet(tx *gorm.DB, tur string, onceki, sonraki *models.Envanter) error {
+	kayit := models.DegisiklikKaydi{
+		Zaman:     time.Now(),
+		Tur:       tur,
+		Kullanici: degisiklikKullanicisi(),
+		Kaynak:    degisiklikKaynagi,
+	}
+	for _, taraf := range []struct {
+		envanter *models.Envanter
+		hedef    *string
+	}{{onceki, &kayit.Onceki}, {sonraki, &kayit.Sonraki}} {
+		if taraf.envanter == nil {
+			continue
+		}
+		kayit.EnvanterID = taraf.envanter.ID
+		veri, err := json.Marshal(taraf.envanter)
+		if err != nil {
+			return fmt.Errorf("denetim kaydı oluşturulamadı: %w", err)
+		}
+		*taraf.hedef = string(veri)
+	}
+
+	if err := tx.Create(&kayit).Error; err != nil {
+		return fmt.Errorf("denetim kaydı yazılamadı: %w", err)
+	}
+	return nil
+}
+
+// DegisiklikKaydiService envanter değişikliklerinin denetim kayıtlarını okur
+type DegisiklikKaydiService struct{}
+
+// NewDegisiklikKaydiService yeni denetim kaydı servisi oluşturur
+func NewDegisiklikKaydiService() *DegisiklikKaydiService {
+	return &DegisiklikKaydiService{}
+}
+
+// GetEnvanterGecmisi bir lotun tüm değişikliklerini eskiden yeniye getirir
+func (s *DegisiklikKaydiService) GetEnvanterGecmisi(envanterID uint) ([]models.DegisiklikKaydi, error) {
+	var kayitlar []models.DegisiklikKaydi
+	err := database.GetDB().Where("envanter_id = ?", envanterID).Order("zaman asc, id asc").Find(&kayitlar).Error
+	if err != nil {
+		return nil, fmt.Errorf("değişiklik geçmişi getirilemedi: %w", err)
+	}
+	return kayitlar, nil
+}
+
+// GetSonDegisiklikler tüm lotlardaki son değişiklikleri yeniden eskiye getirir
+func (s *DegisiklikKaydiService) GetSonDegisiklikler(limit int) ([]models.DegisiklikKaydi, error) {
+	var kayitlar []models.DegisiklikKaydi
+	err := database.GetDB().Order("zaman desc, id desc").Limit(limit).Find(&kayitlar).Error
+	if err != nil {
+		return nil, fmt.Errorf("değişiklik geçmişi getirilemedi: %w", err)
+	}
+	return kayitlar, nil
+}
+
+// AlanFarki denetim kaydında değeri değişen tek bir Envanter alanı (JSON adıyla)
+type AlanFarki struct {
+	Alan    string `json:"alan"`
+	Onceki  string `json:"onceki"`
+	Sonraki string `json:"sonraki"`
+}
+
+// DegisiklikGorunumu denetim kaydının JSON çıktısı: lotun önceki ve sonraki hali metin yerine
+// JSON nesnesi olarak, düzenleme ve çıkışlarda değişen alanlarla birlikte yazılır
+type DegisiklikGorunumu struct {
+	models.DegisiklikKaydi
+	Onceki  json.RawMessage `json:"onceki,omitempty"`
+	Sonraki json.RawMessage `json:"sonraki,omitempty"`
+	Farklar []AlanFarki     `json:"farklar,omitempty"`
+}
+
+// DegisiklikGorunumleri denetim kayıtlarını CLI ve API JSON çıktısı için hazırlar
+func DegisiklikGorunumleri(kayitlar []models.DegisiklikKaydi) ([]DegisiklikGorunumu, error) {
+	gorunumler := make([]DegisiklikGorunumu, 0, len(kayitlar))
+	for i := range kayitlar {
+		farklar, err := DegisiklikFarklari(&kayitlar[i])
+		if err != nil {
+			return nil, err
+		}
+		gorunumler = append(gorunumler, DegisiklikGorunumu{
+			DegisiklikKaydi: kayitlar[i],
+			Onceki:          json.RawMessage(kayitlar[i].Onceki),
+			Sonraki:         json.RawMessage(kayitlar[i].Sonraki),
+			Farklar:         farklar,
+		})
+	}
+	return gorunumler, nil
+}
+
+// farkDisiAlanlar her kayıtta değişen ve farklarda gösterilmeyen alanlar
+var farkDisiAlanlar = map[string]bool{"created_at": true, "updated_at": true}
+
+// DegisiklikFarklari kaydın öncesi ve sonrası arasında değişen alanları Envanter alan sırasıyla döner.
+// Ekleme, silme gibi tek taraflı kayıtlarda karşılaştırılacak taraf olmadığından nil döner.
+func DegisiklikFarklari(kayit *models.DegisiklikKaydi) ([]AlanFarki, error) {
+	if kayit.Onceki == "" || kayit.Sonraki == "" {
+		return nil, nil
+	}
+	oncekiAlanlar, oncekiDegerler, err := jsonAlanlari(kayit.Onceki)
+	if err != nil {
+		return nil, err
+	}
+	sonrakiAlanlar, sonrakiDegerler, err := jsonAlanlari(kayit.Sonraki)
+	if err != nil {
+		return nil, err
+	}
+
+	// Alan sırası sonraki halden alınır; yalnızca önceki halde olan alanlar (omitempty) sona eklenir
+	alanlar := sonrakiAlanlar
+	for _, alan := range oncekiAlanlar {
+		if _, exists := sonrakiDegerler[alan]; !exists {
+			alanlar = append(alanlar, alan)
+		}
+	}
+
+	var farklar []AlanFarki
+	for _, alan := range alanlar {
+		if farkDisiAlanlar[alan] || oncekiDegerler[alan] == sonrakiDegerler[alan] {
+			continue
+		}
+		farklar = append(farklar, AlanFarki{Alan: alan, Onceki: oncekiDegerler[alan], Sonraki: sonrakiDegerler[alan]})
+	}
+	return farklar, nil
+}
+
+// jsonAlanlari JSON nesnesinin alan adlarını yazıldığı sırayla ve değerlerini metin olarak döner
+func jsonAlanlari(veri string) ([]string, map[string]string, error) {
+	degerler := map[string]string{}
+	if strings.TrimSpace(veri) == "" {
+		return nil, degerler, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(veri))
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, fmt.Errorf("denetim kaydı çözülemedi: %w", err)
+	}
+	var alanlar []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, fmt.Errorf("denetim kaydı çözülemedi: %w", err)
+		}
+		alan, _ := token.(string)
+
+		var ham json.RawMessage
+		if err := decoder.Decode(&ham); err != nil {
+			return nil, nil, fmt.Errorf("denetim kaydı çözülemedi: %w", err)
+		}
+		// Metin değerler tırnaksız gösterilir
+		var metin string
+		if bytes.HasPrefix(ham, []byte(`"`)) && json.Unmarshal(ham, &metin) == nil {
+			degerler[alan] = metin
+		} else {
+			degerler[alan] = string(ham)
+		}
+		alanlar = append(alanlar, alan)
+	}
+	return alanlar, degerler, nil
+}
+
+// hesaplananAlanlar diğer alanlardan türetilen ve kısa özette gösterilmeyen alanlar
+var hesaplananAlanlar = map[string]bool{"toplam_alis": true, "guncel_tutar": true, "kar_zarar": true, "kar_zarar_yuzde": true}
+
+// DegisiklikOzeti kaydı tek satırda özetler: düzenleme ve çıkışlarda "alan: eski → yeni" listesi,
+// ekleme, silme gibi tek taraflı kayıtlarda lotun kod, miktar ve alış fiyatı
+func DegisiklikOzeti(kayit *models.DegisiklikKaydi) string {
+	if kayit.Onceki == "" || kayit.Sonraki == "" {
+		veri := kayit.Sonraki
+		if veri == "" {
+			veri = kayit.Onceki
+		}
+		var envanter models.Envanter
+		if err := json.Unmarshal([]byte(veri), &envanter); err != nil {
+			return fmt.Sprintf("çözülemedi: %v", err)
+		}
+		return fmt.Sprintf("%s %s %s, alış fiyatı %s", envanter.Kod, envanter.Miktar, envanter.Birim, envanter.AlisFiyati.StringFixed(2))
+	}
+
+	farklar, err := DegisiklikFarklari(kayit)
+	if err != nil {
+		return err.Error()
+	}
+	var parcalar []string
+	for _, fark := range farklar {
+		if !hesaplananAlanlar[fark.Alan] {
+			parcalar = append(parcalar, fmt.Sprintf("%s: %s → %s", fark.Alan, fark.Onceki, fark.Sonraki))
+		}
+	}
+	if len(parcalar) == 0 {
+		return "-"
+	}
+	return strings.Join(parcalar, ", ")
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDegisiklikKaydi(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+	degisiklikService := NewDegisiklikKaydiService()
+
+	ceyrek := testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+
+	// Alış fiyatı düzeltmesi
+	ceyrek.AlisFiyati = decimal.RequireFromString("6750")
+	if err := s.UpdateEnvanterWithMode(ceyrek, true); err != nil {
+		t.Fatalf("UpdateEnvanterWithMode: %v", err)
+	}
+	if _, err := s.DisposeEnvanter(ceyrek.ID, models.IslemSatis, decimal.NewFromInt(1), decimal.NewFromInt(7000), time.Now(), ""); err != nil {
+		t.Fatalf("satış: %v", err)
+	}
+	if err := s.DeleteEnvanter(ceyrek.ID); err != nil {
+		t.Fatalf("silme: %v", err)
+	}
+	if _, err := s.RestoreEnvanter(ceyrek.ID); err != nil {
+		t.Fatalf("geri alma: %v", err)
+	}
+	if err := s.DeleteEnvanter(ceyrek.ID); err != nil {
+		t.Fatalf("silme: %v", err)
+	}
+	if _, err := s.PurgeEnvanter(ceyrek.ID); err == nil {
+		t.Fatal("satışı olan lot kalıcı olarak silinmemeli")
+	}
+
+	kayitlar, err := degisiklikService.GetEnvanterGecmisi(ceyrek.ID)
+	if err != nil {
+		t.Fatalf("GetEnvanterGecmisi: %v", err)
+	}
+	beklenen := []string{
+		models.DegisiklikEkleme, models.DegisiklikDuzenleme, models.DegisiklikCikis, models.DegisiklikSilme,
+		models.DegisiklikGeriAlma, models.DegisiklikSilme,
+	}
+	if len(kayitlar) != len(beklenen) {
+		t.Fatalf("%d değişiklik kaydı, beklenen %d", len(kayitlar), len(beklenen))
+	}
+	for i, tur := range beklenen {
+		if kayitlar[i].Tur != tur {
+			t.Errorf("kayıt %d türü %s, beklenen %s", i, kayitlar[i].Tur, tur)
+		}
+		if kayitlar[i].EnvanterID != ceyrek.ID || kayitlar[i].Kaynak != "cli" {
+			t.Errorf("kayıt %d beklenmedik: %+v", i, kayitlar[i])
+		}
+	}
+	if kayitlar[0].Onceki != "" || kayitlar[0].Sonraki == "" {
+		t.Error("ekleme kaydında yalnızca sonraki hal olmalı")
+	}
+
+	// Lot kalıcı silinse de geçmişi korunur
+	gram := testLotuEkle(t, s, 0, "Altın", "GA", "gram", "5", "3000")
+	if err := s.DeleteEnvanter(gram.ID); err != nil {
+		t.Fatalf("silme: %v", err)
+	}
+	if _, err := s.PurgeEnvanter(gram.ID); err != nil {
+		t.Fatalf("kalıcı silme: %v", err)
+	}
+	gramKayitlari, err := degisiklikService.GetEnvanterGecmisi(gram.ID)
+	if err != nil {
+		t.Fatalf("GetEnvanterGecmisi: %v", err)
+	}
+	if len(gramKayitlari) != 3 {
+		t.Fatalf("%d değişiklik kaydı, beklenen 3", len(gramKayitlari))
+	}
+	if son := gramKayitlari[2]; son.Tur != models.DegisiklikKaliciSilme || son.Onceki == "" || son.Sonraki != "" {
+		t.Errorf("kalıcı silme kaydında yalnızca önceki hal olmalı: %+v", son)
+	}
+
+	// Düzenleme farkında alış fiyatı ve ondan türeyen toplam görünür, zaman damgaları görünmez
+	farklar, err := DegisiklikFarklari(&kayitlar[1])
+	if err != nil {
+		t.Fatalf("DegisiklikFarklari: %v", err)
+	}
+	degisenler := map[string]AlanFarki{}
+	for _, fark := range farklar {
+		degisenler[fark.Alan] = fark
+	}
+	if fark := degisenler["alis_fiyati"]; fark.Onceki != "6500" || fark.Sonraki != "6750" {
+		t.Errorf("alis_fiyati farkı beklenmedik: %+v", fark)
+	}
+	if _, ok := degisenler["toplam_alis"]; !ok {
+		t.Error("toplam_alis farkı bekleniyordu")
+	}
+	if _, ok := degisenler["updated_at"]; ok {
+		t.Error("updated_at farklarda gösterilmemeli")
+	}
+	if ozet := DegisiklikOzeti(&kayitlar[1]); ozet != "alis_fiyati: 6500 → 6750" {
+		t.Errorf("düzenleme özeti = %q", ozet)
+	}
+	if ozet := DegisiklikOzeti(&kayitlar[2]); ozet != "miktar: 2 → 1" {
+		t.Errorf("çıkış özeti = %q", ozet)
+	}
+	if farklar, _ := DegisiklikFarklari(&kayitlar[0]); farklar != nil {
+		t.Errorf("tek taraflı kayıtta fark olmamalı: %v", farklar)
+	}
+
+	// Son değişiklikler yeniden eskiye sıralanır
+	SetDegisiklikKaynagi("api")
+	t.Cleanup(func() { SetDegisiklikKaynagi("cli") })
+	dolar := testLotuEkle(t, s, 0, "Döviz", "USD", "adet", "100", "40")
+	son, err := degisiklikService.GetSonDegisiklikler(2)
+	if err != nil {
+		t.Fatalf("GetSonDegisiklikler: %v", err)
+	}
+	if len(son) != 2 || son[0].EnvanterID != dolar.ID || son[0].Kaynak != "api" || son[1].Tur != models.DegisiklikKaliciSilme {
+		t.Errorf("son değişiklikler beklenmedik: %+v", son)
+	}
+}
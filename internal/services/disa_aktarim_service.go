@@ -0,0 +1,296 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/format"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Dışa aktarım biçimleri
+const (
+	BicimJSON = "json"
+	BicimCSV  = "csv"
+)
+
+// CSV sayı formatları
+const (
+	SayiFormatiTR     = "tr"     // 1.234,56 - noktalı virgül ayraçlı, Türkçe Excel için
+	SayiFormatiMakine = "makine" // 1234.56 - virgül ayraçlı, programlar için
+)
+
+// Dışa aktarılan tablolar
+const (
+	TabloEnvanter = "envanter"
+	TabloGruplar  = "gruplar"
+	TabloOzet     = "ozet"
+)
+
+// DisaAktarimTablolari CSV olarak ayrı dosyalara yazılan tablolar
+var DisaAktarimTablolari = []string{TabloEnvanter, TabloGruplar, TabloOzet}
+
+// DisaAktarim envanter, kod bazlı gruplar ve toplamların tek bir anlık görüntüsü
+type DisaAktarim struct {
+	Zaman         time.Time                         `json:"zaman"`
+	DegerlemeModu string                            `json:"degerleme_modu"`
+	Portfoy       string                            `json:"portfoy,omitempty"` // Boşsa tüm portföyler
+	Envanter      []models.Envanter                 `json:"envanter"`
+	Gruplar       map[string]map[string]interface{} `json:"gruplar"`
+	Toplamlar     map[string]decimal.Decimal        `json:"toplamlar"`
+}
+
+// DisaAktarimService envanter verilerini JSON ve CSV olarak dışa aktarır
+type DisaAktarimService struct {
+	envanterService *EnvanterService
+}
+
+// NewDisaAktarimService yeni dışa aktarım servisi oluşturur
+func NewDisaAktarimService() *DisaAktarimService {
+	return &DisaAktarimService{
+		envanterService: NewEnvanterService(),
+	}
+}
+
+// SetPortfoy dışa aktarımı tek bir portföyle sınırlar (0: tüm portföyler)
+func (s *DisaAktarimService) SetPortfoy(portfoyID uint) {
+	s.envanterService.SetPortfoy(portfoyID)
+}
+
+// Topla veritabanındaki son değerlerden dışa aktarım görüntüsünü hazırlar (API çağrısı yapmaz)
+func (s *DisaAktarimService) Topla() (*DisaAktarim, error) {
+	// Üç tablo aynı görüntüden hazırlanır
+	gorunum, err := s.envanterService.GetGorunum()
+	if err != nil {
+		return nil, err
+	}
+	envanterler, gruplar, toplamlar := gorunum.Envanterler, gorunum.Gruplar, gorunum.Toplamlar
+
+	if envanterler == nil {
+		envanterler = []models.Envanter{}
+	}
+
+	var portfoyAdi string
+	if portfoyID := s.envanterService.GetPortfoy(); portfoyID != 0 {
+		var portfoy models.Portfoy
+		if err := database.GetDB().First(&portfoy, portfoyID).Error; err != nil {
+			return nil, fmt.Errorf("portföy bulunamadı: %w", err)
+		}
+		portfoyAdi = portfoy.Ad
+	}
+
+	return &DisaAktarim{
+		Zaman:         time.Now(),
+		DegerlemeModu: s.envanterService.GetDegerlemeModu(),
+		Portfoy:       portfoyAdi,
+		Envanter:      envanterler,
+		Gruplar:       gruplar,
+		Toplamlar:     toplamlar,
+	}, nil
+}
+
+// WriteJSON görüntünün tamamını girintili JSON olarak yazar
+func (s *DisaAktarimService) WriteJSON(w io.Writer, veri *DisaAktarim) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(veri)
+}
+
+// WriteCSV tek bir tabloyu seçilen sayı formatında CSV olarak yazar
+func (s *DisaAktarimService) WriteCSV(w io.Writer, veri *DisaAktarim, tablo, sayiFormati string) error {
+	if sayiFormati != SayiFormatiTR && sayiFormati != SayiFormatiMakine {
+		return fmt.Errorf("geçersiz sayı formatı: %s (tr veya makine olmalı)", sayiFormati)
+	}
+
+	var satirlar [][]string
+	switch tablo {
+	case TabloEnvanter:
+		satirlar = envanterSatirlari(veri.Envanter, sayiFormati)
+	case TabloGruplar:
+		satirlar = grupSatirlari(veri.Gruplar, sayiFormati)
+	case TabloOzet:
+		satirlar = ozetSatirlari(veri.Toplamlar, sayiFormati)
+	default:
+		return fmt.Errorf("bilinmeyen tablo: %s", tablo)
+	}
+
+	// Türkçe Excel ondalık virgül kullandığı için ayraç noktalı virgüldür;
+	// UTF-8 BOM Excel'in Türkçe karakterleri doğru okumasını sağlar
+	if sayiFormati == SayiFormatiTR {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return err
+		}
+	}
+
+	yazici := csv.NewWriter(w)
+	if sayiFormati == SayiFormatiTR {
+		yazici.Comma = ';'
+	}
+	if err := yazici.WriteAll(satirlar); err != nil {
+		return fmt.Errorf("CSV yazılamadı: %w", err)
+	}
+	return nil
+}
+
+// SaveDosyalar görüntüyü dizine yazar: JSON için tek dosya, CSV için tablo başına bir dosya
+func (s *DisaAktarimService) SaveDosyalar(veri *DisaAktarim, dizin, bicim, sayiFormati string) ([]string, error) {
+	if err := os.MkdirAll(dizin, 0755); err != nil {
+		return nil, fmt.Errorf("dışa aktarım dizini oluşturulamadı: %w", err)
+	}
+
+	zamanEki := veri.Zaman.Format("20060102_150405")
+	var dosyalar []string
+
+	switch bicim {
+	case BicimJSON:
+		yol := filepath.Join(dizin, fmt.Sprintf("altintakip_%s.json", zamanEki))
+		if err := dosyayaYaz(yol, func(w io.Writer) error { return s.WriteJSON(w, veri) }); err != nil {
+			return nil, err
+		}
+		dosyalar = append(dosyalar, yol)
+	case BicimCSV:
+		for _, tablo := range DisaAktarimTablolari {
+			yol := filepath.Join(dizin, fmt.Sprintf("altintakip_%s_%s.csv", tablo, zamanEki))
+			if err := dosyayaYaz(yol, func(w io.Writer) error { return s.WriteCSV(w, veri, tablo, sayiFormati) }); err != nil {
+				return nil, err
+			}
+			dosyalar = append(dosyalar, yol)
+		}
+	default:
+		return nil, fmt.Errorf("geçersiz dışa aktarım biçimi: %s (json veya csv olmalı)", bicim)
+	}
+
+	logger.Infof("Dışa aktarım tamamlandı: %v", dosyalar)
+	return dosyalar, nil
+}
+
+// dosyayaYaz dosyayı oluşturur ve içeriği yazar
+func dosyayaYaz(yol string, yaz func(w io.Writer) error) error {
+	dosya, err := os.Create(yol)
+	if err != nil {
+		return fmt.Errorf("dosya oluşturulamadı: %w", err)
+	}
+	if err := yaz(dosya); err != nil {
+		dosya.Close()
+		return err
+	}
+	return dosya.Close()
+}
+
+// envanterSatirlari envanter tablosunun başlık ve veri satırlarını hazırlar
+func envanterSatirlari(envanterler []models.Envanter, sayiFormati string) [][]string {
+	baslik := []string{"id", "tur", "cins", "kod", "miktar", "birim", "giris_tipi", "alis_tarihi", "alis_fiyati", "toplam_alis",
+		"guncel_alis_fiyati", "guncel_satis_fiyati", "guncel_fiyat", "guncel_tutar", "makas_maliyeti", "kar_zarar", "kar_zarar_yuzde", "notlar"}
+	if sayiFormati == SayiFormatiTR {
+		baslik = []string{"ID", "Tür", "Cins", "Kod", "Miktar", "Birim", "Giriş Tipi", "Alış Tarihi", "Alış Fiyatı", "Toplam Alış",
+			"Güncel Alış", "Güncel Satış", "Güncel Fiyat", "Güncel Tutar", "Makas", "K/Z", "K/Z %", "Notlar"}
+	}
+
+	satirlar := [][]string{baslik}
+	for _, e := range envanterler {
+		satirlar = append(satirlar, []string{
+			fmt.Sprintf("%d", e.ID), e.Tur, e.Cins, e.Kod,
+			miktarYaz(e.Miktar, sayiFormati), e.Birim, e.GirisTipi,
+			tarihYaz(e.AlisTarihi, sayiFormati),
+			tutarYaz(e.AlisFiyati, sayiFormati), tutarYaz(e.ToplamAlis, sayiFormati),
+			tutarYaz(e.GuncelAlisFiyati, sayiFormati), tutarYaz(e.GuncelSatisFiyati, sayiFormati),
+			tutarYaz(e.GuncelFiyat, sayiFormati), tutarYaz(e.GuncelTutar, sayiFormati),
+			tutarYaz(e.MakasMaliyeti(), sayiFormati),
+			tutarYaz(e.KarZarar, sayiFormati), tutarYaz(e.KarZararYuzde, sayiFormati),
+			e.Notlar,
+		})
+	}
+	return satirlar
+}
+
+// grupSatirlari kod bazlı grup tablosunun satırlarını koda göre sıralı hazırlar
+func grupSatirlari(gruplar map[string]map[string]interface{}, sayiFormati string) [][]string {
+	baslik := []string{"kod", "tur", "cins", "birim", "adet", "toplam_miktar", "ortalama_alis_fiyati", "toplam_alis_tutar",
+		"toplam_guncel_tutar", "gerceklesmemis_kar_zarar", "kar_zarar_yuzde", "gerceklesen_kar_zarar"}
+	if sayiFormati == SayiFormatiTR {
+		baslik = []string{"Kod", "Tür", "Cins", "Birim", "Lot Sayısı", "Toplam Miktar", "Ort. Alış Fiyatı", "Toplam Alış",
+			"Güncel Tutar", "Gerçekleşmemiş K/Z", "K/Z %", "Gerçekleşen K/Z"}
+	}
+
+	kodlar := make([]string, 0, len(gruplar))
+	for kod := range gruplar {
+		kodlar = append(kodlar, kod)
+	}
+	sort.Strings(kodlar)
+
+	satirlar := [][]string{baslik}
+	for _, kod := range kodlar {
+		grup := gruplar[kod]
+		satirlar = append(satirlar, []string{
+			kod, fmt.Sprint(grup["tur"]), fmt.Sprint(grup["cins"]), fmt.Sprint(grup["birim"]), fmt.Sprint(grup["adet"]),
+			miktarYaz(grup["toplam_miktar"].(decimal.Decimal), sayiFormati),
+			tutarYaz(grup["ortalama_alis_fiyati"].(decimal.Decimal), sayiFormati),
+			tutarYaz(grup["toplam_alis_tutar"].(decimal.Decimal), sayiFormati),
+			tutarYaz(grup["toplam_guncel_tutar"].(decimal.Decimal), sayiFormati),
+			tutarYaz(grup["gerceklesmemis_kar_zarar"].(decimal.Decimal), sayiFormati),
+			tutarYaz(grup["kar_zarar_yuzde"].(decimal.Decimal), sayiFormati),
+			tutarYaz(grup["gerceklesen_kar_zarar"].(decimal.Decimal), sayiFormati),
+		})
+	}
+	return satirlar
+}
+
+// ozetSatirlari toplam değerleri alan/değer satırları olarak hazırlar
+func ozetSatirlari(toplamlar map[string]decimal.Decimal, sayiFormati string) [][]string {
+	alanlar := []struct{ anahtar, isim string }{
+		{"toplam_alis", "Toplam Alış"},
+		{"toplam_guncel", "Güncel Değer"},
+		{"toplam_kar", "Kar/Zarar"},
+		{"toplam_kar_yuzde", "Kar/Zarar %"},
+		{"toplam_gerceklesen_kar", "Gerçekleşen K/Z"},
+	}
+
+	satirlar := [][]string{{"alan", "deger"}}
+	if sayiFormati == SayiFormatiTR {
+		satirlar = [][]string{{"Alan", "Değer"}}
+	}
+	for _, alan := range alanlar {
+		isim := alan.anahtar
+		if sayiFormati == SayiFormatiTR {
+			isim = alan.isim
+		}
+		satirlar = append(satirlar, []string{isim, tutarYaz(toplamlar[alan.anahtar], sayiFormati)})
+	}
+	return satirlar
+}
+
+// tutarYaz TL tutarını 2 ondalıkla seçilen formatta yazar
+func tutarYaz(tutar decimal.Decimal, sayiFormati string) string {
+	metin := tutar.StringFixed(2)
+	if sayiFormati == SayiFormatiTR {
+		return format.TurkishNumber(metin)
+	}
+	return metin
+}
+
+// miktarYaz miktarı gereksiz sıfırlar olmadan seçilen formatta yazar
+func miktarYaz(miktar decimal.Decimal, sayiFormati string) string {
+	metin := miktar.String()
+	if sayiFormati == SayiFormatiTR {
+		return format.TurkishNumber(metin)
+	}
+	return metin
+}
+
+// tarihYaz tarihi Türkçe (GG.AA.YYYY) veya ISO (YYYY-AA-GG) formatında yazar
+func tarihYaz(tarih time.Time, sayiFormati string) string {
+	if sayiFormati == SayiFormatiTR {
+		return tarih.Format("02.01.2006")
+	}
+	return tarih.Format("2006-01-02")
+}
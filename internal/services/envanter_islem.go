@@ -0,0 +1,318 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// miktarHassasiyeti ağırlıklı ortalamada lotlara dağıtılan miktarların ondalık basamak sayısı
+const miktarHassasiyeti = 8
+
+// KodGerceklesen bir kod için satışlardan gerçekleşen kar/zarar toplamı
+type KodGerceklesen struct {
+	Kod      string
+	Tur      string
+	Cins     string
+	Birim    string
+	KarZarar decimal.Decimal
+}
+
+// cikisGunuSonu çıkış gününün bittiği an. Tarihler gün hassasiyetinde girildiğinden alış tarihi
+// bu andan önce olan (çıkışla aynı gün veya daha önce alınmış) lotlardan çıkış yapılabilir.
+func cikisGunuSonu(tarih time.Time) time.Time {
+	yil, ay, gun := tarih.Date()
+	return time.Date(yil, ay, gun+1, 0, 0, 0, 0, tarih.Location())
+}
+
+// cikisParametreleri bir satış veya hediye çıkışının ortak bilgileri
+type cikisParametreleri struct {
+	tip        string
+	birimFiyat decimal.Decimal
+	tarih      time.Time
+	notlar     string
+	referans   string
+	yontem     string
+}
+
+// DisposeEnvanter belirli bir lottan satış veya hediye çıkışı yapar. Kısmi çıkışta
+// lotun kalan miktarı azalır; tamamı çıkarsa lot kapanır ama geçmişi korunur.
+func (s *EnvanterService) DisposeEnvanter(id uint, tip string, miktar, birimFiyat decimal.Decimal, tarih time.Time, notlar string) (*models.Islem, error) {
+	if err := cikisDogrula(tip, miktar, birimFiyat); err != nil {
+		return nil, err
+	}
+
+	params := cikisParametreleri{
+		tip:        tip,
+		birimFiyat: birimFiyat,
+		tarih:      tarih,
+		notlar:     notlar,
+		referans:   yeniCikisReferansi(),
+	}
+
+	var islem models.Islem
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var envanter models.Envanter
+		if err := tx.First(&envanter, id).Error; err != nil {
+			return fmt.Errorf("envanter kaydı bulunamadı: %w", err)
+		}
+		if !envanter.AlisTarihi.Before(cikisGunuSonu(tarih)) {
+			return fmt.Errorf("çıkış tarihi (%s) lotun alış tarihinden (%s) önce olamaz",
+				tarih.Format("02.01.2006"), envanter.AlisTarihi.Format("02.01.2006"))
+		}
+		if miktar.GreaterThan(envanter.Miktar) {
+			return fmt.Errorf("çıkış miktarı (%s) kalan miktardan (%s) fazla olamaz", miktar, envanter.Miktar)
+		}
+
+		var err error
+		islem, err = lottanCikisYap(tx, &envanter, miktar, params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("çıkış işlemi kaydedilemedi: %w", err)
+	}
+
+	logger.Infof("%s işlemi kaydedildi: Envanter ID %d, %s %s, gerçekleşen kar/zarar %s",
+		models.IslemTipleri[tip], id, miktar, islem.Kod, islem.GerceklesenKarZarar.StringFixed(2))
+	database.DegisiklikSonrasi("cikis")
+	return &islem, nil
+}
+
+// DisposeByKod bir koddan satış veya hediye çıkışı yapar. Tüketilecek lotlar kodun
+// maliyet yöntemine göre seçilir: FIFO en eski, LIFO en yeni lottan başlar;
+// ağırlıklı ortalamada tüm açık lotlar aynı oranda azaltılır. Yalnızca seçili portföyün
+// lotları tüketilir; tüm portföyler görünümünde kod tek bir portföyde olmalıdır.
+func (s *EnvanterService) DisposeByKod(kod, tip string, miktar, birimFiyat decimal.Decimal, tarih time.Time, notlar string) ([]models.Islem, error) {
+	if err := cikisDogrula(tip, miktar, birimFiyat); err != nil {
+		return nil, err
+	}
+
+	yontem, err := s.GetMaliyetYontemi(kod)
+	if err != nil {
+		return nil, err
+	}
+
+	params := cikisParametreleri{
+		tip:        tip,
+		birimFiyat: birimFiyat,
+		tarih:      tarih,
+		notlar:     notlar,
+		referans:   yeniCikisReferansi(),
+		yontem:     yontem,
+	}
+
+	var islemler []models.Islem
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		siralama := "alis_tarihi asc, id asc"
+		if yontem == models.YontemLIFO {
+			siralama = "alis_tarihi desc, id desc"
+		}
+
+		// Çıkış tarihinden sonra alınan lotlar henüz elde olmadığından tüketilemez
+		var lotlar []models.Envanter
+		err := tx.Scopes(acikLotlar, s.portfoyKapsami).
+			Where("kod = ? AND alis_tarihi < ?", kod, cikisGunuSonu(tarih)).
+			Order(siralama).Find(&lotlar).Error
+		if err != nil {
+			return fmt.Errorf("lotlar getirilemedi: %w", err)
+		}
+		// Bir sahibin satışı başka bir sahibin lotlarını tüketmemeli
+		for _, lot := range lotlar {
+			if lot.PortfoyID != lotlar[0].PortfoyID {
+				return fmt.Errorf("%s birden fazla portföyde var; koda göre çıkış için bir portföy seçin", kod)
+			}
+		}
+
+		toplamMiktar := decimal.Zero
+		for _, lot := range lotlar {
+			toplamMiktar = toplamMiktar.Add(lot.Miktar)
+		}
+		if miktar.GreaterThan(toplamMiktar) {
+			return fmt.Errorf("çıkış miktarı (%s) %s için %s tarihinde eldeki miktardan (%s) fazla olamaz",
+				miktar, kod, tarih.Format("02.01.2006"), toplamMiktar)
+		}
+
+		var paylar []decimal.Decimal
+		if yontem == models.YontemOrtalama {
+			paylar = ortalamaPaylari(miktar, toplamMiktar, lotlar)
+		} else {
+			paylar = siraliPaylar(miktar, lotlar)
+		}
+
+		for i := range lotlar {
+			if !paylar[i].IsPositive() {
+				continue
+			}
+			islem, err := lottanCikisYap(tx, &lotlar[i], paylar[i], params)
+			if err != nil {
+				return err
+			}
+			islemler = append(islemler, islem)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("çıkış işlemi kaydedilemedi: %w", err)
+	}
+
+	karZarar := decimal.Zero
+	for _, islem := range islemler {
+		karZarar = karZarar.Add(islem.GerceklesenKarZarar)
+	}
+	logger.Infof("%s işlemi kaydedildi (%s): %s %s, %d lot, gerçekleşen kar/zarar %s",
+		models.IslemTipleri[tip], models.MaliyetYontemiIsmi(yontem), miktar, kod, len(islemler), karZarar.StringFixed(2))
+	database.DegisiklikSonrasi("cikis")
+	return islemler, nil
+}
+
+// siraliPaylar FIFO/LIFO için lotları verilen sırayla tüketir; her lottan çıkacak miktarı döner
+func siraliPaylar(miktar decimal.Decimal, lotlar []models.Envanter) []decimal.Decimal {
+	paylar := make([]decimal.Decimal, len(lotlar))
+	kalan := miktar
+	for i, lot := range lotlar {
+		if !kalan.IsPositive() {
+			break
+		}
+		paylar[i] = decimal.Min(kalan, lot.Miktar)
+		kalan = kalan.Sub(paylar[i])
+	}
+	return paylar
+}
+
+// ortalamaPaylari ağırlıklı ortalamada miktarı lotlara kalan miktarlarıyla orantılı dağıtır.
+// Paylar aşağı yuvarlanır, artık kapasitesi olan lotlara sırayla eklenir; böylece paylar
+// toplamı tam olarak çıkış miktarına eşit olur ve hiçbir lot eksiye düşmez.
+func ortalamaPaylari(miktar, toplamMiktar decimal.Decimal, lotlar []models.Envanter) []decimal.Decimal {
+	paylar := make([]decimal.Decimal, len(lotlar))
+	dagitilan := decimal.Zero
+	for i, lot := range lotlar {
+		paylar[i] = miktar.Mul(lot.Miktar).Div(toplamMiktar).Truncate(miktarHassasiyeti)
+		dagitilan = dagitilan.Add(paylar[i])
+	}
+
+	artik := miktar.Sub(dagitilan)
+	for i, lot := range lotlar {
+		if !artik.IsPositive() {
+			break
+		}
+		ek := decimal.Min(artik, lot.Miktar.Sub(paylar[i]))
+		paylar[i] = paylar[i].Add(ek)
+		artik = artik.Sub(ek)
+	}
+	return paylar
+}
+
+// cikisDogrula çıkış parametrelerini kontrol eder
+func cikisDogrula(tip string, miktar, birimFiyat decimal.Decimal) error {
+	if tip != models.IslemSatis && tip != models.IslemHediyeCikis {
+		return fmt.Errorf("geçersiz çıkış tipi: %s", tip)
+	}
+	if !miktar.IsPositive() {
+		return fmt.Errorf("çıkış miktarı sıfırdan büyük olmalı")
+	}
+	if tip == models.IslemSatis && !birimFiyat.IsPositive() {
+		return fmt.Errorf("satış fiyatı sıfırdan büyük olmalı")
+	}
+	return nil
+}
+
+// lottanCikisYap tek bir lottan çıkış işlemini kaydeder ve lotun kalan miktarını azaltır
+func lottanCikisYap(tx *gorm.DB, envanter *models.Envanter, miktar decimal.Decimal, params cikisParametreleri) (models.Islem, error) {
+	islem := models.Islem{
+		EnvanterID: envanter.ID,
+		Kod:        envanter.Kod,
+		Tip:        params.tip,
+		Tarih:      params.tarih,
+		Miktar:     miktar,
+		Maliyet:    miktar.Mul(envanter.AlisFiyati),
+		Referans:   params.referans,
+		Yontem:     params.yontem,
+		Notlar:     params.notlar,
+	}
+	// Hediye çıkışında gelir olmadığından kar/zarar gerçekleşmez
+	if params.tip == models.IslemSatis {
+		islem.BirimFiyat = params.birimFiyat
+		islem.Tutar = miktar.Mul(params.birimFiyat)
+		islem.GerceklesenKarZarar = islem.Tutar.Sub(islem.Maliyet)
+	}
+	if err := tx.Create(&islem).Error; err != nil {
+		return islem, err
+	}
+
+	onceki := *envanter
+	envanter.Miktar = envanter.Miktar.Sub(miktar)
+	envanter.ToplamAlis = envanter.Miktar.Mul(envanter.AlisFiyati)
+	envanter.GuncelDegerleriHesapla()
+	if err := tx.Save(envanter).Error; err != nil {
+		return islem, err
+	}
+	return islem, degisiklikKaydet(tx, models.DegisiklikCikis, &onceki, envanter)
+}
+
+// yeniCikisReferansi aynı çıkışa ait işlemleri bağlayan benzersiz referans üretir
+func yeniCikisReferansi() string {
+	return fmt.Sprintf("CKS-%d", time.Now().UnixNano())
+}
+
+// GetIslemler bir lota ait tüm işlemleri tarih sırasıyla getirir
+func (s *EnvanterService) GetIslemler(envanterID uint) ([]models.Islem, error) {
+	var islemler []models.Islem
+	err := database.GetDB().Where("envanter_id = ?", envanterID).Order("tarih asc, id asc").Find(&islemler).Error
+	if err != nil {
+		return nil, fmt.Errorf("işlemler getirilemedi: %w", err)
+	}
+	return islemler, nil
+}
+
+// GetAllIslemler tüm işlemleri tarih sırasıyla getirir
+func (s *EnvanterService) GetAllIslemler() ([]models.Islem, error) {
+	var islemler []models.Islem
+	err := database.GetDB().Scopes(s.islemPortfoyKapsami).Order("tarih asc, id asc").Find(&islemler).Error
+	if err != nil {
+		return nil, fmt.Errorf("işlemler getirilemedi: %w", err)
+	}
+	return islemler, nil
+}
+
+// getGerceklesenKarZararlar satışlardan gerçekleşen kar/zararı kod bazında toplar.
+// Tutarlar metin olarak saklandığından toplama SQL'de değil decimal ile yapılır.
+// Satışlar lot çöp kutusunda olsa da sayılır; satış geçmişi olan lotlar kalıcı olarak silinemediğinden
+// (bkz. PurgeEnvanter) gerçekleşen kar/zarar silme veya boşaltmayla değişmez.
+func (s *EnvanterService) getGerceklesenKarZararlar() ([]KodGerceklesen, error) {
+	var satirlar []struct {
+		Kod      string
+		Tur      string
+		Cins     string
+		Birim    string
+		KarZarar decimal.Decimal
+	}
+	err := database.GetDB().Table("islemler").
+		Select("islemler.kod AS kod, envanter.tur AS tur, envanter.cins AS cins, envanter.birim AS birim, islemler.gerceklesen_kar_zarar AS kar_zarar").
+		Joins("JOIN envanter ON envanter.id = islemler.envanter_id").
+		Where("islemler.tip = ? AND islemler.deleted_at IS NULL", models.IslemSatis).
+		Scopes(s.islemPortfoyKapsami).
+		Order("islemler.kod asc").
+		Scan(&satirlar).Error
+	if err != nil {
+		return nil, fmt.Errorf("gerçekleşen kar/zarar hesaplanamadı: %w", err)
+	}
+
+	var sonuc []KodGerceklesen
+	indeksler := make(map[string]int)
+	for _, satir := range satirlar {
+		i, exists := indeksler[satir.Kod]
+		if !exists {
+			i = len(sonuc)
+			indeksler[satir.Kod] = i
+			sonuc = append(sonuc, KodGerceklesen{Kod: satir.Kod, Tur: satir.Tur, Cins: satir.Cins, Birim: satir.Birim})
+		}
+		sonuc[i].KarZarar = sonuc[i].KarZarar.Add(satir.KarZarar)
+	}
+	return sonuc, nil
+}
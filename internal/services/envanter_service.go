@@ -2,29 +2,137 @@ package services
 
 import (
 	"fmt"
-	"log"
+	"strings"
+	"sync"
+	"time"
 
 	"altintakip/internal/database"
+	"altintakip/internal/logger"
 	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // EnvanterService envanter işlemlerini yönetir
 type EnvanterService struct {
-	altinService *AltinKaynakService
+	fiyatSaglayici PriceProvider
+	degerlemeModu  string
+	portfoyID      uint // 0: tüm portföyler
+
+	// Son fiyat güncellemesinde kullanılan görüntü (arka plan güncellemesi ve arayüz birlikte erişir)
+	sonFiyatlarMu sync.Mutex
+	sonFiyatlar   *AltinFiyatlari
 }
 
 // NewEnvanterService yeni envanter servisi oluşturur
 func NewEnvanterService() *EnvanterService {
+	return NewEnvanterServiceWithProvider(NewConfiguredPriceProvider())
+}
+
+// NewEnvanterServiceWithProvider belirtilen fiyat sağlayıcısı ile envanter servisi oluşturur
+func NewEnvanterServiceWithProvider(provider PriceProvider) *EnvanterService {
 	return &EnvanterService{
-		altinService: NewAltinKaynakService(),
+		fiyatSaglayici: provider,
+		degerlemeModu:  DegerlemeModu(),
+	}
+}
+
+// GetDegerlemeModu servisin kullandığı değerleme modunu döner
+func (s *EnvanterService) GetDegerlemeModu() string {
+	return s.degerlemeModu
+}
+
+// SetPortfoy sorguları ve koda göre çıkışları tek bir portföyle sınırlar (0: tüm portföyler).
+// Fiyat güncellemesi her zaman tüm lotlara uygulanır.
+func (s *EnvanterService) SetPortfoy(portfoyID uint) {
+	s.portfoyID = portfoyID
+}
+
+// GetPortfoy servisin sınırlandığı portföyü döner (0: tüm portföyler)
+func (s *EnvanterService) GetPortfoy() uint {
+	return s.portfoyID
+}
+
+// portfoyKapsami envanter sorgusunu seçili portföyle sınırlar
+func (s *EnvanterService) portfoyKapsami(db *gorm.DB) *gorm.DB {
+	if s.portfoyID == 0 {
+		return db
+	}
+	return db.Where("portfoy_id = ?", s.portfoyID)
+}
+
+// islemPortfoyKapsami işlem sorgusunu seçili portföyün lotlarına ait işlemlerle sınırlar
+func (s *EnvanterService) islemPortfoyKapsami(db *gorm.DB) *gorm.DB {
+	if s.portfoyID == 0 {
+		return db
+	}
+	return db.Where("envanter_id IN (SELECT id FROM envanter WHERE portfoy_id = ?)", s.portfoyID)
+}
+
+// yeniLotPortfoyu portföyü belirtilmemiş yeni lotun ekleneceği portföy
+func (s *EnvanterService) yeniLotPortfoyu() uint {
+	if s.portfoyID != 0 {
+		return s.portfoyID
+	}
+	return models.VarsayilanPortfoyID
+}
+
+// GetFiyatlar aktif sağlayıcıdan anlık fiyat görüntüsünü çeker (önbellek süresi dolmadıysa son görüntüyü döner)
+func (s *EnvanterService) GetFiyatlar() (*AltinFiyatlari, error) {
+	return s.fiyatSaglayici.GetFiyatlar()
+}
+
+// SonFiyatlar son fiyat güncellemesinde kullanılan görüntüyü döner; bu servisle henüz güncelleme
+// yapılmadıysa (liste modu, açılışta API hatası) önbellekteki son başarılı fiyatlara bakılır.
+// Hiç fiyat çekilmemişse nil döner.
+func (s *EnvanterService) SonFiyatlar() *AltinFiyatlari {
+	s.sonFiyatlarMu.Lock()
+	fiyatlar := s.sonFiyatlar
+	s.sonFiyatlarMu.Unlock()
+	if fiyatlar != nil {
+		return fiyatlar
+	}
+
+	fiyatlar, err := SonIyiFiyatlar()
+	if err != nil {
+		logger.Warnf("%v", err)
 	}
+	return fiyatlar
+}
+
+// GetAlisSatis fiyat görüntüsünde bir kodun alış ve satış fiyatını döner
+func (s *EnvanterService) GetAlisSatis(fiyatlar *AltinFiyatlari, kod string) (float64, float64, error) {
+	return s.fiyatSaglayici.GetAlisSatis(fiyatlar, kod)
+}
+
+// fiyatUygula fiyat görüntüsündeki alış/satış fiyatlarını ve sağlayıcı adını envantere yazar,
+// güncel fiyatı değerleme moduna göre belirler
+func (s *EnvanterService) fiyatUygula(envanter *models.Envanter, fiyatlar *AltinFiyatlari) error {
+	alis, satis, err := s.fiyatSaglayici.GetAlisSatis(fiyatlar, envanter.Kod)
+	if err != nil {
+		return err
+	}
+
+	// Sağlayıcı fiyatları float döner; en kısa ondalık gösterimiyle decimal'a çevrilir
+	envanter.GuncelAlisFiyati = decimal.NewFromFloat(alis)
+	envanter.GuncelSatisFiyati = decimal.NewFromFloat(satis)
+	envanter.GuncelFiyat = DegerlemeFiyati(envanter.GuncelAlisFiyati, envanter.GuncelSatisFiyati, s.degerlemeModu)
+	envanter.APIKaynak = s.fiyatSaglayici.Name()
+	return nil
+}
+
+// acikLotlar tamamı satılmamış (kalan miktarı olan) envanter kayıtlarını seçer.
+// Miktar metin olarak saklandığından karşılaştırma sayısal değere çevrilerek yapılır.
+func acikLotlar(db *gorm.DB) *gorm.DB {
+	return db.Where("CAST(miktar AS REAL) > 0")
 }
 
 // GetAllEnvanter tüm envanter kayıtlarını getirir
 func (s *EnvanterService) GetAllEnvanter() ([]models.Envanter, error) {
 	var envanter []models.Envanter
 
-	err := database.GetDB().Order("tur asc, alis_tarihi asc").Find(&envanter).Error
+	err := database.GetDB().Scopes(acikLotlar, s.portfoyKapsami).Order("tur asc, alis_tarihi asc").Find(&envanter).Error
 	if err != nil {
 		return nil, fmt.Errorf("envanter kayıtları getirilemedi: %w", err)
 	}
@@ -32,45 +140,71 @@ func (s *EnvanterService) GetAllEnvanter() ([]models.Envanter, error) {
 	return envanter, nil
 }
 
-// UpdateGuncelFiyatlar tüm envanter için güncel fiyatları günceller
+// UpdateGuncelFiyatlar güncel fiyatları çeker ve tüm envantere uygular
 func (s *EnvanterService) UpdateGuncelFiyatlar() error {
-	log.Println("Güncel fiyatlar alınıyor...")
+	fiyatlar, err := s.GuncelFiyatlariCek()
+	if err != nil {
+		return err
+	}
+	return s.GuncelFiyatlariUygula(fiyatlar)
+}
+
+// GuncelFiyatlariCek önbelleği atlayarak sağlayıcıdan güncel fiyatları çeker; veritabanına dokunmaz.
+// Yeniden denemelerle uzun sürebildiğinden veritabanı kilidi tutulmadan çağrılmalıdır.
+func (s *EnvanterService) GuncelFiyatlariCek() (*AltinFiyatlari, error) {
+	logger.Infof("Güncel fiyatlar alınıyor...")
 
-	// API'den güncel fiyatları al
-	fiyatlar, err := s.altinService.GetFiyatlar()
+	fiyatlar, err := tazeFiyatlar(s.fiyatSaglayici)
 	if err != nil {
-		return fmt.Errorf("fiyatlar alınamadı: %w", err)
+		return nil, fmt.Errorf("fiyatlar alınamadı: %w", err)
 	}
+	return fiyatlar, nil
+}
+
+// GuncelFiyatlariUygula çekilmiş fiyat görüntüsünü fiyat geçmişine, tüm açık lotlara ve günlük portföy özetine yazar
+func (s *EnvanterService) GuncelFiyatlariUygula(fiyatlar *AltinFiyatlari) error {
+	logger.Infof("Fiyatlar başarıyla alındı (%s). Güncelleme tarihi: %s", s.fiyatSaglayici.Name(), fiyatlar.GuncellemeTarihi.Format("2006-01-02 15:04:05"))
+	if len(fiyatlar.Eksikler) > 0 {
+		logger.Warnf("Kısmi fiyat güncellemesi: %s fiyatları alınamadı, son başarılı fiyatlar kullanılıyor", strings.Join(fiyatlar.Eksikler, ", "))
+	}
+	s.sonFiyatlarMu.Lock()
+	s.sonFiyatlar = fiyatlar
+	s.sonFiyatlarMu.Unlock()
 
-	log.Printf("Fiyatlar başarıyla alındı. Güncelleme tarihi: %s", fiyatlar.GuncellemeTarihi.Format("2006-01-02 15:04:05"))
+	// Çekilen fiyatları geçmişe yaz (sadece değişenler; önbellekten tamamlanan gruplar yazılmaz)
+	if _, err := NewFiyatGecmisiService().SaveFiyatlar(fiyatlar.Taze(), s.fiyatSaglayici.Name()); err != nil {
+		logger.Errorf("Fiyat geçmişi kaydedilemedi: %v", err)
+	}
 
 	// Tüm envanter kayıtlarını al
 	var envanterler []models.Envanter
-	err = database.GetDB().Find(&envanterler).Error
+	err := database.GetDB().Scopes(acikLotlar).Find(&envanterler).Error
 	if err != nil {
 		return fmt.Errorf("envanter kayıtları getirilemedi: %w", err)
 	}
 
 	// Her envanter için güncel fiyatı güncelle
 	for i := range envanterler {
-		guncelFiyat, err := s.altinService.GetFiyatByType(fiyatlar, envanterler[i].Kod)
-		if err != nil {
-			log.Printf("UYARI: Kod %s için fiyat bulunamadı: %v", envanterler[i].Kod, err)
+		if err := s.fiyatUygula(&envanterler[i], fiyatlar); err != nil {
+			logger.Warnf("Kod %s için fiyat bulunamadı: %v", envanterler[i].Kod, err)
 			continue
 		}
 
-		envanterler[i].GuncelFiyat = guncelFiyat
-		//envanterler[i].APIKaynak = "data.altinkaynak.com"
 		envanterler[i].GuncelDegerleriHesapla()
 
 		// Veritabanında güncelle
 		err = database.GetDB().Save(&envanterler[i]).Error
 		if err != nil {
-			log.Printf("HATA: Envanter ID %d güncellenemedi: %v", envanterler[i].ID, err)
+			logger.Errorf("Envanter ID %d güncellenemedi: %v", envanterler[i].ID, err)
 		}
 	}
 
-	log.Printf("Toplam %d envanter kaydı güncellendi", len(envanterler))
+	logger.Infof("Toplam %d envanter kaydı güncellendi", len(envanterler))
+
+	// Günün portföy özetini yaz (gün içinde her güncellemede yenilenir)
+	if err := NewPortfoyGecmisiService().SaveGunlukOzet(time.Now(), envanterler); err != nil {
+		logger.Errorf("Günlük portföy özeti yazılamadı: %v", err)
+	}
 	return nil
 }
 
@@ -81,47 +215,192 @@ func (s *EnvanterService) AddEnvanter(envanter *models.Envanter) error {
 
 // AddEnvanterWithMode yeni envanter kaydı ekler (liste modu parametreli)
 func (s *EnvanterService) AddEnvanterWithMode(envanter *models.Envanter, isListMode bool) error {
+	return s.AddEnvanterWithFiyatlar(envanter, s.kayitFiyatlari(envanter, isListMode))
+}
+
+// kayitFiyatlari eklenen/düzenlenen lotun güncel fiyatı girilmemişse fiyat görüntüsünü çeker;
+// liste modunda, fiyat girilmişse veya fiyatlar alınamazsa nil döner
+func (s *EnvanterService) kayitFiyatlari(envanter *models.Envanter, isListMode bool) *AltinFiyatlari {
+	if isListMode {
+		logger.Debugf("Liste modu: Güncel fiyat API'den çekilmeyecek")
+		return nil
+	}
+	if !envanter.GuncelFiyat.IsZero() {
+		return nil
+	}
+	fiyatlar, err := s.fiyatSaglayici.GetFiyatlar()
+	if err != nil {
+		logger.Warnf("Güncel fiyat alınamadı, sadece alış bilgileri kaydediliyor: %v", err)
+		return nil
+	}
+	return fiyatlar
+}
+
+// guncelFiyatiDoldur güncel fiyatı girilmemiş lota verilen görüntüden fiyat yazar (fiyatlar nil olabilir)
+func (s *EnvanterService) guncelFiyatiDoldur(envanter *models.Envanter, fiyatlar *AltinFiyatlari) {
+	if fiyatlar == nil || !envanter.GuncelFiyat.IsZero() {
+		return
+	}
+	if err := s.fiyatUygula(envanter, fiyatlar); err != nil {
+		logger.Warnf("Kod %s için güncel fiyat bulunamadı: %v", envanter.Kod, err)
+		return
+	}
+	logger.Debugf("Güncel fiyat API'den çekildi: %s = %s", envanter.Kod, envanter.GuncelFiyat.StringFixed(2))
+}
+
+// AddEnvanterWithFiyatlar yeni envanter kaydını önceden çekilmiş fiyat görüntüsüyle ekler; fiyat
+// sağlayıcısına gitmez. API sunucusu fiyatları yazma kilidini almadan önce çekmek için kullanır.
+func (s *EnvanterService) AddEnvanterWithFiyatlar(envanter *models.Envanter, fiyatlar *AltinFiyatlari) error {
 	// Toplam alış tutarını hesapla
-	envanter.ToplamAlis = envanter.Miktar * envanter.AlisFiyati
+	envanter.ToplamAlis = envanter.Miktar.Mul(envanter.AlisFiyati)
+	s.guncelFiyatiDoldur(envanter, fiyatlar)
+
+	if envanter.PortfoyID == 0 {
+		envanter.PortfoyID = s.yeniLotPortfoyu()
+	}
 
-	// Liste modunda değilse ve güncel fiyat girilmemişse (0 ise) API'den çek
-	if !isListMode && envanter.GuncelFiyat == 0 {
-		fiyatlar, err := s.altinService.GetFiyatlar()
+	// Lot ve giriş işlemi birlikte kaydedilir
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := lotKaydet(tx, envanter); err != nil {
+			return err
+		}
+		return degisiklikKaydet(tx, models.DegisiklikEkleme, nil, envanter)
+	})
+	if err != nil {
+		return fmt.Errorf("envanter kaydedilemedi: %w", err)
+	}
+
+	logger.Infof("Yeni envanter kaydı eklendi: %s %s (%s %s)", envanter.Tur, envanter.Cins, envanter.Miktar, envanter.Birim)
+	database.DegisiklikSonrasi("ekleme")
+	return nil
+}
+
+// AddEnvanterlerWithMode birden fazla envanter kaydını tek işlemde ekler; biri başarısız olursa hiçbiri eklenmez
+func (s *EnvanterService) AddEnvanterlerWithMode(envanterler []*models.Envanter, isListMode bool) error {
+	// Fiyatlar tüm kayıtlar için bir kez çekilir
+	var fiyatlar *AltinFiyatlari
+	if !isListMode {
+		var err error
+		fiyatlar, err = s.fiyatSaglayici.GetFiyatlar()
 		if err != nil {
-			log.Printf("UYARI: Güncel fiyat alınamadı, sadece alış bilgileri kaydediliyor: %v", err)
-		} else {
-			guncelFiyat, err := s.altinService.GetFiyatByType(fiyatlar, envanter.Kod)
-			if err == nil {
-				envanter.GuncelFiyat = guncelFiyat
-				log.Printf("Güncel fiyat API'den çekildi: %s = %.2f", envanter.Kod, guncelFiyat)
-			} else {
-				log.Printf("UYARI: Kod %s için güncel fiyat bulunamadı: %v", envanter.Kod, err)
+			logger.Warnf("Güncel fiyat alınamadı, sadece alış bilgileri kaydediliyor: %v", err)
+		}
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, envanter := range envanterler {
+			envanter.ToplamAlis = envanter.Miktar.Mul(envanter.AlisFiyati)
+			if envanter.PortfoyID == 0 {
+				envanter.PortfoyID = s.yeniLotPortfoyu()
+			}
+			s.guncelFiyatiDoldur(envanter, fiyatlar)
+			if err := lotKaydet(tx, envanter); err != nil {
+				return fmt.Errorf("%s %s: %w", envanter.Kod, envanter.AlisTarihi.Format("02.01.2006"), err)
+			}
+			if err := degisiklikKaydet(tx, models.DegisiklikIceAktarim, nil, envanter); err != nil {
+				return err
 			}
 		}
-	} else if isListMode {
-		log.Printf("Liste modu: Güncel fiyat API'den çekilmeyecek")
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("envanter kayıtları kaydedilemedi: %w", err)
 	}
 
+	logger.Infof("%d envanter kaydı toplu olarak eklendi", len(envanterler))
+	database.DegisiklikSonrasi("ice-aktarim")
+	return nil
+}
+
+// lotKaydet lotu güncel değerleriyle ve giriş işlemiyle birlikte verilen işlem içinde kaydeder
+func lotKaydet(tx *gorm.DB, envanter *models.Envanter) error {
 	// Güncel değerleri hesapla
 	envanter.GuncelDegerleriHesapla()
 
-	err := database.GetDB().Create(envanter).Error
+	if envanter.GirisTipi == "" {
+		envanter.GirisTipi = models.IslemAlis
+	}
+	if envanter.GirisTipi != models.IslemAlis && envanter.GirisTipi != models.IslemHediyeGiris {
+		return fmt.Errorf("geçersiz giriş tipi: %s", envanter.GirisTipi)
+	}
+	if err := portfoyVarMi(tx, envanter.PortfoyID); err != nil {
+		return err
+	}
+
+	if err := tx.Create(envanter).Error; err != nil {
+		return err
+	}
+	islem := envanter.AcilisIslemi(decimal.Zero)
+	return tx.Create(&islem).Error
+}
+
+// acilisIslemiEsitle düzenlenen lotun giriş işlemini lotun miktar, fiyat ve tarihiyle eşitler;
+// giriş işlemi olmayan eski lotlar için oluşturur
+func acilisIslemiEsitle(tx *gorm.DB, envanter *models.Envanter) error {
+	var cikislar []models.Islem
+	if err := tx.Where("envanter_id = ? AND tip IN ?", envanter.ID, []string{models.IslemSatis, models.IslemHediyeCikis}).Find(&cikislar).Error; err != nil {
+		return fmt.Errorf("lotun çıkış işlemleri getirilemedi: %w", err)
+	}
+	cikan := decimal.Zero
+	for _, cikis := range cikislar {
+		cikan = cikan.Add(cikis.Miktar)
+	}
+	guncel := envanter.AcilisIslemi(cikan)
+
+	var girisler []models.Islem
+	if err := tx.Where("envanter_id = ? AND tip IN ?", envanter.ID, []string{models.IslemAlis, models.IslemHediyeGiris}).Order("id asc").Limit(1).Find(&girisler).Error; err != nil {
+		return fmt.Errorf("lotun giriş işlemi getirilemedi: %w", err)
+	}
+	if len(girisler) == 0 {
+		if err := tx.Create(&guncel).Error; err != nil {
+			return fmt.Errorf("lotun giriş işlemi oluşturulamadı: %w", err)
+		}
+		return nil
+	}
+	err := tx.Model(&girisler[0]).Updates(map[string]interface{}{
+		"kod":         guncel.Kod,
+		"tip":         guncel.Tip,
+		"tarih":       guncel.Tarih,
+		"miktar":      guncel.Miktar,
+		"birim_fiyat": guncel.BirimFiyat,
+		"tutar":       guncel.Tutar,
+	}).Error
 	if err != nil {
-		return fmt.Errorf("envanter kaydedilemedi: %w", err)
+		return fmt.Errorf("lotun giriş işlemi güncellenemedi: %w", err)
 	}
+	return nil
+}
 
-	log.Printf("Yeni envanter kaydı eklendi: %s %s (%.2f %s)", envanter.Tur, envanter.Cins, envanter.Miktar, envanter.Birim)
+// portfoyVarMi lotun atandığı portföyün mevcut olduğunu kontrol eder
+func portfoyVarMi(tx *gorm.DB, portfoyID uint) error {
+	var sayi int64
+	if err := tx.Model(&models.Portfoy{}).Where("id = ?", portfoyID).Count(&sayi).Error; err != nil {
+		return fmt.Errorf("portföy kontrol edilemedi: %w", err)
+	}
+	if sayi == 0 {
+		return fmt.Errorf("portföy bulunamadı: ID %d", portfoyID)
+	}
 	return nil
 }
 
-// DeleteEnvanter envanter kaydını siler
+// DeleteEnvanter envanter kaydını çöp kutusuna taşır (yumuşak silme); RestoreEnvanter ile geri alınabilir
 func (s *EnvanterService) DeleteEnvanter(id uint) error {
-	err := database.GetDB().Delete(&models.Envanter{}, id).Error
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var envanter models.Envanter
+		if err := tx.First(&envanter, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&envanter).Error; err != nil {
+			return err
+		}
+		return degisiklikKaydet(tx, models.DegisiklikSilme, &envanter, nil)
+	})
 	if err != nil {
 		return fmt.Errorf("envanter silinemedi: %w", err)
 	}
 
-	log.Printf("Envanter kaydı silindi: ID %d", id)
+	logger.Infof("Envanter kaydı silindi: ID %d", id)
+	database.DegisiklikSonrasi("silme")
 	return nil
 }
 
@@ -132,76 +411,123 @@ func (s *EnvanterService) UpdateEnvanter(envanter *models.Envanter) error {
 
 // UpdateEnvanterWithMode envanter kaydını günceller (liste modu parametreli)
 func (s *EnvanterService) UpdateEnvanterWithMode(envanter *models.Envanter, isListMode bool) error {
-	// Toplam alış tutarını yeniden hesapla
-	envanter.ToplamAlis = envanter.Miktar * envanter.AlisFiyati
+	return s.UpdateEnvanterWithFiyatlar(envanter, s.kayitFiyatlari(envanter, isListMode))
+}
 
-	// Liste modunda değilse ve güncel fiyat 0 ise API'den çek
-	if !isListMode && envanter.GuncelFiyat == 0 {
-		fiyatlar, err := s.altinService.GetFiyatlar()
-		if err != nil {
-			log.Printf("UYARI: Güncel fiyat alınamadı: %v", err)
-		} else {
-			guncelFiyat, err := s.altinService.GetFiyatByType(fiyatlar, envanter.Kod)
-			if err == nil {
-				envanter.GuncelFiyat = guncelFiyat
-				log.Printf("Güncel fiyat API'den çekildi: %s = %.2f", envanter.Kod, guncelFiyat)
-			} else {
-				log.Printf("UYARI: Kod %s için güncel fiyat bulunamadı: %v", envanter.Kod, err)
-			}
-		}
-	} else if isListMode {
-		log.Printf("Liste modu: Güncel fiyat API'den çekilmeyecek")
-	}
+// UpdateEnvanterWithFiyatlar envanter kaydını önceden çekilmiş fiyat görüntüsüyle günceller; fiyat sağlayıcısına gitmez
+func (s *EnvanterService) UpdateEnvanterWithFiyatlar(envanter *models.Envanter, fiyatlar *AltinFiyatlari) error {
+	// Toplam alış tutarını yeniden hesapla
+	envanter.ToplamAlis = envanter.Miktar.Mul(envanter.AlisFiyati)
+	s.guncelFiyatiDoldur(envanter, fiyatlar)
 
 	// Güncel değerleri hesapla
 	envanter.GuncelDegerleriHesapla()
 
-	err := database.GetDB().Save(envanter).Error
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Denetim kaydı için lotun değişiklik öncesi hali
+		var onceki models.Envanter
+		if err := tx.First(&onceki, envanter.ID).Error; err != nil {
+			return err
+		}
+		if err := portfoyVarMi(tx, envanter.PortfoyID); err != nil {
+			return err
+		}
+		if err := tx.Save(envanter).Error; err != nil {
+			return err
+		}
+		if err := acilisIslemiEsitle(tx, envanter); err != nil {
+			return err
+		}
+		return degisiklikKaydet(tx, models.DegisiklikDuzenleme, &onceki, envanter)
+	})
 	if err != nil {
 		return fmt.Errorf("envanter güncellenemedi: %w", err)
 	}
 
-	log.Printf("Envanter kaydı güncellendi: %s %s (%.2f %s)", envanter.Tur, envanter.Cins, envanter.Miktar, envanter.Birim)
+	logger.Infof("Envanter kaydı güncellendi: %s %s (%s %s)", envanter.Tur, envanter.Cins, envanter.Miktar, envanter.Birim)
+	database.DegisiklikSonrasi("duzenleme")
 	return nil
 }
 
-// GetToplamDegerler toplam değerleri hesaplar
-func (s *EnvanterService) GetToplamDegerler() (map[string]float64, error) {
+// GetToplamDegerler seçili portföyün (veya tüm portföylerin) toplam değerlerini hesaplar
+// (tüm toplamlar decimal ile, kuruş kaybı olmadan)
+func (s *EnvanterService) GetToplamDegerler() (map[string]decimal.Decimal, error) {
 	var envanterler []models.Envanter
-	err := database.GetDB().Find(&envanterler).Error
+	err := database.GetDB().Scopes(acikLotlar, s.portfoyKapsami).Find(&envanterler).Error
 	if err != nil {
 		return nil, fmt.Errorf("envanter kayıtları getirilemedi: %w", err)
 	}
 
-	toplamlar := map[string]float64{
-		"toplam_alis":   0,
-		"toplam_guncel": 0,
-		"toplam_kar":    0,
+	// Satışlardan gerçekleşen kar/zarar
+	gerceklesenler, err := s.getGerceklesenKarZararlar()
+	if err != nil {
+		return nil, err
 	}
+	return toplamDegerleriHesapla(envanterler, gerceklesenler), nil
+}
 
+// toplamDegerleriHesapla açık lotların ve gerçekleşen kar/zararların toplamlarını hesaplar
+func toplamDegerleriHesapla(envanterler []models.Envanter, gerceklesenler []KodGerceklesen) map[string]decimal.Decimal {
+	var toplamAlis, toplamGuncel, toplamKar decimal.Decimal
 	for _, envanter := range envanterler {
-		toplamlar["toplam_alis"] += envanter.ToplamAlis
-		toplamlar["toplam_guncel"] += envanter.GuncelTutar
-		toplamlar["toplam_kar"] += envanter.KarZarar
+		toplamAlis = toplamAlis.Add(envanter.ToplamAlis)
+		toplamGuncel = toplamGuncel.Add(envanter.GuncelTutar)
+		toplamKar = toplamKar.Add(envanter.KarZarar)
+	}
+
+	toplamlar := map[string]decimal.Decimal{
+		"toplam_alis":      toplamAlis,
+		"toplam_guncel":    toplamGuncel,
+		"toplam_kar":       toplamKar,
+		"toplam_kar_yuzde": models.YuzdeHesapla(toplamKar, toplamAlis),
 	}
 
-	// Toplam kar/zarar yüzdesi
-	if toplamlar["toplam_alis"] > 0 {
-		toplamlar["toplam_kar_yuzde"] = (toplamlar["toplam_kar"] / toplamlar["toplam_alis"]) * 100
+	toplamGerceklesen := decimal.Zero
+	for _, gerceklesen := range gerceklesenler {
+		toplamGerceklesen = toplamGerceklesen.Add(gerceklesen.KarZarar)
 	}
+	toplamlar["toplam_gerceklesen_kar"] = toplamGerceklesen
 
-	return toplamlar, nil
+	return toplamlar
 }
 
-// GetKodBazliGruplar kod bazlı gruplu verileri hesaplar
+// GetKodBazliGruplar seçili portföyün (veya tüm portföylerin) kod bazlı gruplu verilerini
+// hesaplar (tutar ve miktarlar decimal.Decimal)
 func (s *EnvanterService) GetKodBazliGruplar() (map[string]map[string]interface{}, error) {
 	var envanterler []models.Envanter
-	err := database.GetDB().Order("tur asc, cins asc").Find(&envanterler).Error
+	err := database.GetDB().Scopes(acikLotlar, s.portfoyKapsami).Order("tur asc, cins asc").Find(&envanterler).Error
 	if err != nil {
 		return nil, fmt.Errorf("envanter kayıtları getirilemedi: %w", err)
 	}
 
+	// Gerçekleşen kar/zararlar (tamamı satılmış kodlar da listelenir)
+	gerceklesenler, err := s.getGerceklesenKarZararlar()
+	if err != nil {
+		return nil, err
+	}
+	return kodBazliGruplariHesapla(envanterler, gerceklesenler), nil
+}
+
+// kodBazliGruplariHesapla açık lotları ve gerçekleşen kar/zararları koda göre gruplar
+func kodBazliGruplariHesapla(envanterler []models.Envanter, gerceklesenler []KodGerceklesen) map[string]map[string]interface{} {
 	gruplar := make(map[string]map[string]interface{})
+	yeniGrup := func(tur, cins, birim string) map[string]interface{} {
+		return map[string]interface{}{
+			"tur":                   tur,
+			"cins":                  cins,
+			"birim":                 birim,
+			"toplam_miktar":         decimal.Zero,
+			"toplam_alis_tutar":     decimal.Zero,
+			"toplam_guncel_tutar":   decimal.Zero,
+			"toplam_kar_zarar":      decimal.Zero,
+			"gerceklesen_kar_zarar": decimal.Zero,
+			"ortalama_alis_fiyati":  decimal.Zero,
+			"ortalama_kar_zarar":    decimal.Zero,
+			"kar_zarar_yuzde":       decimal.Zero,
+			"adet":                  0,
+			"api_kaynak":            "",
+		}
+	}
 
 	// Kod bazlı gruplama
 	for _, envanter := range envanterler {
@@ -211,56 +537,94 @@ func (s *EnvanterService) GetKodBazliGruplar() (map[string]map[string]interface{
 		}
 
 		if _, exists := gruplar[kod]; !exists {
-			gruplar[kod] = map[string]interface{}{
-				"tur":                 envanter.Tur,
-				"cins":                envanter.Cins,
-				"birim":               envanter.Birim,
-				"toplam_miktar":       0.0,
-				"toplam_alis_tutar":   0.0,
-				"toplam_guncel_tutar": 0.0,
-				"toplam_kar_zarar":    0.0,
-				"adet":                0,
-			}
+			gruplar[kod] = yeniGrup(envanter.Tur, envanter.Cins, envanter.Birim)
 		}
 
 		grup := gruplar[kod]
-		grup["toplam_miktar"] = grup["toplam_miktar"].(float64) + envanter.Miktar
-		grup["toplam_alis_tutar"] = grup["toplam_alis_tutar"].(float64) + envanter.ToplamAlis
-		grup["toplam_guncel_tutar"] = grup["toplam_guncel_tutar"].(float64) + envanter.GuncelTutar
-		grup["toplam_kar_zarar"] = grup["toplam_kar_zarar"].(float64) + envanter.KarZarar
+		grup["toplam_miktar"] = grup["toplam_miktar"].(decimal.Decimal).Add(envanter.Miktar)
+		grup["toplam_alis_tutar"] = grup["toplam_alis_tutar"].(decimal.Decimal).Add(envanter.ToplamAlis)
+		grup["toplam_guncel_tutar"] = grup["toplam_guncel_tutar"].(decimal.Decimal).Add(envanter.GuncelTutar)
+		grup["toplam_kar_zarar"] = grup["toplam_kar_zarar"].(decimal.Decimal).Add(envanter.KarZarar)
 		grup["adet"] = grup["adet"].(int) + 1
+		if grup["api_kaynak"] == "" {
+			grup["api_kaynak"] = envanter.APIKaynak
+		}
+	}
+
+	// Gerçekleşen kar/zararları ekle (tamamı satılmış kodlar da listelenir)
+	for _, gerceklesen := range gerceklesenler {
+		kod := gerceklesen.Kod
+		if kod == "" {
+			kod = "TANIMSIZ"
+		}
+		if _, exists := gruplar[kod]; !exists {
+			gruplar[kod] = yeniGrup(gerceklesen.Tur, gerceklesen.Cins, gerceklesen.Birim)
+		}
+		gruplar[kod]["gerceklesen_kar_zarar"] = gerceklesen.KarZarar
 	}
 
 	// Ortalama değerleri hesapla
 	for kod, grup := range gruplar {
 		adet := grup["adet"].(int)
 		if adet > 0 {
-			grup["ortalama_alis_fiyati"] = grup["toplam_alis_tutar"].(float64) / grup["toplam_miktar"].(float64)
-			grup["ortalama_kar_zarar"] = grup["toplam_kar_zarar"].(float64) / float64(adet)
+			toplamMiktar := grup["toplam_miktar"].(decimal.Decimal)
+			toplamAlis := grup["toplam_alis_tutar"].(decimal.Decimal)
+			toplamKarZarar := grup["toplam_kar_zarar"].(decimal.Decimal)
 
-			// Kar/zarar yüzdesi
-			if grup["toplam_alis_tutar"].(float64) > 0 {
-				grup["kar_zarar_yuzde"] = (grup["toplam_kar_zarar"].(float64) / grup["toplam_alis_tutar"].(float64)) * 100
-			} else {
-				grup["kar_zarar_yuzde"] = 0.0
+			// Ortalamalar gösterim içindir; bölme sonucu 4 ondalığa yuvarlanır
+			if toplamMiktar.IsPositive() {
+				grup["ortalama_alis_fiyati"] = toplamAlis.DivRound(toplamMiktar, 4)
 			}
+			grup["ortalama_kar_zarar"] = toplamKarZarar.DivRound(decimal.NewFromInt(int64(adet)), 4)
+
+			// Kar/zarar yüzdesi
+			grup["kar_zarar_yuzde"] = models.YuzdeHesapla(toplamKarZarar, toplamAlis)
 		}
+		// Açık lotlardaki kar/zarar henüz gerçekleşmemiştir
+		grup["gerceklesmemis_kar_zarar"] = grup["toplam_kar_zarar"]
 		gruplar[kod] = grup
 	}
 
-	return gruplar, nil
+	return gruplar
+}
+
+// EnvanterGorunumu tek bir yenilemede okunan açık lotlar ile bunlardan hesaplanan kod bazlı gruplar
+// ve toplamlar; envanter, grup ve özet tabloları aynı görüntüden çizilir
+type EnvanterGorunumu struct {
+	Envanterler []models.Envanter
+	Gruplar     map[string]map[string]interface{}
+	Toplamlar   map[string]decimal.Decimal
+}
+
+// GetGorunum seçili portföyün açık lotlarını ve gerçekleşen kar/zararlarını birer sorguyla okuyup
+// grupları ve toplamları bellekte hesaplar (API çağrısı yapmaz)
+func (s *EnvanterService) GetGorunum() (*EnvanterGorunumu, error) {
+	envanterler, err := s.GetAllEnvanterFromDB()
+	if err != nil {
+		return nil, err
+	}
+	gerceklesenler, err := s.getGerceklesenKarZararlar()
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnvanterGorunumu{
+		Envanterler: envanterler,
+		Gruplar:     kodBazliGruplariHesapla(envanterler, gerceklesenler),
+		Toplamlar:   toplamDegerleriHesapla(envanterler, gerceklesenler),
+	}, nil
 }
 
 // GetAllEnvanterFromDB sadece veritabanından envanter kayıtlarını getirir (API çağrısı yapmaz)
 func (s *EnvanterService) GetAllEnvanterFromDB() ([]models.Envanter, error) {
 	var envanter []models.Envanter
 
-	err := database.GetDB().Order("tur asc, alis_tarihi asc").Find(&envanter).Error
+	err := database.GetDB().Scopes(acikLotlar, s.portfoyKapsami).Order("tur asc, alis_tarihi asc").Find(&envanter).Error
 	if err != nil {
 		return nil, fmt.Errorf("envanter kayıtları getirilemedi: %w", err)
 	}
 
-	log.Printf("Veritabanından %d envanter kaydı alındı", len(envanter))
+	logger.Debugf("Veritabanından %d envanter kaydı alındı", len(envanter))
 	return envanter, nil
 }
 
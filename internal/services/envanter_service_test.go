@@ -0,0 +1,474 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// testVeritabani geçici dizinde migrasyonları uygulanmış boş bir veritabanı açar
+func testVeritabani(t *testing.T) {
+	t.Helper()
+	dizin := t.TempDir()
+	t.Setenv("APP_DATA_DIR", dizin)
+	t.Setenv("DB_PATH", filepath.Join(dizin, "test.db"))
+	t.Setenv("BACKUP_RETENTION", "0")
+	t.Setenv("VALUATION_MODE", "")
+
+	if err := database.Connect(); err != nil {
+		t.Fatalf("veritabanı açılamadı: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("migrasyon: %v", err)
+	}
+}
+
+// testLotuEkle fiyat çekmeden yeni lot ekler
+func testLotuEkle(t *testing.T, s *EnvanterService, portfoyID uint, tur, kod, birim, miktar, alisFiyati string) *models.Envanter {
+	t.Helper()
+	envanter := &models.Envanter{
+		PortfoyID:  portfoyID,
+		Tur:        tur,
+		Cins:       kod,
+		Kod:        kod,
+		Birim:      birim,
+		Miktar:     decimal.RequireFromString(miktar),
+		AlisFiyati: decimal.RequireFromString(alisFiyati),
+		AlisTarihi: time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local),
+	}
+	if err := s.AddEnvanterWithMode(envanter, true); err != nil {
+		t.Fatalf("lot eklenemedi: %v", err)
+	}
+	return envanter
+}
+
+// decimalKontrol map alanının beklenen decimal değere eşit olduğunu kontrol eder
+func decimalKontrol(t *testing.T, etiket string, got interface{}, want string) {
+	t.Helper()
+	d, ok := got.(decimal.Decimal)
+	if !ok {
+		t.Errorf("%s decimal değil: %#v", etiket, got)
+		return
+	}
+	if !d.Equal(decimal.RequireFromString(want)) {
+		t.Errorf("%s = %s, beklenen %s", etiket, d, want)
+	}
+}
+
+func TestUpdateGuncelFiyatlar(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+
+	ceyrek := testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+	dolar := testLotuEkle(t, s, 0, "Döviz", "USD", "adet", "1000", "40")
+	bilinmeyen := testLotuEkle(t, s, 0, "Altın", "XAU", "gram", "1", "3000")
+
+	if err := s.UpdateGuncelFiyatlar(); err != nil {
+		t.Fatalf("UpdateGuncelFiyatlar: %v", err)
+	}
+
+	got, err := s.GetEnvanterByID(ceyrek.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimalKontrol(t, "C güncel alış", got.GuncelAlisFiyati, "7020")
+	decimalKontrol(t, "C güncel satış", got.GuncelSatisFiyati, "7180")
+	decimalKontrol(t, "C güncel fiyat", got.GuncelFiyat, "7020")
+	decimalKontrol(t, "C güncel tutar", got.GuncelTutar, "14040")
+	decimalKontrol(t, "C kar/zarar", got.KarZarar, "1040")
+	decimalKontrol(t, "C kar/zarar yüzde", got.KarZararYuzde, "8")
+
+	got, err = s.GetEnvanterByID(dolar.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimalKontrol(t, "USD güncel tutar", got.GuncelTutar, "41652")
+	decimalKontrol(t, "USD kar/zarar", got.KarZarar, "1652")
+	if got.APIKaynak != "fixture" {
+		t.Errorf("USD fiyat kaynağı = %q, beklenen fixture", got.APIKaynak)
+	}
+
+	// API'de olmayan kod güncellenmeden kalır
+	got, err = s.GetEnvanterByID(bilinmeyen.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimalKontrol(t, "XAU güncel fiyat", got.GuncelFiyat, "0")
+	if got.APIKaynak != "" {
+		t.Errorf("fiyatı bulunamayan lotun kaynağı = %q", got.APIKaynak)
+	}
+
+	// Fiyatlar geçmişe kaynağıyla yazılır
+	sonFiyatlar, err := NewFiyatGecmisiService().GetSonFiyatlar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sonFiyatlar) != 6 || sonFiyatlar["HH"].Alis != 4342.49 || sonFiyatlar["HH"].Kaynak != "fixture" {
+		t.Errorf("fiyat geçmişi beklenmedik: %d kod, HH = %+v", len(sonFiyatlar), sonFiyatlar["HH"])
+	}
+
+	// Değişmeyen fiyatlar ikinci güncellemede tekrar yazılmaz
+	if err := s.UpdateGuncelFiyatlar(); err != nil {
+		t.Fatalf("ikinci UpdateGuncelFiyatlar: %v", err)
+	}
+	var kayitSayisi int64
+	database.GetDB().Model(&models.FiyatGecmisi{}).Count(&kayitSayisi)
+	if kayitSayisi != 6 {
+		t.Errorf("fiyat geçmişi kayıt sayısı = %d, beklenen 6", kayitSayisi)
+	}
+}
+
+func TestUpdateGuncelFiyatlarDegerlemeModu(t *testing.T) {
+	testVeritabani(t)
+	t.Setenv("VALUATION_MODE", DegerlemeOrta)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+
+	lot := testLotuEkle(t, s, 0, "Altın", "GA", "gram", "10", "4000")
+	if err := s.UpdateGuncelFiyatlar(); err != nil {
+		t.Fatalf("UpdateGuncelFiyatlar: %v", err)
+	}
+
+	got, err := s.GetEnvanterByID(lot.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimalKontrol(t, "GA orta fiyat", got.GuncelFiyat, "4358.5")
+	decimalKontrol(t, "GA güncel tutar", got.GuncelTutar, "43585")
+}
+
+func TestUpdateGuncelFiyatlarAPIHatasi(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(t.TempDir()))
+
+	lot := testLotuEkle(t, s, 0, "Altın", "C", "adet", "1", "6500")
+	if err := s.UpdateGuncelFiyatlar(); err == nil {
+		t.Fatal("fiyatlar alınamadığında hata bekleniyordu")
+	}
+
+	got, err := s.GetEnvanterByID(lot.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimalKontrol(t, "C güncel fiyat", got.GuncelFiyat, "0")
+}
+
+func TestGetKodBazliGruplar(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+
+	ikinci, err := NewPortfoyService().AddPortfoy("İkinci", "")
+	if err != nil {
+		t.Fatalf("portföy eklenemedi: %v", err)
+	}
+
+	testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+	testLotuEkle(t, s, 0, "Altın", "C", "adet", "1", "7500")
+	dolar := testLotuEkle(t, s, 0, "Döviz", "USD", "adet", "1000", "40")
+	testLotuEkle(t, s, ikinci.ID, "Altın", "C", "adet", "4", "6000")
+
+	if err := s.UpdateGuncelFiyatlar(); err != nil {
+		t.Fatalf("UpdateGuncelFiyatlar: %v", err)
+	}
+	if _, err := s.DisposeEnvanter(dolar.ID, models.IslemSatis, decimal.NewFromInt(500), decimal.NewFromInt(42), time.Now(), ""); err != nil {
+		t.Fatalf("satış: %v", err)
+	}
+
+	s.SetPortfoy(models.VarsayilanPortfoyID)
+	gruplar, err := s.GetKodBazliGruplar()
+	if err != nil {
+		t.Fatalf("GetKodBazliGruplar: %v", err)
+	}
+	if len(gruplar) != 2 {
+		t.Fatalf("grup sayısı = %d, beklenen 2", len(gruplar))
+	}
+
+	c := gruplar["C"]
+	if c["adet"] != 2 || c["birim"] != "adet" || c["tur"] != "Altın" {
+		t.Errorf("C grubu beklenmedik: %v", c)
+	}
+	decimalKontrol(t, "C toplam miktar", c["toplam_miktar"], "3")
+	decimalKontrol(t, "C toplam alış", c["toplam_alis_tutar"], "20500")
+	decimalKontrol(t, "C toplam güncel", c["toplam_guncel_tutar"], "21060")
+	decimalKontrol(t, "C gerçekleşmemiş", c["gerceklesmemis_kar_zarar"], "560")
+	decimalKontrol(t, "C gerçekleşen", c["gerceklesen_kar_zarar"], "0")
+	decimalKontrol(t, "C ortalama alış", c["ortalama_alis_fiyati"], "6833.3333")
+	decimalKontrol(t, "C kar/zarar yüzde", c["kar_zarar_yuzde"], "2.7317")
+
+	usd := gruplar["USD"]
+	decimalKontrol(t, "USD toplam miktar", usd["toplam_miktar"], "500")
+	decimalKontrol(t, "USD toplam güncel", usd["toplam_guncel_tutar"], "20826")
+	decimalKontrol(t, "USD gerçekleşmemiş", usd["gerceklesmemis_kar_zarar"], "826")
+	decimalKontrol(t, "USD gerçekleşen", usd["gerceklesen_kar_zarar"], "1000")
+
+	// Tüm portföyler görünümünde ikinci portföyün lotu da gruba katılır
+	s.SetPortfoy(0)
+	gruplar, err = s.GetKodBazliGruplar()
+	if err != nil {
+		t.Fatalf("GetKodBazliGruplar: %v", err)
+	}
+	decimalKontrol(t, "tüm portföyler C miktar", gruplar["C"]["toplam_miktar"], "7")
+	decimalKontrol(t, "tüm portföyler C alış", gruplar["C"]["toplam_alis_tutar"], "44500")
+
+	// Tamamı satılan kod yalnızca gerçekleşen kar/zararla listelenir
+	if _, err := s.DisposeEnvanter(dolar.ID, models.IslemSatis, decimal.NewFromInt(500), decimal.NewFromInt(41), time.Now(), ""); err != nil {
+		t.Fatalf("satış: %v", err)
+	}
+	gruplar, err = s.GetKodBazliGruplar()
+	if err != nil {
+		t.Fatalf("GetKodBazliGruplar: %v", err)
+	}
+	usd = gruplar["USD"]
+	if usd == nil || usd["adet"] != 0 {
+		t.Fatalf("kapanan USD grubu beklenmedik: %v", usd)
+	}
+	decimalKontrol(t, "kapanan USD miktar", usd["toplam_miktar"], "0")
+	decimalKontrol(t, "kapanan USD gerçekleşen", usd["gerceklesen_kar_zarar"], "1500")
+}
+
+func TestGetGorunum(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+
+	testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+	dolar := testLotuEkle(t, s, 0, "Döviz", "USD", "adet", "1000", "40")
+	if err := s.UpdateGuncelFiyatlar(); err != nil {
+		t.Fatalf("UpdateGuncelFiyatlar: %v", err)
+	}
+	if _, err := s.DisposeEnvanter(dolar.ID, models.IslemSatis, decimal.NewFromInt(500), decimal.NewFromInt(42), time.Now(), ""); err != nil {
+		t.Fatalf("satış: %v", err)
+	}
+
+	// Görüntü ayrı sorgularla aynı lotları, grupları ve toplamları verir
+	gorunum, err := s.GetGorunum()
+	if err != nil {
+		t.Fatalf("GetGorunum: %v", err)
+	}
+	envanterler, _ := s.GetAllEnvanterFromDB()
+	gruplar, _ := s.GetKodBazliGruplar()
+	toplamlar, _ := s.GetToplamDegerler()
+
+	if len(gorunum.Envanterler) != len(envanterler) || len(gorunum.Gruplar) != len(gruplar) {
+		t.Fatalf("görüntü %d lot, %d grup; beklenen %d lot, %d grup",
+			len(gorunum.Envanterler), len(gorunum.Gruplar), len(envanterler), len(gruplar))
+	}
+	for i := range envanterler {
+		if gorunum.Envanterler[i].ID != envanterler[i].ID {
+			t.Errorf("lot %d sırası farklı: %d, beklenen %d", i, gorunum.Envanterler[i].ID, envanterler[i].ID)
+		}
+	}
+	for kod, grup := range gruplar {
+		for _, alan := range []string{"toplam_miktar", "toplam_guncel_tutar", "gerceklesen_kar_zarar"} {
+			decimalKontrol(t, kod+" "+alan, gorunum.Gruplar[kod][alan], grup[alan].(decimal.Decimal).String())
+		}
+	}
+	for alan, deger := range toplamlar {
+		decimalKontrol(t, alan, gorunum.Toplamlar[alan], deger.String())
+	}
+	decimalKontrol(t, "gerçekleşen toplam", gorunum.Toplamlar["toplam_gerceklesen_kar"], "1000")
+}
+
+func TestDisposeByKodAlisTarihi(t *testing.T) {
+	for _, yontem := range []string{models.YontemFIFO, models.YontemLIFO} {
+		t.Run(yontem, func(t *testing.T) {
+			testVeritabani(t)
+			s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+			if err := s.SetMaliyetYontemi("C", yontem); err != nil {
+				t.Fatalf("SetMaliyetYontemi: %v", err)
+			}
+
+			eski := testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+			yeni := &models.Envanter{
+				Tur: "Altın", Cins: "C", Kod: "C", Birim: "adet",
+				Miktar:     decimal.NewFromInt(3),
+				AlisFiyati: decimal.NewFromInt(7000),
+				AlisTarihi: time.Date(2025, 6, 1, 14, 30, 0, 0, time.Local),
+			}
+			if err := s.AddEnvanterWithMode(yeni, true); err != nil {
+				t.Fatalf("lot eklenemedi: %v", err)
+			}
+
+			// Yeni lotun alışından önceki satış yalnızca eski lottan karşılanabilir
+			nisan := time.Date(2025, 4, 15, 0, 0, 0, 0, time.Local)
+			if _, err := s.DisposeByKod("C", models.IslemSatis, decimal.NewFromInt(3), decimal.NewFromInt(7500), nisan, ""); err == nil {
+				t.Fatal("çıkış tarihinde eldeki miktardan fazla satış reddedilmeliydi")
+			}
+			islemler, err := s.DisposeByKod("C", models.IslemSatis, decimal.NewFromInt(1), decimal.NewFromInt(7500), nisan, "")
+			if err != nil {
+				t.Fatalf("DisposeByKod: %v", err)
+			}
+			if len(islemler) != 1 || islemler[0].EnvanterID != eski.ID {
+				t.Fatalf("satış eski lottan yapılmalıydı: %+v", islemler)
+			}
+
+			// Saat içeren alış tarihi, aynı gün tarihli satışı engellemez
+			islemler, err = s.DisposeByKod("C", models.IslemSatis, decimal.NewFromInt(1), decimal.NewFromInt(7500), time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local), "")
+			if err != nil {
+				t.Fatalf("DisposeByKod: %v", err)
+			}
+			beklenenLot := eski.ID
+			if yontem == models.YontemLIFO {
+				beklenenLot = yeni.ID
+			}
+			if len(islemler) != 1 || islemler[0].EnvanterID != beklenenLot {
+				t.Errorf("aynı gün satış beklenen lottan yapılmadı: %+v", islemler)
+			}
+		})
+	}
+}
+
+func TestDisposeByKodGerceklesenKarZarar(t *testing.T) {
+	// Aynı gün alınmış üç lot (1 gr × 3000, 3300, 3600); satış fiyatı 4000
+	testler := []struct {
+		ad, yontem, miktar string
+		paylar             []string // lotlardan çıkan miktarlar, alış sırasıyla
+		karZararlar        []string
+		toplamKarZarar     string
+	}{
+		{"FIFO tek lot", models.YontemFIFO, "1", []string{"1", "0", "0"}, []string{"1000", "0", "0"}, "1000"},
+		{"FIFO iki lot", models.YontemFIFO, "1.5", []string{"1", "0.5", "0"}, []string{"1000", "350", "0"}, "1350"},
+		{"LIFO tek lot", models.YontemLIFO, "1", []string{"0", "0", "1"}, []string{"0", "0", "400"}, "400"},
+		{"LIFO iki lot", models.YontemLIFO, "1.5", []string{"0", "0.5", "1"}, []string{"0", "350", "400"}, "750"},
+		{"ortalama tam bölünen", models.YontemOrtalama, "1.5", []string{"0.5", "0.5", "0.5"}, []string{"500", "350", "200"}, "1050"},
+		// 1/3 = 0.33333333 (8 basamakta kesilir); kalan 0.00000001 ilk lota eklenir
+		{"ortalama kesme artığı", models.YontemOrtalama, "1", []string{"0.33333334", "0.33333333", "0.33333333"},
+			[]string{"333.33334", "233.333331", "133.333332"}, "700.000003"},
+	}
+	for _, tt := range testler {
+		t.Run(tt.ad, func(t *testing.T) {
+			testVeritabani(t)
+			s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+			if err := s.SetMaliyetYontemi("GA", tt.yontem); err != nil {
+				t.Fatalf("SetMaliyetYontemi: %v", err)
+			}
+			var lotlar []*models.Envanter
+			for _, fiyat := range []string{"3000", "3300", "3600"} {
+				lotlar = append(lotlar, testLotuEkle(t, s, 0, "Altın", "GA", "gram", "1", fiyat))
+			}
+
+			satis := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+			islemler, err := s.DisposeByKod("GA", models.IslemSatis, decimal.RequireFromString(tt.miktar), decimal.NewFromInt(4000), satis, "")
+			if err != nil {
+				t.Fatalf("DisposeByKod: %v", err)
+			}
+			lotIslemleri := map[uint]models.Islem{}
+			toplamMiktar := decimal.Zero
+			for _, islem := range islemler {
+				lotIslemleri[islem.EnvanterID] = islem
+				toplamMiktar = toplamMiktar.Add(islem.Miktar)
+				if islem.Yontem != tt.yontem {
+					t.Errorf("işlem yöntemi = %s, beklenen %s", islem.Yontem, tt.yontem)
+				}
+			}
+			decimalKontrol(t, "çıkan toplam miktar", toplamMiktar, tt.miktar)
+
+			for i, lot := range lotlar {
+				islem, ok := lotIslemleri[lot.ID]
+				if tt.paylar[i] == "0" {
+					if ok {
+						t.Errorf("lot %d'den çıkış beklenmiyordu: %+v", i+1, islem)
+					}
+					continue
+				}
+				if !ok {
+					t.Errorf("lot %d'den çıkış yapılmadı", i+1)
+					continue
+				}
+				decimalKontrol(t, fmt.Sprintf("lot %d miktar", i+1), islem.Miktar, tt.paylar[i])
+				decimalKontrol(t, fmt.Sprintf("lot %d gerçekleşen K/Z", i+1), islem.GerceklesenKarZarar, tt.karZararlar[i])
+
+				kalan, err := s.GetEnvanterByID(lot.ID)
+				if err != nil {
+					t.Fatalf("GetEnvanterByID: %v", err)
+				}
+				decimalKontrol(t, fmt.Sprintf("lot %d kalan", i+1), kalan.Miktar, decimal.NewFromInt(1).Sub(decimal.RequireFromString(tt.paylar[i])).String())
+			}
+
+			gruplar, err := s.GetKodBazliGruplar()
+			if err != nil {
+				t.Fatalf("GetKodBazliGruplar: %v", err)
+			}
+			decimalKontrol(t, "grup gerçekleşen K/Z", gruplar["GA"]["gerceklesen_kar_zarar"], tt.toplamKarZarar)
+		})
+	}
+}
+
+func TestDisposeEnvanterAlisTarihindenOnce(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+	ceyrek := testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+
+	onceki := ceyrek.AlisTarihi.AddDate(0, 0, -1)
+	if _, err := s.DisposeEnvanter(ceyrek.ID, models.IslemSatis, decimal.NewFromInt(1), decimal.NewFromInt(7000), onceki, ""); err == nil {
+		t.Fatal("alış tarihinden önceki çıkış reddedilmeliydi")
+	}
+	lot, err := s.GetEnvanterByID(ceyrek.ID)
+	if err != nil {
+		t.Fatalf("GetEnvanterByID: %v", err)
+	}
+	decimalKontrol(t, "reddedilen çıkış sonrası miktar", lot.Miktar, "2")
+
+	if _, err := s.DisposeEnvanter(ceyrek.ID, models.IslemSatis, decimal.NewFromInt(1), decimal.NewFromInt(7000), ceyrek.AlisTarihi, ""); err != nil {
+		t.Errorf("alış günündeki çıkış kabul edilmeliydi: %v", err)
+	}
+}
+
+func TestUpdateEnvanterAcilisIslemi(t *testing.T) {
+	testVeritabani(t)
+	s := NewEnvanterServiceWithProvider(NewFixtureSaglayici(fixtureDizini))
+	ceyrek := testLotuEkle(t, s, 0, "Altın", "C", "adet", "2", "6500")
+	if _, err := s.DisposeEnvanter(ceyrek.ID, models.IslemSatis, decimal.NewFromInt(1), decimal.NewFromInt(7000), ceyrek.AlisTarihi, ""); err != nil {
+		t.Fatalf("satış: %v", err)
+	}
+
+	acilisKontrol := func(asama, miktar, birimFiyat, tutar string, tarih time.Time) {
+		t.Helper()
+		var girisler []models.Islem
+		database.GetDB().Where("envanter_id = ? AND tip = ?", ceyrek.ID, models.IslemAlis).Find(&girisler)
+		if len(girisler) != 1 {
+			t.Fatalf("%s: %d giriş işlemi, beklenen 1", asama, len(girisler))
+		}
+		decimalKontrol(t, asama+" miktar", girisler[0].Miktar, miktar)
+		decimalKontrol(t, asama+" birim fiyat", girisler[0].BirimFiyat, birimFiyat)
+		decimalKontrol(t, asama+" tutar", girisler[0].Tutar, tutar)
+		if !girisler[0].Tarih.Equal(tarih) {
+			t.Errorf("%s tarih = %s, beklenen %s", asama, girisler[0].Tarih, tarih)
+		}
+	}
+
+	// Düzenlenen kalan miktar, fiyat ve tarih giriş işlemine yansır; satılan miktar girişte kalır
+	lot, err := s.GetEnvanterByID(ceyrek.ID)
+	if err != nil {
+		t.Fatalf("GetEnvanterByID: %v", err)
+	}
+	yeniTarih := time.Date(2025, 2, 15, 0, 0, 0, 0, time.Local)
+	lot.Miktar = decimal.NewFromInt(3)
+	lot.AlisFiyati = decimal.NewFromInt(6750)
+	lot.AlisTarihi = yeniTarih
+	if err := s.UpdateEnvanterWithMode(lot, true); err != nil {
+		t.Fatalf("UpdateEnvanterWithMode: %v", err)
+	}
+	acilisKontrol("düzenleme sonrası", "4", "6750", "27000", yeniTarih)
+
+	// Giriş işlemi olmayan eski lot düzenlenince işlem oluşturulur
+	database.GetDB().Unscoped().Where("envanter_id = ? AND tip = ?", ceyrek.ID, models.IslemAlis).Delete(&models.Islem{})
+	if err := s.UpdateEnvanterWithMode(lot, true); err != nil {
+		t.Fatalf("UpdateEnvanterWithMode: %v", err)
+	}
+	acilisKontrol("eski lot düzenlemesi", "4", "6750", "27000", yeniTarih)
+
+	// Migrasyon giriş işlemi olmayan eski lotları tamamlar; tutar lotun toplam alışı ile
+	// satılan miktarın düşülen maliyetidir (3 × 6750 + 1 × 6500)
+	database.GetDB().Unscoped().Where("envanter_id = ? AND tip = ?", ceyrek.ID, models.IslemAlis).Delete(&models.Islem{})
+	database.GetDB().Where("version = ?", 6).Delete(&models.SemaMigrasyonu{})
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("migrasyon: %v", err)
+	}
+	acilisKontrol("migrasyon sonrası", "4", "6750", "26750", yeniTarih)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// NewFixtureSaglayici API yerine dizindeki kayıtlı Gold.json/Currency.json dosyalarını okuyan
+// sağlayıcı oluşturur. Dosyalar API_RECORD_DIR ile kaydedilmiş gerçek yanıtlar olabilir;
+// istekler aynı parse ve metrik yolundan geçer, yalnızca ağ yerine dosya okunur.
+func NewFixtureSaglayici(dizin string) *AltinKaynakService {
+	s := NewAltinKaynakServiceWithURL("http://fixture")
+	s.ad = "fixture"
+	s.client.Transport = dosyaTasiyici{dizin: dizin}
+	s.yenidenDeneme = 0 // Dosya okuma hataları geçici değildir
+	return s
+}
+
+// dosyaTasiyici HTTP isteklerini istek yolunun dosya adıyla dizindeki dosyadan yanıtlar
+type dosyaTasiyici struct {
+	dizin string
+}
+
+// RoundTrip dosya varsa 200, yoksa 404 yanıtı üretir
+func (t dosyaTasiyici) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.dizin == "" {
+		return nil, fmt.Errorf("fixture dizini tanımlı değil (FIXTURE_DIR)")
+	}
+
+	durum := http.StatusOK
+	body, err := os.ReadFile(filepath.Join(t.dizin, path.Base(req.URL.Path)))
+	if os.IsNotExist(err) {
+		durum, body = http.StatusNotFound, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("fixture okunamadı: %w", err)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(durum),
+		StatusCode:    durum,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+)
+
+// FiyatGecmisiService fiyat geçmişi kayıt ve sorgularını yönetir
+type FiyatGecmisiService struct{}
+
+// NewFiyatGecmisiService yeni fiyat geçmişi servisi oluşturur
+func NewFiyatGecmisiService() *FiyatGecmisiService {
+	return &FiyatGecmisiService{}
+}
+
+// SaveFiyatlar çekilen fiyatları geçmişe yazar. Alış veya satış fiyatı son
+// kayıttan farklı olmayan kodlar atlanır. Eklenen kayıt sayısını döner.
+func (s *FiyatGecmisiService) SaveFiyatlar(fiyatlar *AltinFiyatlari, kaynak string) (int, error) {
+	sonFiyatlar, err := s.GetSonFiyatlar()
+	if err != nil {
+		return 0, err
+	}
+
+	var yeniKayitlar []models.FiyatGecmisi
+	for _, item := range append(append([]RestPriceItem{}, fiyatlar.GoldItems...), fiyatlar.CurrencyItems...) {
+		kod := strings.ToUpper(strings.TrimSpace(item.Kod))
+		if kod == "" {
+			continue
+		}
+
+		kayit := models.FiyatGecmisi{
+			Kod:               kod,
+			Alis:              parseFloat(item.Alis),
+			Satis:             parseFloat(item.Satis),
+			Degisim:           item.Change,
+			GuncellenmeZamani: item.GuncellenmeZamani,
+			CekilmeZamani:     fiyatlar.GuncellemeTarihi,
+			Kaynak:            kaynak,
+		}
+
+		// Fiyat değişmemişse kaydetme
+		if son, exists := sonFiyatlar[kod]; exists && son.Alis == kayit.Alis && son.Satis == kayit.Satis {
+			continue
+		}
+		sonFiyatlar[kod] = kayit
+		yeniKayitlar = append(yeniKayitlar, kayit)
+	}
+
+	if len(yeniKayitlar) == 0 {
+		logger.Debugf("Fiyat geçmişi: değişen fiyat yok")
+		return 0, nil
+	}
+
+	if err := database.GetDB().CreateInBatches(yeniKayitlar, 100).Error; err != nil {
+		return 0, fmt.Errorf("fiyat geçmişi kaydedilemedi: %w", err)
+	}
+
+	logger.Debugf("Fiyat geçmişine %d kayıt eklendi", len(yeniKayitlar))
+	return len(yeniKayitlar), nil
+}
+
+// GetSonFiyatlar her kod için en son kaydedilmiş fiyatı döner
+func (s *FiyatGecmisiService) GetSonFiyatlar() (map[string]models.FiyatGecmisi, error) {
+	var kayitlar []models.FiyatGecmisi
+	err := database.GetDB().
+		Where("id IN (?)", database.GetDB().Model(&models.FiyatGecmisi{}).Select("MAX(id)").Group("kod")).
+		Find(&kayitlar).Error
+	if err != nil {
+		return nil, fmt.Errorf("son fiyatlar getirilemedi: %w", err)
+	}
+
+	sonFiyatlar := make(map[string]models.FiyatGecmisi, len(kayitlar))
+	for _, kayit := range kayitlar {
+		sonFiyatlar[kayit.Kod] = kayit
+	}
+	return sonFiyatlar, nil
+}
+
+// GetFiyatGecmisi bir kodun belirtilen tarihten itibaren fiyat geçmişini eskiden yeniye döner.
+// Sıfır tarih tüm geçmişi getirir.
+func (s *FiyatGecmisiService) GetFiyatGecmisi(kod string, baslangic time.Time) ([]models.FiyatGecmisi, error) {
+	var kayitlar []models.FiyatGecmisi
+	query := database.GetDB().Where("kod = ?", strings.ToUpper(strings.TrimSpace(kod)))
+	if !baslangic.IsZero() {
+		query = query.Where("cekilme_zamani >= ?", baslangic)
+	}
+
+	if err := query.Order("cekilme_zamani asc, id asc").Find(&kayitlar).Error; err != nil {
+		return nil, fmt.Errorf("fiyat geçmişi getirilemedi: %w", err)
+	}
+	return kayitlar, nil
+}
+
+// GetFiyatAt bir kodun belirtilen andaki (o ana kadar kaydedilmiş son) fiyatını döner
+func (s *FiyatGecmisiService) GetFiyatAt(kod string, an time.Time) (*models.FiyatGecmisi, error) {
+	var kayitlar []models.FiyatGecmisi
+	err := database.GetDB().
+		Where("kod = ? AND cekilme_zamani <= ?", strings.ToUpper(strings.TrimSpace(kod)), an).
+		Order("cekilme_zamani desc, id desc").
+		Limit(1).
+		Find(&kayitlar).Error
+	if err != nil {
+		return nil, fmt.Errorf("fiyat geçmişi getirilemedi: %w", err)
+	}
+	if len(kayitlar) == 0 {
+		return nil, nil
+	}
+	return &kayitlar[0], nil
+}
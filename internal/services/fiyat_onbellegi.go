@@ -0,0 +1,78 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"altintakip/internal/database"
+)
+
+// fiyatOnbellegiDosyasi son başarılı fiyat görüntüsünün veri dizinindeki dosya adı
+const fiyatOnbellegiDosyasi = "son_fiyatlar.json"
+
+// fiyatOnbelleginiOku önbellekteki son başarılı fiyat görüntüsünü okur; dosya yoksa nil döner
+func fiyatOnbelleginiOku(yol string) (*AltinFiyatlari, error) {
+	veri, err := os.ReadFile(yol)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fiyatlar AltinFiyatlari
+	if err := json.Unmarshal(veri, &fiyatlar); err != nil {
+		return nil, fmt.Errorf("%s çözülemedi: %w", yol, err)
+	}
+	return &fiyatlar, nil
+}
+
+// fiyatOnbellegineYaz fiyat görüntüsünü önbelleğe yazar. Dosya önce geçici adla yazılıp
+// yerine taşındığından yarıda kalan yazma önceki önbelleği bozmaz.
+func fiyatOnbellegineYaz(yol string, fiyatlar *AltinFiyatlari) error {
+	// Eksik gruplar önbellekten tamamlandığından kaydedilen görüntüde eksik yoktur
+	kayit := *fiyatlar
+	kayit.Eksikler = nil
+
+	veri, err := json.Marshal(&kayit)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(yol), 0755); err != nil {
+		return err
+	}
+	gecici := yol + ".tmp"
+	if err := os.WriteFile(gecici, veri, 0644); err != nil {
+		return err
+	}
+	return os.Rename(gecici, yol)
+}
+
+// SonIyiFiyatlar veri dizinindeki önbellekten en son başarıyla çekilen fiyatları döner; API'ye
+// ulaşılamadığında alış fiyatı önerisi ve fiyatların ne kadar eski olduğunu göstermek için kullanılır.
+// Önbellek yoksa nil döner.
+func SonIyiFiyatlar() (*AltinFiyatlari, error) {
+	dizin, err := database.VeriDizini()
+	if err != nil {
+		return nil, err
+	}
+	fiyatlar, err := fiyatOnbelleginiOku(filepath.Join(dizin, fiyatOnbellegiDosyasi))
+	if err != nil {
+		return nil, fmt.Errorf("fiyat önbelleği okunamadı: %w", err)
+	}
+	return fiyatlar, nil
+}
+
+// kayitliFiyatlar önbellek dosyasındaki son başarılı fiyatları döner; önbellek kapalıysa veya dosya yoksa nil döner
+func (s *AltinKaynakService) kayitliFiyatlar() (*AltinFiyatlari, error) {
+	if s.onbellekYolu == "" {
+		return nil, nil
+	}
+	fiyatlar, err := fiyatOnbelleginiOku(s.onbellekYolu)
+	if err != nil {
+		return nil, fmt.Errorf("fiyat önbelleği okunamadı: %w", err)
+	}
+	return fiyatlar, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"altintakip/internal/logger"
+)
+
+// PriceProvider fiyat kaynağı arayüzü. AltinKaynakService ilk uygulamasıdır;
+// TCMB, başka bir kuyumcu veya yerel dosya gibi kaynaklar bu arayüzü
+// uygulayarak eklenebilir.
+type PriceProvider interface {
+	// Name sağlayıcının adını döner (ör. "altinkaynak")
+	Name() string
+
+	// GetFiyatlar tüm ürünlerin anlık fiyat görüntüsünü çeker
+	GetFiyatlar() (*AltinFiyatlari, error)
+
+	// GetFiyatByType fiyat görüntüsünde belirli bir ürün kodunun alış fiyatını döner
+	GetFiyatByType(fiyatlar *AltinFiyatlari, kod string) (float64, error)
+
+	// GetAlisSatis fiyat görüntüsünde belirli bir ürün kodunun alış ve satış fiyatını döner
+	GetAlisSatis(fiyatlar *AltinFiyatlari, kod string) (alis, satis float64, err error)
+
+	// GetItemByCode fiyat görüntüsünde belirli bir kodun tüm bilgisini döner
+	GetItemByCode(fiyatlar *AltinFiyatlari, kod string) (*RestPriceItem, error)
+}
+
+// varsayilanSaglayici PRICE_PROVIDER boş bırakıldığında kullanılan sağlayıcı
+const varsayilanSaglayici = "altinkaynak"
+
+// saglayicilar kayıtlı fiyat sağlayıcılarının kurucuları
+var saglayicilar = map[string]func() PriceProvider{
+	"altinkaynak": func() PriceProvider { return NewAltinKaynakService() },
+	"fixture":     func() PriceProvider { return NewFixtureSaglayici(os.Getenv("FIXTURE_DIR")) },
+}
+
+// NewPriceProvider isme göre fiyat sağlayıcısı oluşturur
+func NewPriceProvider(name string) (PriceProvider, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = varsayilanSaglayici
+	}
+
+	kurucu, exists := saglayicilar[name]
+	if !exists {
+		return nil, fmt.Errorf("bilinmeyen fiyat sağlayıcısı: %s", name)
+	}
+
+	return kurucu(), nil
+}
+
+// Süreç içinde paylaşılan, önbellekli sağlayıcılar (sağlayıcı adına göre)
+var (
+	paylasilanSaglayicilarMu sync.Mutex
+	paylasilanSaglayicilar   = map[string]PriceProvider{}
+)
+
+// NewConfiguredPriceProvider PRICE_PROVIDER ayarına göre aktif sağlayıcıyı döner. Sağlayıcı süreç
+// içinde paylaşılır ve çekilen fiyatlar PRICE_CACHE_TTL süresince yeniden kullanılır.
+func NewConfiguredPriceProvider() PriceProvider {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("PRICE_PROVIDER")))
+	if name == "" {
+		name = varsayilanSaglayici
+	}
+	if _, exists := saglayicilar[name]; !exists {
+		logger.Warnf("bilinmeyen fiyat sağlayıcısı: %s, varsayılan sağlayıcı kullanılıyor: %s", name, varsayilanSaglayici)
+		name = varsayilanSaglayici
+	}
+
+	paylasilanSaglayicilarMu.Lock()
+	defer paylasilanSaglayicilarMu.Unlock()
+	if provider, exists := paylasilanSaglayicilar[name]; exists {
+		return provider
+	}
+
+	provider, _ := NewPriceProvider(name)
+	if sure := fiyatOnbellekSuresi(); sure > 0 {
+		provider = newOnbellekliSaglayici(provider, sure)
+	}
+	paylasilanSaglayicilar[name] = provider
+	return provider
+}
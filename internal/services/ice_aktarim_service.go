@@ -0,0 +1,433 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/format"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// İçe aktarımda kullanılan sayı/tarih formatları
+const (
+	IceAktarimTR = "tr" // 1.234,56 ve 02.01.2006
+	IceAktarimEN = "en" // 1,234.56 ve 2006-01-02 veya 01/02/2006
+)
+
+// İçe aktarım alanları
+const (
+	AlanKod       = "kod"
+	AlanMiktar    = "miktar"
+	AlanFiyat     = "fiyat"
+	AlanTarih     = "tarih"
+	AlanTur       = "tur"
+	AlanCins      = "cins"
+	AlanBirim     = "birim"
+	AlanGirisTipi = "giris_tipi"
+	AlanNotlar    = "notlar"
+)
+
+// zorunluAlanlar CSV'de mutlaka eşleşmesi gereken alanlar
+var zorunluAlanlar = []string{AlanKod, AlanMiktar, AlanFiyat, AlanTarih}
+
+// alanTakmaAdlari başlık eşleştirmesi verilmediğinde tanınan sütun adları (normalleştirilmiş)
+var alanTakmaAdlari = map[string][]string{
+	AlanKod:       {"kod", "code", "urun_kodu", "symbol"},
+	AlanMiktar:    {"miktar", "quantity", "qty", "amount"},
+	AlanFiyat:     {"fiyat", "alis_fiyati", "birim_fiyat", "price", "unit_price"},
+	AlanTarih:     {"tarih", "alis_tarihi", "date"},
+	AlanTur:       {"tur", "type"},
+	AlanCins:      {"cins", "name"},
+	AlanBirim:     {"birim", "unit"},
+	AlanGirisTipi: {"giris_tipi", "entry_type"},
+	AlanNotlar:    {"notlar", "not", "notes", "note"},
+}
+
+// tarihFormatlari içe aktarım formatına göre denenen tarih düzenleri
+var tarihFormatlari = map[string][]string{
+	IceAktarimTR: {"02.01.2006", "2.1.2006"},
+	IceAktarimEN: {"2006-01-02", "01/02/2006", "1/2/2006"},
+}
+
+// kopyaFiyatToleransi birim fiyatları yarım kuruştan az farklı olan satırlar aynı sayılır
+var kopyaFiyatToleransi = decimal.New(5, -3)
+
+// IceAktarimSatiri CSV'deki bir satırın ayrıştırılmış hali
+type IceAktarimSatiri struct {
+	Satir      int             `json:"satir"` // CSV'deki satır numarası (başlık 1. satır)
+	Envanter   models.Envanter `json:"envanter"`
+	Hata       string          `json:"hata,omitempty"`
+	OlasiKopya bool            `json:"olasi_kopya"`
+	KopyaNotu  string          `json:"kopya_notu,omitempty"`
+}
+
+// IceAktarimOnizleme içe aktarılacak satırların önizlemesi
+type IceAktarimOnizleme struct {
+	Sutunlar map[string]string  `json:"sutunlar"` // alan -> CSV başlığı
+	Satirlar []IceAktarimSatiri `json:"satirlar"`
+}
+
+// HataSayisi ayrıştırılamayan satır sayısını döner
+func (o *IceAktarimOnizleme) HataSayisi() int {
+	sayi := 0
+	for _, satir := range o.Satirlar {
+		if satir.Hata != "" {
+			sayi++
+		}
+	}
+	return sayi
+}
+
+// KopyaSayisi olası kopya olarak işaretlenen satır sayısını döner
+func (o *IceAktarimOnizleme) KopyaSayisi() int {
+	sayi := 0
+	for _, satir := range o.Satirlar {
+		if satir.Hata == "" && satir.OlasiKopya {
+			sayi++
+		}
+	}
+	return sayi
+}
+
+// IceAktarimService CSV dosyalarından envanter kayıtlarını içe aktarır
+type IceAktarimService struct {
+	envanterService *EnvanterService
+}
+
+// NewIceAktarimService yeni içe aktarım servisi oluşturur
+func NewIceAktarimService() *IceAktarimService {
+	return &IceAktarimService{
+		envanterService: NewEnvanterService(),
+	}
+}
+
+// SetPortfoy kayıtların ekleneceği portföyü belirler (0: varsayılan portföy)
+func (s *IceAktarimService) SetPortfoy(portfoyID uint) {
+	s.envanterService.SetPortfoy(portfoyID)
+}
+
+// SutunEslesmesiCoz "kod=Ürün,miktar=Adet" biçimindeki eşleştirmeyi alan -> başlık haritasına çevirir
+func SutunEslesmesiCoz(metin string) (map[string]string, error) {
+	eslesme := map[string]string{}
+	for _, parca := range strings.Split(metin, ",") {
+		parca = strings.TrimSpace(parca)
+		if parca == "" {
+			continue
+		}
+		alan, baslik, ok := strings.Cut(parca, "=")
+		alan = strings.ToLower(strings.TrimSpace(alan))
+		if !ok || baslik == "" {
+			return nil, fmt.Errorf("geçersiz sütun eşleştirmesi: %s (alan=başlık olmalı)", parca)
+		}
+		if _, bilinen := alanTakmaAdlari[alan]; !bilinen {
+			return nil, fmt.Errorf("bilinmeyen alan: %s", alan)
+		}
+		eslesme[alan] = strings.TrimSpace(baslik)
+	}
+	return eslesme, nil
+}
+
+// Onizle CSV'yi okur, satırları ayrıştırır ve mevcut kayıtlarla olası kopyaları işaretler (veritabanına yazmaz)
+func (s *IceAktarimService) Onizle(r io.Reader, sayiFormati string, eslesme map[string]string) (*IceAktarimOnizleme, error) {
+	if _, ok := tarihFormatlari[sayiFormati]; !ok {
+		return nil, fmt.Errorf("geçersiz format: %s (tr veya en olmalı)", sayiFormati)
+	}
+
+	icerik, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("CSV okunamadı: %w", err)
+	}
+	metin := strings.TrimPrefix(string(icerik), "\ufeff")
+
+	okuyucu := csv.NewReader(strings.NewReader(metin))
+	okuyucu.Comma = ayracBul(metin)
+	okuyucu.FieldsPerRecord = -1
+	okuyucu.TrimLeadingSpace = true
+
+	kayitlar, err := okuyucu.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV ayrıştırılamadı: %w", err)
+	}
+	if len(kayitlar) < 2 {
+		return nil, fmt.Errorf("CSV'de başlık satırından sonra kayıt yok")
+	}
+
+	sutunlar, indeksler, err := sutunlariEslestir(kayitlar[0], eslesme)
+	if err != nil {
+		return nil, err
+	}
+
+	onizleme := &IceAktarimOnizleme{Sutunlar: sutunlar}
+	for i, kayit := range kayitlar[1:] {
+		if satirBos(kayit) {
+			continue
+		}
+		onizleme.Satirlar = append(onizleme.Satirlar, satirAyristir(i+2, kayit, indeksler, sayiFormati))
+	}
+
+	if err := s.kopyalariIsaretle(onizleme.Satirlar); err != nil {
+		return nil, err
+	}
+	return onizleme, nil
+}
+
+// Kaydet önizlemedeki geçerli satırları tek işlemde ekler; hatalı satır varsa hiçbir şey eklenmez
+func (s *IceAktarimService) Kaydet(onizleme *IceAktarimOnizleme, kopyalariDahilEt, isListMode bool) (int, error) {
+	if hataSayisi := onizleme.HataSayisi(); hataSayisi > 0 {
+		return 0, fmt.Errorf("%d satır hatalı, içe aktarım yapılmadı", hataSayisi)
+	}
+
+	var envanterler []*models.Envanter
+	for i := range onizleme.Satirlar {
+		satir := &onizleme.Satirlar[i]
+		if satir.OlasiKopya && !kopyalariDahilEt {
+			continue
+		}
+		envanter := satir.Envanter
+		envanterler = append(envanterler, &envanter)
+	}
+	if len(envanterler) == 0 {
+		return 0, nil
+	}
+
+	if err := s.envanterService.AddEnvanterlerWithMode(envanterler, isListMode); err != nil {
+		return 0, err
+	}
+	return len(envanterler), nil
+}
+
+// kopyalariIsaretle hedef portföyde aynı kod, alış günü ve birim fiyata sahip mevcut lotları veya dosya içi tekrarları işaretler
+func (s *IceAktarimService) kopyalariIsaretle(satirlar []IceAktarimSatiri) error {
+	// Satılıp miktarı sıfırlanan lotlar da karşılaştırılır; başka portföylerdeki aynı alışlar kopya sayılmaz
+	var mevcutlar []models.Envanter
+	if err := database.GetDB().Where("portfoy_id = ?", s.envanterService.yeniLotPortfoyu()).Find(&mevcutlar).Error; err != nil {
+		return fmt.Errorf("mevcut kayıtlar okunamadı: %w", err)
+	}
+
+	ayni := func(kod string, tarih time.Time, fiyat decimal.Decimal, digerKod string, digerTarih time.Time, digerFiyat decimal.Decimal) bool {
+		return kod == digerKod &&
+			tarih.Local().Format("2006-01-02") == digerTarih.Local().Format("2006-01-02") &&
+			fiyat.Sub(digerFiyat).Abs().LessThan(kopyaFiyatToleransi)
+	}
+
+	for i := range satirlar {
+		satir := &satirlar[i]
+		if satir.Hata != "" {
+			continue
+		}
+		e := satir.Envanter
+
+		for _, mevcut := range mevcutlar {
+			if ayni(e.Kod, e.AlisTarihi, e.AlisFiyati, mevcut.Kod, mevcut.AlisTarihi, mevcut.AlisFiyati) {
+				satir.OlasiKopya = true
+				satir.KopyaNotu = fmt.Sprintf("mevcut kayıt ID %d", mevcut.ID)
+				break
+			}
+		}
+		if satir.OlasiKopya {
+			continue
+		}
+
+		for _, onceki := range satirlar[:i] {
+			if onceki.Hata == "" && onceki.Envanter.Miktar.Equal(e.Miktar) &&
+				ayni(e.Kod, e.AlisTarihi, e.AlisFiyati, onceki.Envanter.Kod, onceki.Envanter.AlisTarihi, onceki.Envanter.AlisFiyati) {
+				satir.OlasiKopya = true
+				satir.KopyaNotu = fmt.Sprintf("%d. satırın tekrarı", onceki.Satir)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// sutunlariEslestir başlık satırını alanlara eşler; açık eşleştirme yoksa bilinen sütun adlarını kullanır
+func sutunlariEslestir(baslik []string, eslesme map[string]string) (map[string]string, map[string]int, error) {
+	normal := make([]string, len(baslik))
+	for i, ad := range baslik {
+		normal[i] = sutunAdiNormallestir(ad)
+	}
+
+	sutunlar := map[string]string{}
+	indeksler := map[string]int{}
+	for alan, takmaAdlar := range alanTakmaAdlari {
+		adaylar := takmaAdlar
+		if verilen, ok := eslesme[alan]; ok {
+			adaylar = []string{sutunAdiNormallestir(verilen)}
+		}
+		for _, aday := range adaylar {
+			for i, ad := range normal {
+				if ad == aday {
+					sutunlar[alan] = baslik[i]
+					indeksler[alan] = i
+					break
+				}
+			}
+			if _, bulundu := indeksler[alan]; bulundu {
+				break
+			}
+		}
+		if verilen, ok := eslesme[alan]; ok {
+			if _, bulundu := indeksler[alan]; !bulundu {
+				return nil, nil, fmt.Errorf("%s alanı için '%s' sütunu bulunamadı", alan, verilen)
+			}
+		}
+	}
+
+	for _, alan := range zorunluAlanlar {
+		if _, bulundu := indeksler[alan]; !bulundu {
+			return nil, nil, fmt.Errorf("%s sütunu bulunamadı, --sutun %s=<başlık> ile eşleştirin", alan, alan)
+		}
+	}
+	return sutunlar, indeksler, nil
+}
+
+// satirAyristir tek bir CSV satırını envanter kaydına çevirir; hatalar satıra yazılır
+func satirAyristir(satirNo int, kayit []string, indeksler map[string]int, sayiFormati string) IceAktarimSatiri {
+	satir := IceAktarimSatiri{Satir: satirNo}
+	deger := func(alan string) string {
+		i, ok := indeksler[alan]
+		if !ok || i >= len(kayit) {
+			return ""
+		}
+		return strings.TrimSpace(kayit[i])
+	}
+
+	e := &satir.Envanter
+	e.Kod = strings.ToUpper(deger(AlanKod))
+	e.Tur = deger(AlanTur)
+	e.Cins = deger(AlanCins)
+	e.Birim = strings.ToLower(deger(AlanBirim))
+	e.Notlar = deger(AlanNotlar)
+
+	girisTipi, err := girisTipiCoz(deger(AlanGirisTipi))
+	if err != nil {
+		satir.Hata = err.Error()
+		return satir
+	}
+	e.GirisTipi = girisTipi
+
+	if e.Kod == "" {
+		satir.Hata = "kod boş"
+		return satir
+	}
+
+	miktar, err := sayiCoz(deger(AlanMiktar), sayiFormati)
+	if err != nil || !miktar.IsPositive() {
+		satir.Hata = fmt.Sprintf("geçersiz miktar: %q", deger(AlanMiktar))
+		return satir
+	}
+	e.Miktar = miktar
+
+	fiyat, err := sayiCoz(deger(AlanFiyat), sayiFormati)
+	if err != nil || fiyat.IsNegative() || (fiyat.IsZero() && girisTipi != models.IslemHediyeGiris) {
+		satir.Hata = fmt.Sprintf("geçersiz fiyat: %q", deger(AlanFiyat))
+		return satir
+	}
+	e.AlisFiyati = fiyat
+	e.ToplamAlis = e.Miktar.Mul(e.AlisFiyati)
+
+	tarih, err := tarihCoz(deger(AlanTarih), sayiFormati)
+	if err != nil {
+		satir.Hata = err.Error()
+		return satir
+	}
+	e.AlisTarihi = tarih
+
+	// Tür, cins ve birim boşsa ürün kataloğundan tamamlanır
+	if e.Tur == "" || e.Cins == "" {
+		tur, cins, ok := models.UrunBul(e.Kod)
+		if !ok {
+			satir.Hata = fmt.Sprintf("bilinmeyen kod %s, tür ve cins sütunları gerekli", e.Kod)
+			return satir
+		}
+		if e.Tur == "" {
+			e.Tur = tur
+		}
+		if e.Cins == "" {
+			e.Cins = cins.Name
+		}
+	}
+	if e.Birim == "" {
+		e.Birim = models.VarsayilanBirim(e.Tur, e.Kod)
+	}
+
+	return satir
+}
+
+// sayiCoz sayıyı formatına göre binlik ayırıcılarını temizleyerek decimal'a çevirir
+func sayiCoz(metin, sayiFormati string) (decimal.Decimal, error) {
+	metin = strings.TrimSpace(metin)
+	if metin == "" {
+		return decimal.Zero, fmt.Errorf("boş sayı")
+	}
+
+	// Türkçe: nokta binlik, virgül ondalık; İngilizce: virgül binlik, nokta ondalık
+	if sayiFormati == IceAktarimTR {
+		metin = strings.ReplaceAll(metin, ".", "")
+	} else {
+		metin = strings.ReplaceAll(metin, ",", "")
+	}
+
+	sayi := format.ParsePrice(metin)
+	if sayi.IsZero() && strings.Trim(metin, "0.,₺ ") != "" {
+		return decimal.Zero, fmt.Errorf("geçersiz sayı: %s", metin)
+	}
+	return sayi, nil
+}
+
+// tarihCoz tarihi formatın düzenleriyle sırayla dener
+func tarihCoz(metin, sayiFormati string) (time.Time, error) {
+	metin = strings.TrimSpace(metin)
+	for _, duzen := range tarihFormatlari[sayiFormati] {
+		if tarih, err := time.ParseInLocation(duzen, metin, time.Local); err == nil {
+			return tarih, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("geçersiz tarih: %q (%s bekleniyor)", metin, tarihFormatlari[sayiFormati][0])
+}
+
+// girisTipiCoz giriş tipi sütununu (alis, Alış, hediye_giris, Hediye Girişi) koda çevirir
+func girisTipiCoz(metin string) (string, error) {
+	switch sutunAdiNormallestir(metin) {
+	case "", "alis":
+		return models.IslemAlis, nil
+	case "hediye", "hediye_giris", "hediye_girisi":
+		return models.IslemHediyeGiris, nil
+	}
+	return "", fmt.Errorf("geçersiz giriş tipi: %q", metin)
+}
+
+// sutunAdiNormallestir başlığı küçük harfe, Türkçe karakterleri ASCII'ye ve boşlukları alt çizgiye çevirir
+func sutunAdiNormallestir(ad string) string {
+	ad = strings.NewReplacer(
+		"İ", "i", "I", "i", "ı", "i", "Ş", "s", "ş", "s", "Ğ", "g", "ğ", "g",
+		"Ü", "u", "ü", "u", "Ö", "o", "ö", "o", "Ç", "c", "ç", "c",
+	).Replace(strings.TrimSpace(ad))
+	ad = strings.ToLower(ad)
+	return strings.NewReplacer(" ", "_", "-", "_", ".", "").Replace(ad)
+}
+
+// ayracBul başlık satırındaki noktalı virgül ve virgül sayısına göre ayracı seçer
+func ayracBul(metin string) rune {
+	ilkSatir, _, _ := strings.Cut(metin, "\n")
+	if strings.Count(ilkSatir, ";") > strings.Count(ilkSatir, ",") {
+		return ';'
+	}
+	return ','
+}
+
+// satirBos tüm hücreleri boş olan satırları tespit eder
+func satirBos(kayit []string) bool {
+	for _, hucre := range kayit {
+		if strings.TrimSpace(hucre) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
+)
+
+// GerceklesenCikis tek bir satış veya hediye çıkışının gerçekleşen sonucu
+// (birden fazla lotu tüketmiş olabilir)
+type GerceklesenCikis struct {
+	Referans  string
+	Tarih     time.Time
+	Kod       string
+	Tip       string
+	Yontem    string
+	Miktar    decimal.Decimal
+	Tutar     decimal.Decimal
+	Maliyet   decimal.Decimal
+	KarZarar  decimal.Decimal
+	LotSayisi int
+}
+
+// VarsayilanMaliyetYontemi COST_BASIS_METHOD ayarını, yoksa FIFO'yu döner
+func VarsayilanMaliyetYontemi() string {
+	yontem := strings.ToLower(strings.TrimSpace(os.Getenv("COST_BASIS_METHOD")))
+	if gecerliMaliyetYontemi(yontem) {
+		return yontem
+	}
+	if yontem != "" {
+		logger.Warnf("Geçersiz COST_BASIS_METHOD değeri: %s, FIFO kullanılıyor", yontem)
+	}
+	return models.YontemFIFO
+}
+
+// GetMaliyetYontemi bir kod için seçilmiş maliyet yöntemini döner
+func (s *EnvanterService) GetMaliyetYontemi(kod string) (string, error) {
+	var kayitlar []models.MaliyetYontemi
+	err := database.GetDB().Where("kod = ?", kod).Limit(1).Find(&kayitlar).Error
+	if err != nil {
+		return "", fmt.Errorf("maliyet yöntemi getirilemedi: %w", err)
+	}
+	if len(kayitlar) == 0 {
+		return VarsayilanMaliyetYontemi(), nil
+	}
+	return kayitlar[0].Yontem, nil
+}
+
+// SetMaliyetYontemi bir kod için maliyet yöntemini kaydeder
+func (s *EnvanterService) SetMaliyetYontemi(kod, yontem string) error {
+	if !gecerliMaliyetYontemi(yontem) {
+		return fmt.Errorf("geçersiz maliyet yöntemi: %s", yontem)
+	}
+
+	kayit := models.MaliyetYontemi{Kod: kod, Yontem: yontem}
+	err := database.GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kod"}},
+		DoUpdates: clause.AssignmentColumns([]string{"yontem", "updated_at"}),
+	}).Create(&kayit).Error
+	if err != nil {
+		return fmt.Errorf("maliyet yöntemi kaydedilemedi: %w", err)
+	}
+
+	logger.Infof("Maliyet yöntemi ayarlandı: %s = %s", kod, yontem)
+	database.DegisiklikSonrasi("maliyet-yontemi")
+	return nil
+}
+
+// SonrakiMaliyetYontemi yöntem listesinde bir sonraki yöntemi döner (TUI'de döngüsel seçim için)
+func SonrakiMaliyetYontemi(yontem string) string {
+	for i, y := range models.MaliyetYontemleri {
+		if y.Kod == yontem {
+			return models.MaliyetYontemleri[(i+1)%len(models.MaliyetYontemleri)].Kod
+		}
+	}
+	return models.MaliyetYontemleri[0].Kod
+}
+
+// GetGerceklesenCikislar tüm çıkışları, tükettikleri lotları birleştirerek en yeniden eskiye döner
+func (s *EnvanterService) GetGerceklesenCikislar() ([]GerceklesenCikis, error) {
+	var islemler []models.Islem
+	err := database.GetDB().Scopes(s.islemPortfoyKapsami).Where("tip IN ?", []string{models.IslemSatis, models.IslemHediyeCikis}).
+		Order("tarih asc, id asc").Find(&islemler).Error
+	if err != nil {
+		return nil, fmt.Errorf("çıkış işlemleri getirilemedi: %w", err)
+	}
+
+	cikislar := make(map[string]*GerceklesenCikis)
+	var sira []string
+	for _, islem := range islemler {
+		// Referanssız eski kayıtlar kendi başına bir çıkıştır
+		referans := islem.Referans
+		if referans == "" {
+			referans = fmt.Sprintf("ISL-%d", islem.ID)
+		}
+
+		cikis, exists := cikislar[referans]
+		if !exists {
+			cikis = &GerceklesenCikis{
+				Referans: referans,
+				Tarih:    islem.Tarih,
+				Kod:      islem.Kod,
+				Tip:      islem.Tip,
+				Yontem:   islem.Yontem,
+			}
+			cikislar[referans] = cikis
+			sira = append(sira, referans)
+		}
+		cikis.Miktar = cikis.Miktar.Add(islem.Miktar)
+		cikis.Tutar = cikis.Tutar.Add(islem.Tutar)
+		cikis.Maliyet = cikis.Maliyet.Add(islem.Maliyet)
+		cikis.KarZarar = cikis.KarZarar.Add(islem.GerceklesenKarZarar)
+		cikis.LotSayisi++
+	}
+
+	sonuc := make([]GerceklesenCikis, 0, len(sira))
+	for _, referans := range sira {
+		sonuc = append(sonuc, *cikislar[referans])
+	}
+	sort.SliceStable(sonuc, func(i, j int) bool {
+		return sonuc[i].Tarih.After(sonuc[j].Tarih)
+	})
+	return sonuc, nil
+}
+
+// gecerliMaliyetYontemi yöntemin desteklenip desteklenmediğini kontrol eder
+func gecerliMaliyetYontemi(yontem string) bool {
+	for _, y := range models.MaliyetYontemleri {
+		if y.Kod == yontem {
+			return true
+		}
+	}
+	return false
+}
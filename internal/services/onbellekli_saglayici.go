@@ -0,0 +1,121 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"altintakip/internal/logger"
+)
+
+// varsayilanFiyatOnbellekSuresi PRICE_CACHE_TTL boş bırakıldığında çekilen fiyatların yeniden kullanılacağı süre
+const varsayilanFiyatOnbellekSuresi = time.Minute
+
+// onbellekliSaglayici sağlayıcının son fiyat görüntüsünü süre dolana kadar saklar. Böylece aynı süreçteki
+// güncelleyici, ekleme/düzenleme formları ve komutlar her işlemde API'ye yeniden gitmez.
+type onbellekliSaglayici struct {
+	PriceProvider
+
+	sure  time.Duration
+	simdi func() time.Time
+
+	mu       sync.Mutex // Eşzamanlı isteklerden yalnızca biri API'ye gider, diğerleri sonucunu bekler
+	fiyatlar *AltinFiyatlari
+	alinma   time.Time
+}
+
+// kayitliFiyatKaynagi son başarılı fiyatları diskte saklayan sağlayıcılar; bellekteki görüntü boşken
+// (yeni başlayan komut) başka bir sürecin süresi dolmamış çekimi buradan kullanılır
+type kayitliFiyatKaynagi interface {
+	kayitliFiyatlar() (*AltinFiyatlari, error)
+}
+
+// yenilenebilirSaglayici süresi dolmamış görüntüyü atlayıp fiyatları yeniden çekebilen sağlayıcılar
+type yenilenebilirSaglayici interface {
+	Yenile() (*AltinFiyatlari, error)
+}
+
+// newOnbellekliSaglayici sağlayıcıyı verilen süreli önbellekle sarar
+func newOnbellekliSaglayici(saglayici PriceProvider, sure time.Duration) *onbellekliSaglayici {
+	return &onbellekliSaglayici{
+		PriceProvider: saglayici,
+		sure:          sure,
+		simdi:         time.Now,
+	}
+}
+
+// GetFiyatlar süresi dolmamış son görüntüyü, yoksa sağlayıcıdan yeni çekilen görüntüyü döner.
+// Dönen görüntü çağıranlar arasında paylaşıldığından değiştirilmemelidir.
+func (s *onbellekliSaglayici) GetFiyatlar() (*AltinFiyatlari, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fiyatlar != nil && s.simdi().Sub(s.alinma) < s.sure {
+		logger.Debugf("Fiyatlar önbellekten kullanılıyor (%s önce çekildi)", s.simdi().Sub(s.alinma).Round(time.Second))
+		return s.fiyatlar, nil
+	}
+
+	if s.fiyatlar == nil {
+		if kaynak, ok := s.PriceProvider.(kayitliFiyatKaynagi); ok {
+			kayitli, err := kaynak.kayitliFiyatlar()
+			if err != nil {
+				logger.Warnf("%v", err)
+			} else if kayitli != nil && s.simdi().Sub(kayitli.EnEskiZaman()) < s.sure {
+				logger.Debugf("Fiyatlar kayıtlı son çekimden kullanılıyor (%s)", kayitli.EnEskiZaman().Format("15:04:05"))
+				s.fiyatlar, s.alinma = kayitli, kayitli.EnEskiZaman()
+				return s.fiyatlar, nil
+			}
+		}
+	}
+
+	return s.cek()
+}
+
+// Yenile önbelleğin süresine bakmadan fiyatları sağlayıcıdan çeker ve önbelleği yeniler (F5, otomatik güncelleme)
+func (s *onbellekliSaglayici) Yenile() (*AltinFiyatlari, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cek()
+}
+
+// cek fiyatları sağlayıcıdan çeker; hatalar önbelleğe alınmaz. Görüntünün yaşı en eski grubundan
+// sayılır: alınamayıp son başarılı fiyatlarla tamamlanan gruplar bayatsa görüntü önbellekte
+// tutulmaz, sonraki istekte yeniden denenir. Kilit tutulurken çağrılmalıdır.
+func (s *onbellekliSaglayici) cek() (*AltinFiyatlari, error) {
+	fiyatlar, err := s.PriceProvider.GetFiyatlar()
+	if err != nil {
+		return nil, err
+	}
+	alinma := s.simdi()
+	if enEski := fiyatlar.EnEskiZaman(); !enEski.IsZero() && enEski.Before(alinma) {
+		alinma = enEski
+	}
+	s.fiyatlar, s.alinma = fiyatlar, alinma
+	return fiyatlar, nil
+}
+
+// fiyatOnbellekSuresi PRICE_CACHE_TTL ayarını okur; 0 önbelleği kapatır
+func fiyatOnbellekSuresi() time.Duration {
+	deger := strings.TrimSpace(os.Getenv("PRICE_CACHE_TTL"))
+	if deger == "" {
+		return varsayilanFiyatOnbellekSuresi
+	}
+	if deger == "0" {
+		return 0
+	}
+	sure, err := time.ParseDuration(deger)
+	if err != nil || sure < 0 {
+		logger.Warnf("Geçersiz PRICE_CACHE_TTL değeri: %s, varsayılan kullanılıyor: %s", deger, varsayilanFiyatOnbellekSuresi)
+		return varsayilanFiyatOnbellekSuresi
+	}
+	return sure
+}
+
+// tazeFiyatlar sağlayıcı önbellekliyse önbelleği atlayarak, değilse doğrudan fiyatları çeker
+func tazeFiyatlar(saglayici PriceProvider) (*AltinFiyatlari, error) {
+	if yenilenebilir, ok := saglayici.(yenilenebilirSaglayici); ok {
+		return yenilenebilir.Yenile()
+	}
+	return saglayici.GetFiyatlar()
+}
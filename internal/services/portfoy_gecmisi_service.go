@@ -0,0 +1,246 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
+)
+
+// gunFormati günlük özetlerde kullanılan tarih formatı
+const gunFormati = "2006-01-02"
+
+// Dönem tipleri
+const (
+	DonemHafta = "hafta"
+	DonemAy    = "ay"
+)
+
+// DonemDegisim bir dönemin (hafta/ay) sonundaki portföy değeri ve önceki döneme göre değişimi
+type DonemDegisim struct {
+	Donem        string          `json:"donem"`         // "2026-H42" veya "2026-10"
+	SonGun       string          `json:"son_gun"`       // Dönemin son özet günü
+	ToplamAlis   decimal.Decimal `json:"toplam_alis"`   // Dönem sonundaki toplam alış
+	GuncelTutar  decimal.Decimal `json:"guncel_tutar"`  // Dönem sonundaki TL değeri
+	KarZarar     decimal.Decimal `json:"kar_zarar"`     // Dönem sonundaki kar/zarar
+	Degisim      decimal.Decimal `json:"degisim"`       // Önceki döneme göre TL değer değişimi
+	DegisimYuzde decimal.Decimal `json:"degisim_yuzde"` // Önceki döneme göre değişim yüzdesi
+}
+
+// PortfoyGecmisiService günlük portföy özetlerini yönetir
+type PortfoyGecmisiService struct{}
+
+// NewPortfoyGecmisiService yeni portföy geçmişi servisi oluşturur
+func NewPortfoyGecmisiService() *PortfoyGecmisiService {
+	return &PortfoyGecmisiService{}
+}
+
+// SaveGunlukOzet verilen envanter kayıtlarından günün özetini yazar (aynı gün varsa günceller)
+func (s *PortfoyGecmisiService) SaveGunlukOzet(gun time.Time, envanterler []models.Envanter) error {
+	tarih := gun.Format(gunFormati)
+	satirlar := map[string]*models.PortfoyGunluk{}
+	ekle := func(seviye, anahtar string, envanter models.Envanter) {
+		key := seviye + "|" + anahtar
+		satir, exists := satirlar[key]
+		if !exists {
+			satir = &models.PortfoyGunluk{Tarih: tarih, Seviye: seviye, Anahtar: anahtar}
+			satirlar[key] = satir
+		}
+		satir.ToplamAlis = satir.ToplamAlis.Add(envanter.ToplamAlis)
+		satir.GuncelTutar = satir.GuncelTutar.Add(envanter.GuncelTutar)
+		satir.KarZarar = satir.KarZarar.Add(envanter.KarZarar)
+	}
+
+	// Envanter boş olsa bile toplam satırı yazılır
+	satirlar[models.SeviyeToplam+"|TOPLAM"] = &models.PortfoyGunluk{Tarih: tarih, Seviye: models.SeviyeToplam, Anahtar: "TOPLAM"}
+	for _, envanter := range envanterler {
+		ekle(models.SeviyeToplam, "TOPLAM", envanter)
+		ekle(models.SeviyeTur, envanter.Tur, envanter)
+		ekle(models.SeviyeKod, envanter.Kod, envanter)
+	}
+
+	kayitlar := make([]models.PortfoyGunluk, 0, len(satirlar))
+	for _, satir := range satirlar {
+		kayitlar = append(kayitlar, *satir)
+	}
+
+	err := database.GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tarih"}, {Name: "seviye"}, {Name: "anahtar"}},
+		DoUpdates: clause.AssignmentColumns([]string{"toplam_alis", "guncel_tutar", "kar_zarar", "updated_at"}),
+	}).Create(&kayitlar).Error
+	if err != nil {
+		return fmt.Errorf("günlük portföy özeti kaydedilemedi: %w", err)
+	}
+
+	logger.Debugf("Günlük portföy özeti kaydedildi: %s (%d satır)", tarih, len(kayitlar))
+	return nil
+}
+
+// TamamlaEksikGunler son özetten dünün sonuna kadar eksik günleri fiyat geçmişinden
+// yeniden oluşturur. Hiç özet yoksa fiyat geçmişinin ilk gününden başlar.
+// Eklenen gün sayısını döner.
+func (s *PortfoyGecmisiService) TamamlaEksikGunler(bugun time.Time) (int, error) {
+	bugun = gunBasi(bugun)
+
+	baslangic, err := s.eksikBaslangicGunu()
+	if err != nil || baslangic.IsZero() || !baslangic.Before(bugun) {
+		return 0, err
+	}
+
+	// Lotlar (kapanmış olanlar dahil) ve tüm çıkış işlemleri
+	var lotlar []models.Envanter
+	if err := database.GetDB().Find(&lotlar).Error; err != nil {
+		return 0, fmt.Errorf("envanter kayıtları getirilemedi: %w", err)
+	}
+	var cikislar []models.Islem
+	err = database.GetDB().Where("tip IN ?", []string{models.IslemSatis, models.IslemHediyeCikis}).Find(&cikislar).Error
+	if err != nil {
+		return 0, fmt.Errorf("çıkış işlemleri getirilemedi: %w", err)
+	}
+
+	fiyatGecmisi := NewFiyatGecmisiService()
+	degerlemeModu := DegerlemeModu()
+	eklenen := 0
+	for gun := baslangic; gun.Before(bugun); gun = gun.AddDate(0, 0, 1) {
+		gunSonu := gun.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		envanterler := lotlarGunSonunda(lotlar, cikislar, gunSonu)
+
+		// Fiyat geçmişinde o güne kadar fiyatı olmayan kodlar alış maliyetiyle değerlenir
+		for i := range envanterler {
+			fiyat, err := fiyatGecmisi.GetFiyatAt(envanterler[i].Kod, gunSonu)
+			if err != nil {
+				return eklenen, err
+			}
+			envanterler[i].GuncelFiyat = envanterler[i].AlisFiyati
+			if fiyat != nil {
+				envanterler[i].GuncelFiyat = DegerlemeFiyati(decimal.NewFromFloat(fiyat.Alis), decimal.NewFromFloat(fiyat.Satis), degerlemeModu)
+			}
+			envanterler[i].GuncelDegerleriHesapla()
+		}
+
+		if err := s.SaveGunlukOzet(gun, envanterler); err != nil {
+			return eklenen, err
+		}
+		eklenen++
+	}
+
+	if eklenen > 0 {
+		logger.Infof("Eksik %d günlük portföy özeti tamamlandı (%s itibarıyla)", eklenen, baslangic.Format(gunFormati))
+	}
+	return eklenen, nil
+}
+
+// eksikBaslangicGunu özetlenmesi gereken ilk günü bulur
+func (s *PortfoyGecmisiService) eksikBaslangicGunu() (time.Time, error) {
+	var sonOzet []models.PortfoyGunluk
+	err := database.GetDB().Where("seviye = ?", models.SeviyeToplam).Order("tarih desc").Limit(1).Find(&sonOzet).Error
+	if err != nil {
+		return time.Time{}, fmt.Errorf("son günlük özet getirilemedi: %w", err)
+	}
+	if len(sonOzet) > 0 {
+		sonGun, err := time.ParseInLocation(gunFormati, sonOzet[0].Tarih, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("geçersiz özet tarihi: %w", err)
+		}
+		return sonGun.AddDate(0, 0, 1), nil
+	}
+
+	var ilkFiyat []models.FiyatGecmisi
+	if err := database.GetDB().Order("cekilme_zamani asc").Limit(1).Find(&ilkFiyat).Error; err != nil {
+		return time.Time{}, fmt.Errorf("fiyat geçmişi getirilemedi: %w", err)
+	}
+	if len(ilkFiyat) == 0 {
+		return time.Time{}, nil
+	}
+	return gunBasi(ilkFiyat[0].CekilmeZamani), nil
+}
+
+// lotlarGunSonunda lotların belirtilen andaki miktarlarını, sonraki çıkışları geri ekleyerek hesaplar
+func lotlarGunSonunda(lotlar []models.Envanter, cikislar []models.Islem, an time.Time) []models.Envanter {
+	sonrakiCikislar := map[uint]decimal.Decimal{}
+	for _, cikis := range cikislar {
+		if cikis.Tarih.After(an) {
+			sonrakiCikislar[cikis.EnvanterID] = sonrakiCikislar[cikis.EnvanterID].Add(cikis.Miktar)
+		}
+	}
+
+	var sonuc []models.Envanter
+	for _, lot := range lotlar {
+		if lot.AlisTarihi.After(an) {
+			continue
+		}
+		lot.Miktar = lot.Miktar.Add(sonrakiCikislar[lot.ID])
+		if !lot.Miktar.IsPositive() {
+			continue
+		}
+		lot.ToplamAlis = lot.Miktar.Mul(lot.AlisFiyati)
+		sonuc = append(sonuc, lot)
+	}
+	return sonuc
+}
+
+// GetDonemselDegisimler toplam portföy değerinin haftalık veya aylık değişimini
+// eskiden yeniye, en fazla son "adet" dönem için döner
+func (s *PortfoyGecmisiService) GetDonemselDegisimler(donemTipi string, adet int) ([]DonemDegisim, error) {
+	var ozetler []models.PortfoyGunluk
+	err := database.GetDB().Where("seviye = ?", models.SeviyeToplam).Order("tarih asc").Find(&ozetler).Error
+	if err != nil {
+		return nil, fmt.Errorf("günlük özetler getirilemedi: %w", err)
+	}
+
+	// Her dönemin son günündeki özet dönem kapanışıdır
+	var donemler []DonemDegisim
+	for _, ozet := range ozetler {
+		gun, err := time.ParseInLocation(gunFormati, ozet.Tarih, time.Local)
+		if err != nil {
+			continue
+		}
+		donem := donemAnahtari(gun, donemTipi)
+
+		kapanis := DonemDegisim{
+			Donem:       donem,
+			SonGun:      ozet.Tarih,
+			ToplamAlis:  ozet.ToplamAlis,
+			GuncelTutar: ozet.GuncelTutar,
+			KarZarar:    ozet.KarZarar,
+		}
+		if n := len(donemler); n > 0 && donemler[n-1].Donem == donem {
+			donemler[n-1] = kapanis
+		} else {
+			donemler = append(donemler, kapanis)
+		}
+	}
+
+	for i := 1; i < len(donemler); i++ {
+		onceki := donemler[i-1].GuncelTutar
+		donemler[i].Degisim = donemler[i].GuncelTutar.Sub(onceki)
+		if !onceki.IsZero() {
+			donemler[i].DegisimYuzde = donemler[i].Degisim.Mul(decimal.NewFromInt(100)).DivRound(onceki, 4)
+		}
+	}
+
+	if adet > 0 && len(donemler) > adet {
+		donemler = donemler[len(donemler)-adet:]
+	}
+	return donemler, nil
+}
+
+// donemAnahtari günün ait olduğu haftayı (ISO) veya ayı döner
+func donemAnahtari(gun time.Time, donemTipi string) string {
+	if donemTipi == DonemAy {
+		return gun.Format("2006-01")
+	}
+	yil, hafta := gun.ISOWeek()
+	return fmt.Sprintf("%d-H%02d", yil, hafta)
+}
+
+// gunBasi verilen zamanın yerel gün başlangıcını döner
+func gunBasi(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"altintakip/internal/database"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+)
+
+// PortfoyService portföy (varlık sahibi) kayıtlarını yönetir
+type PortfoyService struct{}
+
+// NewPortfoyService yeni portföy servisi oluşturur
+func NewPortfoyService() *PortfoyService {
+	return &PortfoyService{}
+}
+
+// GetPortfoyler tüm portföyleri oluşturulma sırasıyla getirir
+func (s *PortfoyService) GetPortfoyler() ([]models.Portfoy, error) {
+	var portfoylar []models.Portfoy
+	if err := database.GetDB().Order("id asc").Find(&portfoylar).Error; err != nil {
+		return nil, fmt.Errorf("portföyler getirilemedi: %w", err)
+	}
+	return portfoylar, nil
+}
+
+// PortfoyBul portföyü adından veya ID'sinden bulur
+func (s *PortfoyService) PortfoyBul(adVeyaID string) (*models.Portfoy, error) {
+	adVeyaID = strings.TrimSpace(adVeyaID)
+
+	var portfoylar []models.Portfoy
+	if err := database.GetDB().Where("ad = ?", adVeyaID).Limit(1).Find(&portfoylar).Error; err != nil {
+		return nil, fmt.Errorf("portföy getirilemedi: %w", err)
+	}
+	if len(portfoylar) == 0 {
+		if id, err := strconv.ParseUint(adVeyaID, 10, 64); err == nil {
+			if err := database.GetDB().Where("id = ?", id).Limit(1).Find(&portfoylar).Error; err != nil {
+				return nil, fmt.Errorf("portföy getirilemedi: %w", err)
+			}
+		}
+	}
+	if len(portfoylar) == 0 {
+		return nil, fmt.Errorf("portföy bulunamadı: %s", adVeyaID)
+	}
+	return &portfoylar[0], nil
+}
+
+// AddPortfoy yeni portföy ekler
+func (s *PortfoyService) AddPortfoy(ad, notlar string) (*models.Portfoy, error) {
+	ad = strings.TrimSpace(ad)
+	if ad == "" {
+		return nil, fmt.Errorf("portföy adı boş olamaz")
+	}
+	if err := s.adKullanilmiyor(ad, 0); err != nil {
+		return nil, err
+	}
+
+	portfoy := models.Portfoy{Ad: ad, Notlar: notlar}
+	if err := database.GetDB().Create(&portfoy).Error; err != nil {
+		return nil, fmt.Errorf("portföy eklenemedi: %w", err)
+	}
+
+	logger.Infof("Portföy eklendi: %s (ID %d)", portfoy.Ad, portfoy.ID)
+	database.DegisiklikSonrasi("portfoy")
+	return &portfoy, nil
+}
+
+// RenamePortfoy portföyün adını değiştirir
+func (s *PortfoyService) RenamePortfoy(id uint, yeniAd string) error {
+	yeniAd = strings.TrimSpace(yeniAd)
+	if yeniAd == "" {
+		return fmt.Errorf("portföy adı boş olamaz")
+	}
+	if err := s.adKullanilmiyor(yeniAd, id); err != nil {
+		return err
+	}
+
+	sonuc := database.GetDB().Model(&models.Portfoy{}).Where("id = ?", id).Update("ad", yeniAd)
+	if sonuc.Error != nil {
+		return fmt.Errorf("portföy adı değiştirilemedi: %w", sonuc.Error)
+	}
+	if sonuc.RowsAffected == 0 {
+		return fmt.Errorf("portföy bulunamadı: ID %d", id)
+	}
+
+	logger.Infof("Portföy adı değiştirildi: ID %d = %s", id, yeniAd)
+	database.DegisiklikSonrasi("portfoy")
+	return nil
+}
+
+// DeletePortfoy portföyü siler. Lot geçmişi kaybolmasın diye hiç lotu olmayan
+// (satılmış veya silinmiş lotlar dahil) portföyler silinebilir; varsayılan portföy silinemez.
+func (s *PortfoyService) DeletePortfoy(id uint) error {
+	if id == models.VarsayilanPortfoyID {
+		return fmt.Errorf("varsayılan portföy silinemez (adı değiştirilebilir)")
+	}
+
+	var lotSayisi int64
+	if err := database.GetDB().Unscoped().Model(&models.Envanter{}).Where("portfoy_id = ?", id).Count(&lotSayisi).Error; err != nil {
+		return fmt.Errorf("portföy lotları sayılamadı: %w", err)
+	}
+	if lotSayisi > 0 {
+		return fmt.Errorf("portföyde satılmış ve çöp kutusundaki lotlar dahil %d lot kaydı var; portföy silinemez", lotSayisi)
+	}
+
+	sonuc := database.GetDB().Delete(&models.Portfoy{}, id)
+	if sonuc.Error != nil {
+		return fmt.Errorf("portföy silinemedi: %w", sonuc.Error)
+	}
+	if sonuc.RowsAffected == 0 {
+		return fmt.Errorf("portföy bulunamadı: ID %d", id)
+	}
+
+	logger.Infof("Portföy silindi: ID %d", id)
+	database.DegisiklikSonrasi("portfoy")
+	return nil
+}
+
+// adKullanilmiyor adın başka bir portföyde kullanılmadığını kontrol eder
+func (s *PortfoyService) adKullanilmiyor(ad string, haricID uint) error {
+	var sayi int64
+	if err := database.GetDB().Model(&models.Portfoy{}).Where("ad = ? AND id <> ?", ad, haricID).Count(&sayi).Error; err != nil {
+		return fmt.Errorf("portföy adı kontrol edilemedi: %w", err)
+	}
+	if sayi > 0 {
+		return fmt.Errorf("bu isimde bir portföy zaten var: %s", ad)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"altintakip/internal/logger"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Tetiklenen alarmın durum çubuğunda yanıp sönme ve görünür kalma süreleri
+const (
+	alarmYanipSonmeSayisi  = 6
+	alarmYanipSonmeAraligi = 400 * time.Millisecond
+	alarmGosterimSuresi    = time.Minute
+)
+
+// alarmlariDegerlendir fiyat yenilemesinden sonra alarmları değerlendirir (arka planda çağrılır)
+func (a *App) alarmlariDegerlendir() []services.AlarmOlayi {
+	olaylar, err := services.NewAlarmService().Degerlendir()
+	if err != nil {
+		logger.Errorf("Alarmlar değerlendirilemedi: %v", err)
+		return nil
+	}
+	return olaylar
+}
+
+// alarmGoster tetiklenen alarmları durum çubuğunda yanıp söndürerek gösterir.
+// UI goroutine'inde çağrılmalıdır.
+func (a *App) alarmGoster(olaylar []services.AlarmOlayi) {
+	if len(olaylar) == 0 || a.durumCubugu == nil {
+		return
+	}
+
+	mesajlar := make([]string, len(olaylar))
+	for i, olay := range olaylar {
+		mesajlar[i] = olay.Mesaj
+		if len(olay.Hatalar) > 0 {
+			mesajlar[i] += " [bildirim hatası: " + strings.Join(olay.Hatalar, "; ") + "]"
+		}
+	}
+	a.alarmSayaci++
+	sayac := a.alarmSayaci
+
+	a.durumCubugu.SetText(fmt.Sprintf("🔔 ALARM %s: %s", time.Now().Format("15:04"), strings.Join(mesajlar, " | ")))
+	a.durumCubugu.SetTextColor(tcell.ColorWhite)
+	a.durumCubugu.SetBackgroundColor(tcell.ColorRed)
+
+	go func() {
+		for i := 1; i <= alarmYanipSonmeSayisi; i++ {
+			time.Sleep(alarmYanipSonmeAraligi)
+			arkaPlan := tcell.ColorRed
+			if i%2 == 1 {
+				arkaPlan = tcell.ColorBlack
+			}
+			a.app.QueueUpdateDraw(func() {
+				if a.alarmSayaci == sayac {
+					a.durumCubugu.SetBackgroundColor(arkaPlan)
+				}
+			})
+		}
+
+		// Bir süre kırmızı yazıyla kalır, daha yeni bir alarm yoksa temizlenir
+		a.app.QueueUpdateDraw(func() {
+			if a.alarmSayaci == sayac {
+				a.durumCubugu.SetBackgroundColor(tcell.ColorBlack)
+				a.durumCubugu.SetTextColor(tcell.ColorRed)
+			}
+		})
+		time.Sleep(alarmGosterimSuresi)
+		a.app.QueueUpdateDraw(func() {
+			if a.alarmSayaci == sayac {
+				a.durumCubugu.SetText("")
+			}
+		})
+	}()
+}
@@ -2,11 +2,11 @@ package tui
 
 import (
 	"fmt"
-	"log"
 	"sort"
-	"strings"
 	"time"
 
+	"altintakip/internal/format"
+	"altintakip/internal/logger"
 	"altintakip/internal/models"
 	"altintakip/internal/services"
 
@@ -15,12 +15,6 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-// CinsItem her cins için hem görünen isim hem de API kodu tutar
-type CinsItem struct {
-	Name string // Görünen isim
-	Code string // API kodu
-}
-
 // Sabit değerler
 var (
 	appVersion = "v1.0.1"
@@ -28,67 +22,11 @@ var (
 
 	birimOptions = []string{"gram", "adet", "kilogram", "ons"}
 
+	girisTipiOptions = []string{"Alış", "Hediye Girişi"}
+	cikisTipiOptions = []string{"Satış", "Hediye Çıkışı"}
+
 	// kod eşleştirmeleri REST API'den yüklenecek
-	cinsMapping = map[string][]CinsItem{}
-
-	// Fallback mapping (REST API kodları ve MobilAciklama ile statik olarak kullanılıyor)
-	fallbackCinsMapping = map[string][]CinsItem{
-		"Altın": {
-			// Ana gram/külçe altınlar (DataGroup=2, Main=true)
-			{"Has Altın", "HH"},
-			{"Külçe / Kesme", "CH"},
-			{"24 Ayar Gram", "GA"},
-			{"22 Ayar Gram", "GAT22"},
-			{"22 Ayar Hurda / Bilezik", "B"},
-
-			// Ana sikkeler (DataGroup=8, Main=true)
-			{"Çeyrek", "C"},
-			{"Yarım", "Y"},
-			{"Tam (Teklik)", "T"},
-			{"Gremse", "G"},
-			{"Ata Cumhuriyet", "A"},
-			{"Reşat", "R"},
-			{"Hamit", "H"},
-
-			// Eski sikkeler (DataGroup=9)
-			{"Eski Çeyrek", "EC"},
-			{"Eski Yarım", "EY"},
-			{"Eski Tam", "ET"},
-			{"Eski Gremse", "EG"},
-			{"Eski Ata Cumhuriyet", "EA"},
-
-			// Diğer ayarlar
-			{"18 Ayar", "18"},
-			{"14 Ayar", "14"},
-		},
-		"Gümüş": {
-			// Gümüş ürünleri (DataGroup=7)
-			{"Granül Gümüş", "SG"},
-			{"Külçe (50 GR)", "AG50"},
-			{"Külçe (500 GR)", "AG500"},
-			{"Külçe (1 KG)", "AG1000"},
-		},
-		"Döviz": {
-			// Ana dövizler (DataGroup=1, Main=true)
-			{"Dolar (USD)", "USD"},
-			{"EURO", "EUR"},
-			{"Sterlin (GBP)", "GBP"},
-
-			// Diğer popüler dövizler
-			{"İsviçre Frangı", "CHF"},
-			{"Japon Yeni", "JPY"},
-			{"S. Arabistan Riyali", "SAR"},
-			{"Avustralya Doları", "AUD"},
-			{"Kanada Doları", "CAD"},
-			{"Rus Rublesi", "RUB"},
-			{"Azerbaycan Manatı", "AZN"},
-			{"Çin Yuanı", "CNY"},
-			{"Romanya Leyi", "RON"},
-			{"B.A.E. Dirhemi", "AED"},
-			{"Bulgar Levası", "BGN"},
-			{"Kuweyt Dinarı", "KWD"},
-		},
-	}
+	cinsMapping = map[string][]models.CinsItem{}
 )
 
 // App TUI uygulaması yapısı
@@ -100,6 +38,20 @@ type App struct {
 	pages     *tview.Pages
 	mainFlex  *tview.Flex
 
+	// Gerçekleşen kar/zarar paneli ve grup tablosundaki satırların kodları
+	gerceklesenTable *tview.Table
+	grupKodlari      []string
+
+	// Envanter, grup ve özet tablolarının çizildiği son görüntü; tablo satırları bu görüntüdeki lotlara karşılık gelir
+	gorunum *services.EnvanterGorunumu
+
+	// Son fiyat görüntüsündeki ürünler (günlük değişim, fiyat zamanı ve durum sütunları için)
+	fiyatKalemleri map[string]*services.RestPriceItem
+
+	// Grafik ve değişiklik geçmişi kapandığında focus'un döneceği tablo
+	grafikReturnFocus tview.Primitive
+	gecmisReturnFocus tview.Primitive
+
 	// Scroll indicator'lar
 	envanterScrollIndicator *tview.TextView
 	grupScrollIndicator     *tview.TextView
@@ -111,6 +63,18 @@ type App struct {
 
 	// Liste modu (offline mod)
 	isListMode bool
+
+	// Görüntülenen portföy (0: tüm portföyler) ve portföy listesi
+	portfoyID  uint
+	portfoylar []models.Portfoy
+
+	// Tetiklenen alarmların gösterildiği durum çubuğu ve son gösterimin sırası
+	durumCubugu *tview.TextView
+	alarmSayaci int
+
+	// Durum çubuğunun sağında gösterilen fiyatların yaşı ve son güncellemenin başarısız olup olmadığı
+	fiyatDurumu *tview.TextView
+	fiyatHatasi bool
 }
 
 // NewApp yeni TUI uygulaması oluşturur
@@ -135,19 +99,22 @@ func (a *App) Run() error {
 	a.loadProductMappings()
 
 	// Liste modu değilse güncel fiyatları çek
+	var acilisAlarmlari []services.AlarmOlayi
 	if !a.isListMode {
-		log.Printf("Uygulama başlatılıyor, güncel fiyatlar getiriliyor...")
+		logger.Infof("Uygulama başlatılıyor, güncel fiyatlar getiriliyor...")
 		err := a.envanterService.UpdateGuncelFiyatlar()
+		a.fiyatHatasi = err != nil
 		if err != nil {
-			log.Printf("UYARI: Güncel fiyatlar alınamadı: %v", err)
+			logger.Warnf("Güncel fiyatlar alınamadı: %v", err)
 		} else {
-			log.Printf("Güncel fiyatlar başarıyla güncellendi")
+			logger.Infof("Güncel fiyatlar başarıyla güncellendi")
+			acilisAlarmlari = a.alarmlariDegerlendir()
 		}
 
 		// Otomatik güncelleme başlat (dakikada bir) - liste modu değilse
 		a.startAutoUpdate()
 	} else {
-		log.Printf("Liste modu: Sadece veritabanındaki veriler gösterilecek")
+		logger.Infof("Liste modu: Sadece veritabanındaki veriler gösterilecek")
 	}
 
 	// Terminal ortamını kontrol et
@@ -160,11 +127,13 @@ func (a *App) Run() error {
 	a.table.SetSelectable(true, false)
 	a.table.SetFixed(1, 0)                                                                                 // İlk satırı (header) sabit tut
 	a.table.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack)) // Seçili satır stilini ayarla
-	a.table.SetTitle(" 📊 ENVANTER ")
+	a.portfoyleriYukle()
+	a.envanterBasligi()
 	a.table.SetBorderColor(tcell.ColorBlue)
 	// Envanter tablosu focus aldığında grup tablosunun seçimini kaldır
 	a.table.SetFocusFunc(func() {
 		a.grupTable.SetSelectable(false, false)
+		a.gerceklesenTable.SetSelectable(false, false)
 		a.table.SetSelectable(true, false)
 		a.updateScrollIndicators()
 	})
@@ -190,6 +159,7 @@ func (a *App) Run() error {
 	// Grup tablosu focus aldığında kendisini seçilebilir yap ve envanter tablosunu seçilemez yap
 	a.grupTable.SetFocusFunc(func() {
 		a.table.SetSelectable(false, false)
+		a.gerceklesenTable.SetSelectable(false, false)
 		a.grupTable.SetSelectable(true, false)
 		a.grupTable.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack))
 		a.updateScrollIndicators()
@@ -210,12 +180,27 @@ func (a *App) Run() error {
 	a.ozetTable = tview.NewTable()
 	a.ozetTable.SetBorders(true)
 	a.ozetTable.SetSelectable(false, false)
-	a.ozetTable.SetTitle(" 💰 ÖZET BİLGİLER ")
+	a.ozetTable.SetTitle(fmt.Sprintf(" 💰 ÖZET BİLGİLER (Değerleme: %s) ", services.DegerlemeModuIsmi(a.envanterService.GetDegerlemeModu())))
 	a.ozetTable.SetBorderColor(tcell.ColorYellow)
 
+	// Gerçekleşen kar/zarar paneli (özet tablosunun yanında)
+	a.gerceklesenTable = tview.NewTable()
+	a.gerceklesenTable.SetBorder(true)
+	a.gerceklesenTable.SetSelectable(false, false)
+	a.gerceklesenTable.SetFixed(1, 0)
+	a.gerceklesenTable.SetTitle(" 📈 GERÇEKLEŞEN KAR/ZARAR (Y: Grup Maliyet Yöntemi) ")
+	a.gerceklesenTable.SetBorderColor(tcell.ColorFuchsia)
+	a.gerceklesenTable.SetFocusFunc(func() {
+		a.table.SetSelectable(false, false)
+		a.grupTable.SetSelectable(false, false)
+		a.gerceklesenTable.SetSelectable(true, false)
+		a.gerceklesenTable.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack))
+	})
+
 	// Başlıklar
 	headers := []string{
-		"TÜR", "CİNS", "MİKTAR", "ALIŞ TARİHİ", "ALIŞ FİYATI ₺", "TOPLAM ALIŞ ₺", "GÜNCEL FİYAT ₺", "GÜNCEL TUTAR ₺", "KAR/ZARAR ₺",
+		"TÜR", "CİNS", "MİKTAR", "ALIŞ TARİHİ", "ALIŞ FİYATI ₺", "TOPLAM ALIŞ ₺", "GÜNCEL FİYAT ₺", "GÜNCEL TUTAR ₺", "KAR/ZARAR ₺", "MAKAS ₺",
+		"GÜNLÜK %", "FİYAT ZAMANI", "KAYNAK",
 	}
 
 	for col, header := range headers {
@@ -226,11 +211,9 @@ func (a *App) Run() error {
 	}
 
 	// Verileri yükle
-	log.Printf("Veri yükleme işlemi başlatılıyor...")
-	a.loadData()
-	a.loadGrupData() // Grup analizini yükle
-	a.loadOzetData() // Özet verilerini yükle
-	log.Printf("Veri yükleme işlemi tamamlandı, TUI başlatılıyor...")
+	logger.Debugf("Veri yükleme işlemi başlatılıyor...")
+	a.anaTablolariYenile()
+	logger.Debugf("Veri yükleme işlemi tamamlandı, TUI başlatılıyor...")
 
 	// İlk başta envanter tablosuna focus ayarla
 	a.app.SetFocus(a.table)
@@ -238,29 +221,72 @@ func (a *App) Run() error {
 	// Klavye kısayolları
 	a.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		// Eğer modal açıksa ve Escape tuşuna basılmışsa, sadece modal'ı kapat
-		if a.pages.HasPage("add-form") || a.pages.HasPage("edit-form") || a.pages.HasPage("delete-confirm") || a.pages.HasPage("message") {
+		if a.pages.HasPage("add-form") || a.pages.HasPage("edit-form") || a.pages.HasPage("dispose-form") || a.pages.HasPage("export-form") || a.pages.HasPage("import-form") || a.pages.HasPage("import-preview") || a.pages.HasPage("rapor") || a.pages.HasPage("grafik") || a.pages.HasPage("gecmis") || a.pages.HasPage("delete-confirm") || a.pages.HasPage("cop-kutusu") || a.pages.HasPage("cop-onay") || a.pages.HasPage("portfoy-secici") || a.pages.HasPage("portfoy-form") || a.pages.HasPage("message") {
 			if event.Key() == tcell.KeyEscape {
 				// Hangi modal açıksa onu kapat
 				if a.pages.HasPage("add-form") {
 					a.pages.RemovePage("add-form")
 					a.clearAllTables()
 					a.app.ForceDraw()
-					a.loadData()
-					a.loadGrupData()
-					a.loadOzetData()
+					a.anaTablolariYenile()
 					a.app.SetFocus(a.table)
 				} else if a.pages.HasPage("edit-form") {
 					a.pages.RemovePage("edit-form")
 					a.clearAllTables()
 					a.app.ForceDraw()
-					a.loadData()
-					a.loadGrupData()
-					a.loadOzetData()
+					a.anaTablolariYenile()
+					a.app.SetFocus(a.table)
+				} else if a.pages.HasPage("dispose-form") {
+					a.pages.RemovePage("dispose-form")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.table)
+				} else if a.pages.HasPage("import-preview") {
+					a.closeImportPreview()
+				} else if a.pages.HasPage("import-form") {
+					a.pages.RemovePage("import-form")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.table)
+				} else if a.pages.HasPage("export-form") {
+					a.pages.RemovePage("export-form")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.table)
+				} else if a.pages.HasPage("grafik") {
+					a.pages.RemovePage("grafik")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.grafikReturnFocus)
+				} else if a.pages.HasPage("rapor") {
+					a.pages.RemovePage("rapor")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.table)
+				} else if a.pages.HasPage("portfoy-form") {
+					a.pages.RemovePage("portfoy-form")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.table)
+				} else if a.pages.HasPage("portfoy-secici") {
+					a.pages.RemovePage("portfoy-secici")
+					a.app.ForceDraw()
 					a.app.SetFocus(a.table)
 				} else if a.pages.HasPage("delete-confirm") {
 					a.pages.RemovePage("delete-confirm")
 					a.app.ForceDraw()
 					a.app.SetFocus(a.table)
+				} else if a.pages.HasPage("gecmis") {
+					a.pages.RemovePage("gecmis")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.gecmisReturnFocus)
+				} else if a.pages.HasPage("message") && a.pages.HasPage("cop-kutusu") {
+					// Çöp kutusu üzerindeki mesaj kapanınca çöp kutusu açık kalır
+					a.pages.RemovePage("message")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.pages)
+				} else if a.pages.HasPage("cop-onay") {
+					a.pages.RemovePage("cop-onay")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.pages)
+				} else if a.pages.HasPage("cop-kutusu") {
+					a.pages.RemovePage("cop-kutusu")
+					a.app.ForceDraw()
+					a.app.SetFocus(a.table)
 				} else if a.pages.HasPage("message") {
 					a.pages.RemovePage("message")
 					a.app.ForceDraw()
@@ -282,12 +308,14 @@ func (a *App) Run() error {
 			a.app.Stop()
 			return nil
 		case tcell.KeyTab:
-			// Tab ile sadece envanter ve grup tabloları arasında geçiş
+			// Tab ile envanter, grup ve gerçekleşen kar/zarar tabloları arasında geçiş
 			current := a.app.GetFocus()
 			switch current {
 			case a.table:
 				a.app.SetFocus(a.grupTable)
 			case a.grupTable:
+				a.app.SetFocus(a.gerceklesenTable)
+			case a.gerceklesenTable:
 				a.app.SetFocus(a.table)
 			default:
 				a.app.SetFocus(a.table)
@@ -300,20 +328,22 @@ func (a *App) Run() error {
 				return nil
 			}
 			go func() {
-				log.Printf("Manuel fiyat güncelleme başlatılıyor...")
+				logger.Infof("Manuel fiyat güncelleme başlatılıyor...")
 				err := a.envanterService.UpdateGuncelFiyatlar()
 				if err != nil {
-					log.Printf("Manuel fiyat güncelleme başarısız: %v", err)
+					logger.Errorf("Manuel fiyat güncelleme başarısız: %v", err)
 					a.app.QueueUpdateDraw(func() {
+						a.fiyatGuncellemeSonucu(err)
 						a.showMessage(fmt.Sprintf("Fiyat güncelleme başarısız: %v", err))
 					})
 				} else {
-					log.Printf("Manuel fiyat güncelleme başarılı")
+					logger.Infof("Manuel fiyat güncelleme başarılı")
+					olaylar := a.alarmlariDegerlendir()
 					a.app.QueueUpdateDraw(func() {
-						a.loadData()
-						a.loadGrupData()
-						a.loadOzetData()
+						a.anaTablolariYenile()
+						a.fiyatGuncellemeSonucu(nil)
 						a.showMessage("Fiyatlar başarıyla güncellendi!")
+						a.alarmGoster(olaylar)
 					})
 				}
 			}()
@@ -335,14 +365,55 @@ func (a *App) Run() error {
 				a.showDeleteConfirm()
 			}
 			return nil
+		case 'ç', 'Ç', 'c', 'C': // Satış / hediye çıkışı - envanter veya grup tablosunda
+			current := a.app.GetFocus()
+			if current == a.table || current == a.grupTable {
+				a.showDisposeForm()
+			}
+			return nil
+		case 'g', 'G': // Fiyat grafiği - envanter veya grup tablosunda
+			current := a.app.GetFocus()
+			if current == a.table || current == a.grupTable {
+				a.showGrafik()
+			}
+			return nil
+		case 'r', 'R': // Haftalık / aylık portföy değişim raporu
+			a.showRapor()
+			return nil
+		case 'i', 'I': // CSV içe aktarım
+			a.showImportForm()
+			return nil
+		case 'x', 'X': // JSON / CSV dışa aktarım
+			a.showExportForm()
+			return nil
+		case 'h', 'H': // Değişiklik geçmişi - sadece envanter tablosunda
+			if a.app.GetFocus() == a.table {
+				a.showSeciliGecmis()
+			}
+			return nil
+		case 'k', 'K': // Çöp kutusu - silinen kayıtları geri alma / kalıcı silme
+			a.showCopKutusu()
+			return nil
+		case 'p', 'P': // Portföy değiştirme / tüm portföyler
+			a.showPortfoySecici()
+			return nil
+		case 'y', 'Y': // Maliyet yöntemi değiştirme - sadece grup tablosunda
+			if a.app.GetFocus() == a.grupTable {
+				a.cycleMaliyetYontemi()
+			}
+			return nil
 		}
 		return event
 	})
 
-	// Layout oluştur - 3 tablo dikey olarak + alt boşluk
-	headerText := fmt.Sprintf("🏦 ALTIN TAKİP - %s (F5: Yenile, Tab: Tablolar Arası Geçiş, E: Ekle, D: Düzenle, S: Sil, Ctrl+Q: Çıkış)", appVersion)
+	// Durum çubuğu - tetiklenen alarmlar burada yanıp söner, sağında fiyatların yaşı gösterilir
+	a.durumCubugu = tview.NewTextView().SetTextAlign(tview.AlignLeft)
+	a.fiyatDurumu = tview.NewTextView().SetTextAlign(tview.AlignRight)
+
+	// Layout oluştur - 3 tablo dikey olarak + durum çubuğu
+	headerText := fmt.Sprintf("🏦 ALTIN TAKİP - %s (F5: Yenile, Tab: Tablolar Arası Geçiş, E: Ekle, D: Düzenle, Ç: Satış/Çıkış, Y: Maliyet Yöntemi, G: Grafik, R: Rapor, P: Portföy, I: İçe Aktar, X: Dışa Aktar, S: Sil, H: Geçmiş, K: Çöp Kutusu, Ctrl+Q: Çıkış)", appVersion)
 	if a.isListMode {
-		headerText = fmt.Sprintf("🏦 ALTIN TAKİP - %s (OFFLINE MOD - Tab: Tablolar Arası Geçiş, E: Ekle, D: Düzenle, S: Sil, Ctrl+Q: Çıkış)", appVersion)
+		headerText = fmt.Sprintf("🏦 ALTIN TAKİP - %s (OFFLINE MOD - Tab: Tablolar Arası Geçiş, E: Ekle, D: Düzenle, Ç: Satış/Çıkış, Y: Maliyet Yöntemi, G: Grafik, R: Rapor, P: Portföy, I: İçe Aktar, X: Dışa Aktar, S: Sil, H: Geçmiş, K: Çöp Kutusu, Ctrl+Q: Çıkış)", appVersion)
 	}
 
 	a.mainFlex = tview.NewFlex().SetDirection(tview.FlexRow).
@@ -363,12 +434,16 @@ func (a *App) Run() error {
 				AddItem(a.grupTable, 0, 1, false).
 				AddItem(a.grupScrollIndicator, 1, 0, false), 0, 2, false).
 		AddItem(a.ozetTable, 5, 0, false).
-		AddItem(tview.NewTextView(), 1, 0, false) // Alt boşluk
+		AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).
+			AddItem(a.durumCubugu, 0, 1, false).
+			AddItem(a.fiyatDurumu, 70, 0, false), 1, 0, false) // Alt satır: tetiklenen alarmlar ve fiyatların yaşı
 
 	// Pages ile modal yönetimi
 	a.pages.AddPage("main", a.mainFlex, true, true)
+	a.alarmGoster(acilisAlarmlari)
+	a.fiyatDurumunuGoster()
 
-	log.Printf("TUI başlatılıyor...")
+	logger.Infof("TUI başlatılıyor...")
 	a.app.SetRoot(a.pages, true)
 
 	// Root ayarlandıktan sonra focus ve seçimi ayarla
@@ -395,18 +470,57 @@ func (a *App) Run() error {
 	// Uygulama kapatılırken otomatik güncellemeyi durdur
 	a.stopAutoUpdate()
 
-	log.Printf("TUI sonlandı, hata: %v", result)
+	logger.Infof("TUI sonlandı, hata: %v", result)
 	return result
 }
 
-// loadData verileri yükler
-func (a *App) loadData() {
+// anaTablolariYenile seçili portföyün görüntüsünü bir kez okur; envanter, grup ve özet tabloları bu
+// görüntüden çizilir, ardından gerçekleşen kar/zarar paneli yenilenir
+func (a *App) anaTablolariYenile() {
+	gorunum, err := a.envanterServisi().GetGorunum()
+	if err != nil {
+		logger.Errorf("Veri yüklenemedi: %v", err)
+		a.gorunum = nil
+		a.table.Clear()
+		a.table.SetCell(0, 0, tview.NewTableCell(fmt.Sprintf("HATA: %v", err)).
+			SetTextColor(tcell.ColorRed).
+			SetAlign(tview.AlignCenter))
+		a.grupTable.Clear()
+		a.grupKodlari = a.grupKodlari[:0]
+		a.ozetTable.Clear()
+		return
+	}
+
+	a.gorunum = gorunum
+	a.fiyatKalemleriniYukle()
+	a.loadData(gorunum.Envanterler)
+	a.loadGrupData(gorunum.Gruplar)
+	a.loadOzetData(gorunum.Toplamlar)
+	a.loadGerceklesenData()
+	a.fiyatDurumunuGoster()
+}
+
+// satirEnvanteri envanter tablosundaki satırın (1'den başlar) son görüntüdeki lotunu döner
+func (a *App) satirEnvanteri(row int) (models.Envanter, bool) {
+	if a.gorunum == nil || row <= 0 || row-1 >= len(a.gorunum.Envanterler) {
+		return models.Envanter{}, false
+	}
+	return a.gorunum.Envanterler[row-1], true
+}
+
+// loadData envanter tablosunu görüntüdeki açık lotlarla doldurur
+func (a *App) loadData(envanterler []models.Envanter) {
 	// Tabloyu temizle
 	a.table.Clear()
 
 	// Başlıkları yeniden ekle
 	headers := []string{
-		"TÜR", "CİNS", "MİKTAR", "ALIŞ TARİHİ", "ALIŞ FİYATI ₺", "TOPLAM ALIŞ ₺", "GÜNCEL FİYAT ₺", "GÜNCEL TUTAR ₺", "KAR/ZARAR ₺",
+		"TÜR", "CİNS", "MİKTAR", "ALIŞ TARİHİ", "ALIŞ FİYATI ₺", "TOPLAM ALIŞ ₺", "GÜNCEL FİYAT ₺", "GÜNCEL TUTAR ₺", "KAR/ZARAR ₺", "MAKAS ₺",
+		"GÜNLÜK %", "FİYAT ZAMANI", "KAYNAK",
+	}
+	// Tüm portföyler görünümünde lotun sahibi son sütunda gösterilir
+	if a.portfoySutunuGoster() {
+		headers = append(headers, "PORTFÖY")
 	}
 
 	for col, header := range headers {
@@ -416,19 +530,7 @@ func (a *App) loadData() {
 			SetSelectable(false))
 	}
 
-	envanterService := services.NewEnvanterService()
-	envanterler, err := envanterService.GetAllEnvanterFromDB()
-	if err != nil {
-		log.Printf("Veri yüklenemedi: %v", err)
-
-		// Hata mesajı göster
-		a.table.SetCell(1, 0, tview.NewTableCell(fmt.Sprintf("HATA: %v", err)).
-			SetTextColor(tcell.ColorRed).
-			SetAlign(tview.AlignCenter))
-		return
-	}
-
-	log.Printf("Yüklenen envanter sayısı: %d", len(envanterler))
+	logger.Debugf("Yüklenen envanter sayısı: %d", len(envanterler))
 
 	// Veri yoksa bilgi göster
 	if len(envanterler) == 0 {
@@ -440,10 +542,10 @@ func (a *App) loadData() {
 
 	// Verileri tabloya ekle
 	for row, envanter := range envanterler {
-		karZarar := envanter.GuncelTutar - envanter.ToplamAlis
+		karZarar := envanter.GuncelTutar.Sub(envanter.ToplamAlis)
 		karZararColor := tcell.ColorGreen
 		karZararPrefix := "+"
-		if karZarar < 0 {
+		if karZarar.IsNegative() {
 			karZararColor = tcell.ColorRed
 			karZararPrefix = ""
 		}
@@ -454,19 +556,36 @@ func (a *App) loadData() {
 			cinsIsmi = envanter.Cins // Boş kod durumunda veritabanındaki cins ismini kullan
 		}
 
+		kalem := a.fiyatKalemi(envanter.Kod)
 		a.table.SetCell(row+1, 0, tview.NewTableCell(envanter.Tur))
-		a.table.SetCell(row+1, 1, tview.NewTableCell(cinsIsmi))
-		a.table.SetCell(row+1, 2, tview.NewTableCell(fmt.Sprintf("%s %s", formatQuantity(envanter.Miktar, envanter.Birim), envanter.Birim)))
+		a.table.SetCell(row+1, 1, cinsHucresi(cinsIsmi, kalem))
+		a.table.SetCell(row+1, 2, tview.NewTableCell(fmt.Sprintf("%s %s", format.Quantity(envanter.Miktar, envanter.Birim), envanter.Birim)))
 		a.table.SetCell(row+1, 3, tview.NewTableCell(envanter.AlisTarihi.Format("02.01.2006")))
-		a.table.SetCell(row+1, 4, tview.NewTableCell(formatMoney(envanter.AlisFiyati)))
-		a.table.SetCell(row+1, 5, tview.NewTableCell(formatMoney(envanter.ToplamAlis)))
-		a.table.SetCell(row+1, 6, tview.NewTableCell(formatMoney(envanter.GuncelFiyat)))
-		a.table.SetCell(row+1, 7, tview.NewTableCell(formatMoney(envanter.GuncelTutar)))
-		a.table.SetCell(row+1, 8, tview.NewTableCell(fmt.Sprintf("%s%s", karZararPrefix, formatMoney(karZarar))).
+		a.table.SetCell(row+1, 4, tview.NewTableCell(format.Money(envanter.AlisFiyati)))
+		a.table.SetCell(row+1, 5, tview.NewTableCell(format.Money(envanter.ToplamAlis)))
+		a.table.SetCell(row+1, 6, tview.NewTableCell(format.Money(envanter.GuncelFiyat)))
+		a.table.SetCell(row+1, 7, tview.NewTableCell(format.Money(envanter.GuncelTutar)))
+		a.table.SetCell(row+1, 8, tview.NewTableCell(fmt.Sprintf("%s%s", karZararPrefix, format.Money(karZarar))).
 			SetTextColor(karZararColor))
+
+		// Alış-satış makası: pozisyonu bugün yeniden almak ile satmak arasındaki fark
+		makas := "-"
+		if makasMaliyeti := envanter.MakasMaliyeti(); makasMaliyeti.IsPositive() {
+			makas = format.Money(makasMaliyeti)
+		}
+		a.table.SetCell(row+1, 9, tview.NewTableCell(makas).SetTextColor(tcell.ColorGray))
+
+		// Kuyumcunun bildirdiği günlük değişim, son fiyat zamanı ve fiyatın alındığı sağlayıcı
+		a.table.SetCell(row+1, 10, degisimHucresi(kalem))
+		a.table.SetCell(row+1, 11, fiyatZamaniHucresi(kalem))
+		a.table.SetCell(row+1, 12, kaynakHucresi(envanter.APIKaynak))
+
+		if a.portfoySutunuGoster() {
+			a.table.SetCell(row+1, 13, tview.NewTableCell(a.portfoyAdi(envanter.PortfoyID)).SetTextColor(tcell.ColorAqua))
+		}
 	}
 
-	log.Printf("Tablo verileri hazırlandı")
+	logger.Debugf("Tablo verileri hazırlandı")
 	a.updateScrollIndicators() // Scroll indicator'ları güncelle
 
 	// Envanter tablosunun seçilebilir olduğundan emin ol ve focus ayarla
@@ -476,24 +595,15 @@ func (a *App) loadData() {
 	}
 }
 
-// loadGrupData grup analizini yükler
-func (a *App) loadGrupData() {
-	envanterService := services.NewEnvanterService()
-	gruplar, err := envanterService.GetKodBazliGruplar()
-	if err != nil {
-		log.Printf("Grup verileri yüklenemedi: %v", err)
-		a.grupTable.SetCell(1, 0, tview.NewTableCell(fmt.Sprintf("HATA: %v", err)).
-			SetTextColor(tcell.ColorRed).
-			SetAlign(tview.AlignCenter))
-		return
-	}
-
+// loadGrupData grup tablosunu görüntüdeki kod bazlı gruplarla doldurur
+func (a *App) loadGrupData(gruplar map[string]map[string]interface{}) {
 	a.grupTable.Clear()
 
 	// Grup tablosu başlıkları
 	headers := []string{
 		"TÜR", "CİNS", "TOPLAM MİKTAR", "BİRİM", "ORT. ALIŞ FİYATI ₺",
-		"TOPLAM ALIŞ ₺", "TOPLAM GÜNCEL ₺", "TOPLAM KAR/ZARAR ₺", "KAR/ZARAR %",
+		"TOPLAM ALIŞ ₺", "TOPLAM GÜNCEL ₺", "GERÇEKLEŞMEMİŞ K/Z ₺", "K/Z %", "GERÇEKLEŞEN K/Z ₺",
+		"GÜNLÜK %", "FİYAT ZAMANI", "KAYNAK",
 	}
 
 	for col, header := range headers {
@@ -505,6 +615,7 @@ func (a *App) loadGrupData() {
 
 	// Grup verilerini slice'a çevir ve sırala
 	type GrupVeri struct {
+		Kod     string
 		Tur     string
 		Cins    string
 		Veriler map[string]interface{}
@@ -519,6 +630,7 @@ func (a *App) loadGrupData() {
 		}
 
 		grupSlice = append(grupSlice, GrupVeri{
+			Kod:     kod,
 			Tur:     veri["tur"].(string),
 			Cins:    cinsIsmi, // Kod alanından çevrilen veya veritabanındaki cins ismi
 			Veriler: veri,
@@ -534,50 +646,59 @@ func (a *App) loadGrupData() {
 	})
 
 	// Grup verilerini sırala ve göster
+	a.grupKodlari = a.grupKodlari[:0]
 	row := 1
 	for _, grupItem := range grupSlice {
+		a.grupKodlari = append(a.grupKodlari, grupItem.Kod)
 		veri := grupItem.Veriler
-		karZarar := veri["toplam_kar_zarar"].(float64)
-		karZararYuzde := veri["kar_zarar_yuzde"].(float64)
+		karZarar := veri["toplam_kar_zarar"].(decimal.Decimal)
+		karZararYuzde := veri["kar_zarar_yuzde"].(decimal.Decimal)
 		karZararColor := tcell.ColorGreen
 		karZararPrefix := "+"
-		if karZarar < 0 {
+		if karZarar.IsNegative() {
 			karZararColor = tcell.ColorRed
 			karZararPrefix = ""
 		}
 
+		kalem := a.fiyatKalemi(grupItem.Kod)
 		a.grupTable.SetCell(row, 0, tview.NewTableCell(veri["tur"].(string)))
-		a.grupTable.SetCell(row, 1, tview.NewTableCell(grupItem.Cins)) // Kod alanından çevrilen cins ismi
-		a.grupTable.SetCell(row, 2, tview.NewTableCell(formatQuantity(veri["toplam_miktar"].(float64), veri["birim"].(string))))
+		a.grupTable.SetCell(row, 1, cinsHucresi(grupItem.Cins, kalem)) // Kod alanından çevrilen cins ismi
+		a.grupTable.SetCell(row, 2, tview.NewTableCell(format.Quantity(veri["toplam_miktar"].(decimal.Decimal), veri["birim"].(string))))
 		a.grupTable.SetCell(row, 3, tview.NewTableCell(veri["birim"].(string)))
-		a.grupTable.SetCell(row, 4, tview.NewTableCell(formatMoney(veri["ortalama_alis_fiyati"].(float64))))
-		a.grupTable.SetCell(row, 5, tview.NewTableCell(formatMoney(veri["toplam_alis_tutar"].(float64))))
-		a.grupTable.SetCell(row, 6, tview.NewTableCell(formatMoney(veri["toplam_guncel_tutar"].(float64))))
-		a.grupTable.SetCell(row, 7, tview.NewTableCell(fmt.Sprintf("%s%s", karZararPrefix, formatMoney(karZarar))).
+		a.grupTable.SetCell(row, 4, tview.NewTableCell(format.Money(veri["ortalama_alis_fiyati"].(decimal.Decimal))))
+		a.grupTable.SetCell(row, 5, tview.NewTableCell(format.Money(veri["toplam_alis_tutar"].(decimal.Decimal))))
+		a.grupTable.SetCell(row, 6, tview.NewTableCell(format.Money(veri["toplam_guncel_tutar"].(decimal.Decimal))))
+		a.grupTable.SetCell(row, 7, tview.NewTableCell(fmt.Sprintf("%s%s", karZararPrefix, format.Money(karZarar))).
 			SetTextColor(karZararColor))
-		a.grupTable.SetCell(row, 8, tview.NewTableCell(fmt.Sprintf("%s%.2f%%", karZararPrefix, karZararYuzde)).
+		a.grupTable.SetCell(row, 8, tview.NewTableCell(fmt.Sprintf("%s%s%%", karZararPrefix, karZararYuzde.StringFixed(2))).
 			SetTextColor(karZararColor))
+
+		gerceklesen := veri["gerceklesen_kar_zarar"].(decimal.Decimal)
+		gerceklesenColor := tcell.ColorGreen
+		gerceklesenPrefix := "+"
+		if gerceklesen.IsNegative() {
+			gerceklesenColor = tcell.ColorRed
+			gerceklesenPrefix = ""
+		}
+		a.grupTable.SetCell(row, 9, tview.NewTableCell(fmt.Sprintf("%s%s", gerceklesenPrefix, format.Money(gerceklesen))).
+			SetTextColor(gerceklesenColor))
+		a.grupTable.SetCell(row, 10, degisimHucresi(kalem))
+		a.grupTable.SetCell(row, 11, fiyatZamaniHucresi(kalem))
+		a.grupTable.SetCell(row, 12, kaynakHucresi(veri["api_kaynak"].(string)))
 		row++
 	}
 
-	log.Printf("Grup tablosu hazırlandı")
+	logger.Debugf("Grup tablosu hazırlandı")
 	a.updateScrollIndicators() // Scroll indicator'ları güncelle
 }
 
-// loadOzetData özet verilerini yükler
-func (a *App) loadOzetData() {
-	envanterService := services.NewEnvanterService()
-	envanterler, err := envanterService.GetAllEnvanterFromDB()
-	if err != nil {
-		log.Printf("Özet verileri yüklenemedi: %v", err)
-		return
-	}
-
+// loadOzetData özet tablosunu görüntüdeki toplamlarla doldurur
+func (a *App) loadOzetData(toplamlar map[string]decimal.Decimal) {
 	a.ozetTable.Clear()
 
 	// Özet tablosu başlıkları
 	headers := []string{
-		"TOPLAM ALIŞ TUTARI ₺", "TOPLAM GÜNCEL TUTAR ₺", "TOPLAM KAR/ZARAR ₺", "TOPLAM KAR/ZARAR %",
+		"TOPLAM ALIŞ TUTARI ₺", "TOPLAM GÜNCEL TUTAR ₺", "GERÇEKLEŞMEMİŞ K/Z ₺", "GERÇEKLEŞMEMİŞ K/Z %", "GERÇEKLEŞEN K/Z ₺",
 	}
 
 	for col, header := range headers {
@@ -587,36 +708,37 @@ func (a *App) loadOzetData() {
 			SetSelectable(false))
 	}
 
-	// Toplam hesaplamaları
-	var toplamAlis, toplamGuncel, toplamKar float64
-	for _, envanter := range envanterler {
-		toplamAlis += envanter.ToplamAlis
-		toplamGuncel += envanter.GuncelTutar
-		toplamKar += (envanter.GuncelTutar - envanter.ToplamAlis)
-	}
-
-	var toplamKarYuzde float64
-	if toplamAlis > 0 {
-		toplamKarYuzde = (toplamKar / toplamAlis) * 100
-	}
+	toplamAlis := toplamlar["toplam_alis"]
+	toplamGuncel := toplamlar["toplam_guncel"]
+	toplamKar := toplamlar["toplam_kar"]
+	toplamKarYuzde := toplamlar["toplam_kar_yuzde"]
+	toplamGerceklesen := toplamlar["toplam_gerceklesen_kar"]
 
 	// Renk belirleme
 	karColor := tcell.ColorGreen
 	karPrefix := "+"
-	if toplamKar < 0 {
+	if toplamKar.IsNegative() {
 		karColor = tcell.ColorRed
 		karPrefix = ""
 	}
+	gerceklesenColor := tcell.ColorGreen
+	gerceklesenPrefix := "+"
+	if toplamGerceklesen.IsNegative() {
+		gerceklesenColor = tcell.ColorRed
+		gerceklesenPrefix = ""
+	}
 
 	// Özet satırını ekle
-	a.ozetTable.SetCell(1, 0, tview.NewTableCell(formatMoney(toplamAlis)))
-	a.ozetTable.SetCell(1, 1, tview.NewTableCell(formatMoney(toplamGuncel)))
-	a.ozetTable.SetCell(1, 2, tview.NewTableCell(fmt.Sprintf("%s%s", karPrefix, formatMoney(toplamKar))).
+	a.ozetTable.SetCell(1, 0, tview.NewTableCell(format.Money(toplamAlis)))
+	a.ozetTable.SetCell(1, 1, tview.NewTableCell(format.Money(toplamGuncel)))
+	a.ozetTable.SetCell(1, 2, tview.NewTableCell(fmt.Sprintf("%s%s", karPrefix, format.Money(toplamKar))).
 		SetTextColor(karColor))
-	a.ozetTable.SetCell(1, 3, tview.NewTableCell(fmt.Sprintf("%s%.2f%%", karPrefix, toplamKarYuzde)).
+	a.ozetTable.SetCell(1, 3, tview.NewTableCell(fmt.Sprintf("%s%s%%", karPrefix, toplamKarYuzde.StringFixed(2))).
 		SetTextColor(karColor))
+	a.ozetTable.SetCell(1, 4, tview.NewTableCell(fmt.Sprintf("%s%s", gerceklesenPrefix, format.Money(toplamGerceklesen))).
+		SetTextColor(gerceklesenColor))
 
-	log.Printf("Özet tablosu hazırlandı")
+	logger.Debugf("Özet tablosu hazırlandı")
 }
 
 // getCinsOptions belirtilen tür için cins seçeneklerini string slice olarak döner
@@ -681,12 +803,30 @@ func (a *App) showAddForm() {
 	// Kod alanı (gizli)
 	var selectedKod string
 
+	// Alış fiyatı önerisi: seçilen cinsin güncel satış fiyatı (alan boşsa veya önceki öneriyi taşıyorsa doldurulur)
+	var kotasyon *services.AltinFiyatlari
+	var oneriFiyat string
+	alisFiyatiField := tview.NewInputField().SetLabel("Alış Fiyatı").SetFieldWidth(20)
+	alisFiyatiOner := func() {
+		if kotasyon == nil || selectedKod == "" {
+			return
+		}
+		if mevcut := alisFiyatiField.GetText(); mevcut != "" && mevcut != oneriFiyat {
+			return
+		}
+		if _, satis, err := a.envanterService.GetAlisSatis(kotasyon, selectedKod); err == nil && satis > 0 {
+			oneriFiyat = decimal.NewFromFloat(satis).StringFixed(2)
+			alisFiyatiField.SetText(oneriFiyat)
+		}
+	}
+
 	// Tür değiştiğinde Cins seçeneklerini güncelle
 	turDropdown.SetSelectedFunc(func(text string, index int) {
 		cinsOptions := getCinsOptions(text)
 		cinsDropdown.SetOptions(cinsOptions, func(cinsText string, cinsIndex int) {
 			// Cins seçildiğinde kodu belirle
 			selectedKod = getCinsCode(cinsText)
+			alisFiyatiOner()
 		})
 	})
 
@@ -697,9 +837,42 @@ func (a *App) showAddForm() {
 	form.AddInputField("Miktar", "", 20, nil, nil)
 	form.AddFormItem(birimDropdown)
 	form.AddInputField("Alış Tarihi", "", 20, nil, nil)
-	form.AddInputField("Alış Fiyatı", "", 20, nil, nil)
+	form.AddFormItem(alisFiyatiField)
 	form.AddInputField("Güncel Fiyat (opsiyonel)", "", 20, nil, nil)
 
+	// Liste modu değilse güncel fiyatları arka planda çek
+	if !a.isListMode {
+		go func() {
+			fiyatlar, err := a.envanterService.GetFiyatlar()
+			if err != nil {
+				// API'ye ulaşılamazsa öneri son başarılı fiyatlardan yapılır
+				logger.Warnf("Alış fiyatı önerisi için fiyatlar alınamadı: %v", err)
+				if fiyatlar = a.envanterService.SonFiyatlar(); fiyatlar == nil {
+					return
+				}
+			}
+			a.app.QueueUpdateDraw(func() {
+				kotasyon = fiyatlar
+				alisFiyatiOner()
+			})
+		}()
+	}
+
+	// Giriş tipi dropdown - hediye girişinde alış fiyatı, alındığı günkü değerdir
+	girisTipiDropdown := tview.NewDropDown().
+		SetLabel("Giriş Tipi").
+		SetOptions(girisTipiOptions, nil).
+		SetCurrentOption(0)
+	form.AddFormItem(girisTipiDropdown)
+
+	// Portföy - varsayılan olarak görüntülenen portföy (tüm portföylerde ana portföy)
+	a.portfoyleriYukle()
+	varsayilanPortfoy := a.portfoyID
+	if varsayilanPortfoy == 0 {
+		varsayilanPortfoy = models.VarsayilanPortfoyID
+	}
+	form.AddFormItem(a.portfoyDropdown(varsayilanPortfoy))
+
 	// Butonlar
 	form.AddButton("Kaydet", func() {
 		a.saveNewEnvanterSingle(form, turDropdown, cinsDropdown, birimDropdown, selectedKod)
@@ -708,16 +881,14 @@ func (a *App) showAddForm() {
 		a.pages.RemovePage("add-form")
 		a.clearAllTables()
 		a.app.ForceDraw()
-		a.loadData()
-		a.loadGrupData()
-		a.loadOzetData()
+		a.anaTablolariYenile()
 		a.app.SetFocus(a.table)
 	})
 
 	// Ana form container
 	mainForm := form
 
-	mainForm.SetTitle(" ➕ YENİ EKLE (Formatlar: Tarih: GG.AA.YYYY, Sayılar: 1234.56) ").SetBorder(true)
+	mainForm.SetTitle(" ➕ YENİ EKLE (Formatlar: Tarih: GG.AA.YYYY, Sayılar: 1234.56, Alış fiyatı: güncel satış önerilir) ").SetBorder(true)
 	mainForm.SetBackgroundColor(tcell.ColorBlack)
 
 	// Modal olarak göster - daha büyük boyut
@@ -725,7 +896,7 @@ func (a *App) showAddForm() {
 		AddItem(nil, 0, 1, false).
 		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
 			AddItem(nil, 0, 1, false).
-			AddItem(mainForm, 20, 1, true).
+			AddItem(mainForm, 22, 1, true).
 			AddItem(nil, 0, 1, false), 80, 1, true).
 		AddItem(nil, 0, 1, false)
 
@@ -760,6 +931,11 @@ func (a *App) saveNewEnvanterSingle(form *tview.Form, turDropdown, cinsDropdown,
 
 	// Envanter kaydı oluştur
 	envanter := a.createEnvanter(selectedKod, turText, cinsText, miktarVal, alisFiyati, guncelFiyat, birimText, alisTarihiTime)
+	girisTipiIndex, _ := form.GetFormItem(7).(*tview.DropDown).GetCurrentOption()
+	if girisTipiIndex == 1 {
+		envanter.GirisTipi = models.IslemHediyeGiris
+	}
+	envanter.PortfoyID = a.secilenPortfoyID(form.GetFormItem(8).(*tview.DropDown))
 
 	// Veritabanına kaydet
 	if err := a.saveEnvanterToDatabase(&envanter); err != nil {
@@ -796,29 +972,34 @@ func (a *App) updateEnvanterSingle(form *tview.Form, turDropdown, cinsDropdown,
 		return
 	}
 
-	// Mevcut kaydın ID'sini bul
-	envanterService := services.NewEnvanterService()
-	envanterler, err := envanterService.GetAllEnvanterFromDB()
-	if err != nil || row-1 >= len(envanterler) {
+	// Mevcut kaydın ID'sini bul; güncelleme veritabanındaki son hal üzerine yapılır
+	secili, ok := a.satirEnvanteri(row)
+	if !ok {
+		a.showMessageWithReturn("Kayıt bulunamadı!", form)
+		return
+	}
+	mevcut, err := a.envanterServisi().GetEnvanterByID(secili.ID)
+	if err != nil {
 		a.showMessageWithReturn("Kayıt bulunamadı!", form)
 		return
 	}
 
 	// Güncellenen envanter kaydı
-	envanter := envanterler[row-1]
+	envanter := *mevcut
 	envanter.Kod = selectedKod
 	envanter.Tur = turText
 	envanter.Cins = cinsText
 	envanter.Miktar = miktarVal
 	envanter.Birim = birimText
 	envanter.AlisFiyati = alisFiyati
+	envanter.PortfoyID = a.secilenPortfoyID(form.GetFormItem(7).(*tview.DropDown))
 
 	// Güncel fiyat güncellemesi - eğer girilmişse güncelle, girilmemişse (0 ise) API'den çekilecek
-	if guncelFiyat > 0 {
+	if guncelFiyat.IsPositive() {
 		envanter.GuncelFiyat = guncelFiyat
 	} else {
 		// Güncel fiyat girilmemişse 0 olarak ayarla, servis API'den çekecek
-		envanter.GuncelFiyat = 0
+		envanter.GuncelFiyat = decimal.Zero
 	}
 
 	if alisTarihi != "" {
@@ -844,14 +1025,18 @@ func (a *App) showEditForm() {
 	}
 
 	// Mevcut kaydın verilerini veritabanından al
-	envanterService := services.NewEnvanterService()
-	envanterler, err := envanterService.GetAllEnvanterFromDB()
-	if err != nil || row-1 >= len(envanterler) {
+	secili, ok := a.satirEnvanteri(row)
+	if !ok {
+		a.showMessage("Kayıt bulunamadı!")
+		return
+	}
+	mevcut, err := a.envanterServisi().GetEnvanterByID(secili.ID)
+	if err != nil {
 		a.showMessage("Kayıt bulunamadı!")
 		return
 	}
 
-	envanter := envanterler[row-1]
+	envanter := *mevcut
 
 	// Veritabanındaki orijinal değerleri kullan (precision kaybı olmadan)
 	tur := envanter.Tur
@@ -862,18 +1047,12 @@ func (a *App) showEditForm() {
 	}
 	birim := envanter.Birim
 
-	// Miktarı decimal kullanarak hassas şekilde formatla
-	miktarDecimal := decimal.NewFromFloat(envanter.Miktar)
-	miktarStr := miktarDecimal.StringFixed(2)
-	// Sondaki sıfırları temizle
-	miktarStr = strings.TrimSuffix(miktarStr, ".00")
-	miktarStr = strings.TrimSuffix(miktarStr, ".0")
+	// Miktar veritabanındaki tam değeriyle, sondaki sıfırlar olmadan gösterilir
+	miktarStr := envanter.Miktar.String()
 
-	// Fiyat değerlerini veritabanından decimal kullanarak hassas şekilde formatla
-	alisFiyatiDecimal := decimal.NewFromFloat(envanter.AlisFiyati)
-	guncelFiyatDecimal := decimal.NewFromFloat(envanter.GuncelFiyat)
-	alisFiyati := alisFiyatiDecimal.StringFixed(2)
-	guncelFiyat := guncelFiyatDecimal.StringFixed(2)
+	// Fiyat değerlerini 2 ondalıkla formatla
+	alisFiyati := envanter.AlisFiyati.StringFixed(2)
+	guncelFiyat := envanter.GuncelFiyat.StringFixed(2)
 
 	// Alış tarihini string'e çevir
 	alisTarihiStr := envanter.AlisTarihi.Format("02.01.2006")
@@ -931,6 +1110,10 @@ func (a *App) showEditForm() {
 	form.AddInputField("Alış Fiyatı", alisFiyati, 20, nil, nil)
 	form.AddInputField("Güncel Fiyat (opsiyonel)", guncelFiyat, 20, nil, nil)
 
+	// Portföy - lot başka bir portföye taşınabilir
+	a.portfoyleriYukle()
+	form.AddFormItem(a.portfoyDropdown(envanter.PortfoyID))
+
 	// Butonlar
 	form.AddButton("Güncelle", func() {
 		a.updateEnvanterSingle(form, turDropdown, cinsDropdown, birimDropdown, selectedKod, row)
@@ -939,9 +1122,7 @@ func (a *App) showEditForm() {
 		a.pages.RemovePage("edit-form")
 		a.clearAllTables()
 		a.app.ForceDraw()
-		a.loadData()
-		a.loadGrupData()
-		a.loadOzetData()
+		a.anaTablolariYenile()
 		a.app.SetFocus(a.table)
 	})
 
@@ -956,7 +1137,7 @@ func (a *App) showEditForm() {
 		AddItem(nil, 0, 1, false).
 		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
 			AddItem(nil, 0, 1, false).
-			AddItem(mainForm, 20, 1, true).
+			AddItem(mainForm, 22, 1, true).
 			AddItem(nil, 0, 1, false), 80, 1, true).
 		AddItem(nil, 0, 1, false)
 
@@ -964,7 +1145,8 @@ func (a *App) showEditForm() {
 	a.app.SetFocus(form)
 }
 
-// showDeleteConfirm silme onayı gösterir
+// showDeleteConfirm silme onayı gösterir. Yanlışlıkla S'ye basılıp Enter'la geçilmesin diye
+// varsayılan düğme "İptal"dir; silinen kayıt çöp kutusuna taşınır ve hemen geri alınabilir.
 func (a *App) showDeleteConfirm() {
 	row, _ := a.table.GetSelection()
 	if row <= 0 {
@@ -976,15 +1158,16 @@ func (a *App) showDeleteConfirm() {
 	cins := a.table.GetCell(row, 1).Text
 
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("'%s - %s' kaydını silmek istediğinizden emin misiniz?", tur, cins)).
+		SetText(fmt.Sprintf("'%s - %s' kaydını silmek istediğinizden emin misiniz?\n\nKayıt çöp kutusuna taşınır (K). Satış veya hediye çıkışı için Ç tuşunu kullanın.", tur, cins)).
 		AddButtons([]string{"Sil", "İptal"}).
+		SetFocus(1).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			if buttonLabel == "Sil" {
-				a.deleteEnvanter(row)
-			}
 			a.pages.RemovePage("delete-confirm")
 			a.app.ForceDraw()
 			a.app.SetFocus(a.table)
+			if buttonLabel == "Sil" {
+				a.deleteEnvanter(row)
+			}
 		})
 
 	a.pages.AddPage("delete-confirm", modal, true, true)
@@ -1014,69 +1197,33 @@ func (a *App) showMessageWithReturn(message string, returnWidget tview.Primitive
 	a.app.SetFocus(modal)
 }
 
-// parsePrice fiyat stringini decimal'a çevirir (Türkçe format desteği)
-func parsePrice(price string) decimal.Decimal {
-	// Para birimi sembollerini ve boşlukları temizle
-	price = strings.ReplaceAll(price, " ₺", "")
-	price = strings.ReplaceAll(price, "₺", "")
-	price = strings.TrimSpace(price)
-
-	// Boş string kontrolü
-	if price == "" {
-		return decimal.Zero
-	}
-
-	// Türkçe format: virgül ondalık ayırıcı, nokta binlik ayırıcı
-	// 1.234,56 -> 1234.56
-	// Basit yaklaşım: sadece virgülleri noktaya çevir
-	price = strings.ReplaceAll(price, ",", ".")
-
-	// Birden fazla nokta varsa (binlik ayırıcı durumu), son nokta hariç diğerlerini kaldır
-	dotCount := strings.Count(price, ".")
-	if dotCount > 1 {
-		// En son noktanın pozisyonunu bul
-		lastDotIndex := strings.LastIndex(price, ".")
-		beforeLastDot := price[:lastDotIndex]
-		afterLastDot := price[lastDotIndex:]
-
-		// Son nokta öncesindeki noktaları kaldır (binlik ayırıcı)
-		beforeLastDot = strings.ReplaceAll(beforeLastDot, ".", "")
-		price = beforeLastDot + afterLastDot
-	}
-
-	// Decimal ile parse et
-	result, err := decimal.NewFromString(price)
-	if err != nil {
-		return decimal.Zero
-	}
-	return result
-} // validateAndParseFormData form verilerini validate eder ve parse eder
-func (a *App) validateAndParseFormData(turIndex, cinsIndex, birimIndex int, miktar, alisFiyatiStr, guncelFiyatStr string) (float64, float64, float64, error) {
+// validateAndParseFormData form verilerini validate eder ve parse eder
+func (a *App) validateAndParseFormData(turIndex, cinsIndex, birimIndex int, miktar, alisFiyatiStr, guncelFiyatStr string) (decimal.Decimal, decimal.Decimal, decimal.Decimal, error) {
 	// Validasyon
 	if turIndex < 0 || cinsIndex < 0 || miktar == "" || birimIndex < 0 {
-		return 0, 0, 0, fmt.Errorf("lütfen tüm alanları doldurun")
+		return decimal.Zero, decimal.Zero, decimal.Zero, fmt.Errorf("lütfen tüm alanları doldurun")
 	}
 
 	// Miktar için decimal kullan
-	miktarVal := parsePrice(miktar)
+	miktarVal := format.ParsePrice(miktar)
 	if miktarVal.IsZero() && miktar != "0" && miktar != "0,0" && miktar != "0.0" {
-		return 0, 0, 0, fmt.Errorf("miktar geçerli bir sayı olmalı")
+		return decimal.Zero, decimal.Zero, decimal.Zero, fmt.Errorf("miktar geçerli bir sayı olmalı")
 	}
 
 	// Fiyatları decimal ile parse et
-	alisFiyati := parsePrice(alisFiyatiStr)
+	alisFiyati := format.ParsePrice(alisFiyatiStr)
 	if alisFiyati.IsZero() && alisFiyatiStr != "0" && alisFiyatiStr != "0,0" && alisFiyatiStr != "0.0" {
-		return 0, 0, 0, fmt.Errorf("alış fiyatı geçerli bir sayı olmalı")
+		return decimal.Zero, decimal.Zero, decimal.Zero, fmt.Errorf("alış fiyatı geçerli bir sayı olmalı")
 	}
 
 	// Güncel fiyat opsiyonel - boş bırakılabilir
-	guncelFiyat := parsePrice(guncelFiyatStr)
+	guncelFiyat := format.ParsePrice(guncelFiyatStr)
 	if guncelFiyatStr != "" && guncelFiyat.IsZero() && guncelFiyatStr != "0" && guncelFiyatStr != "0,0" && guncelFiyatStr != "0.0" {
-		return 0, 0, 0, fmt.Errorf("güncel fiyat geçerli bir sayı olmalı")
+		return decimal.Zero, decimal.Zero, decimal.Zero, fmt.Errorf("güncel fiyat geçerli bir sayı olmalı")
 	}
 
-	// Float64'e çevir (veritabanı için)
-	return miktarVal.InexactFloat64(), alisFiyati.InexactFloat64(), guncelFiyat.InexactFloat64(), nil
+	// Decimal olarak döndür; veritabanına da hassasiyet kaybı olmadan yazılır
+	return miktarVal, alisFiyati, guncelFiyat, nil
 }
 
 // parseAlisTarihi alış tarihini parse eder
@@ -1088,7 +1235,7 @@ func (a *App) parseAlisTarihi(alisTarihi string) (time.Time, error) {
 }
 
 // createEnvanter envanter objesi oluşturur
-func (a *App) createEnvanter(selectedKod, turText, cinsText string, miktarVal, alisFiyati, guncelFiyat float64, birimText string, alisTarihiTime time.Time) models.Envanter {
+func (a *App) createEnvanter(selectedKod, turText, cinsText string, miktarVal, alisFiyati, guncelFiyat decimal.Decimal, birimText string, alisTarihiTime time.Time) models.Envanter {
 	envanter := models.Envanter{
 		Kod:         selectedKod,
 		Tur:         turText,
@@ -1101,10 +1248,10 @@ func (a *App) createEnvanter(selectedKod, turText, cinsText string, miktarVal, a
 	}
 
 	// Toplam alış tutarını hesapla
-	envanter.ToplamAlis = miktarVal * alisFiyati
+	envanter.ToplamAlis = miktarVal.Mul(alisFiyati)
 
 	// Eğer güncel fiyat girilmişse hesapla, girilmemişse API'den çekilecek
-	if guncelFiyat > 0 {
+	if guncelFiyat.IsPositive() {
 		envanter.GuncelDegerleriHesapla()
 	}
 
@@ -1113,13 +1260,13 @@ func (a *App) createEnvanter(selectedKod, turText, cinsText string, miktarVal, a
 
 // saveEnvanterToDatabase envanter'ı veritabanına kaydeder
 func (a *App) saveEnvanterToDatabase(envanter *models.Envanter) error {
-	envanterService := services.NewEnvanterService()
+	envanterService := a.envanterServisi()
 	return envanterService.AddEnvanterWithMode(envanter, a.isListMode)
 }
 
 // updateEnvanterInDatabase envanter'ı veritabanında günceller
 func (a *App) updateEnvanterInDatabase(envanter *models.Envanter) error {
-	envanterService := services.NewEnvanterService()
+	envanterService := a.envanterServisi()
 	return envanterService.UpdateEnvanterWithMode(envanter, a.isListMode)
 }
 
@@ -1134,9 +1281,7 @@ func (a *App) refreshDataAndCloseForm(formName, successMessage string) {
 	a.app.ForceDraw()
 
 	// Verileri yeniden yükle
-	a.loadData()
-	a.loadGrupData()
-	a.loadOzetData()
+	a.anaTablolariYenile()
 
 	// Focus'u geri getir
 	a.app.SetFocus(a.table)
@@ -1156,6 +1301,7 @@ func (a *App) clearAllTables() {
 	a.table.Clear()
 	a.grupTable.Clear()
 	a.ozetTable.Clear()
+	a.gerceklesenTable.Clear()
 } // findIndex string slice'ında belirtilen değerin indeksini bulur
 func findIndex(slice []string, value string) int {
 	for i, v := range slice {
@@ -1166,35 +1312,26 @@ func findIndex(slice []string, value string) int {
 	return -1
 }
 
-// deleteEnvanter seçili envanter kaydını siler
+// deleteEnvanter seçili envanter kaydını çöp kutusuna taşır
 func (a *App) deleteEnvanter(row int) {
 	// Mevcut kaydın ID'sini bul
-	envanterService := services.NewEnvanterService()
-	envanterler, err := envanterService.GetAllEnvanterFromDB()
-	if err != nil || row-1 >= len(envanterler) {
+	envanter, ok := a.satirEnvanteri(row)
+	if !ok {
 		a.showMessage("Kayıt bulunamadı!")
 		return
 	}
 
-	// Silinecek envanter
-	envanter := envanterler[row-1]
-
 	// Veritabanından sil
-	err = envanterService.DeleteEnvanter(envanter.ID)
-	if err != nil {
+	if err := a.envanterServisi().DeleteEnvanter(envanter.ID); err != nil {
 		a.showMessage(fmt.Sprintf("Silme başarısız: %v", err))
 		return
 	}
 
 	// Başarılı, verileri yenile
-	a.loadData()
-	a.loadGrupData()
-	a.loadOzetData()
+	a.anaTablolariYenile()
 
-	// Başarı mesajını göster
-	go func() {
-		a.showMessage("Envanter başarıyla silindi!")
-	}()
+	// Geri alma seçeneğiyle bilgi ver
+	a.showSilmeSonrasi(envanter)
 }
 
 // startAutoUpdate otomatik fiyat güncellemeyi başlatır (dakikada bir)
@@ -1205,27 +1342,31 @@ func (a *App) startAutoUpdate() {
 		for {
 			select {
 			case <-a.updateTicker.C:
-				log.Printf("Otomatik fiyat güncelleme başlatılıyor...")
+				logger.Infof("Otomatik fiyat güncelleme başlatılıyor...")
 				err := a.envanterService.UpdateGuncelFiyatlar()
 				if err != nil {
-					log.Printf("UYARI: Otomatik fiyat güncelleme başarısız: %v", err)
+					logger.Warnf("Otomatik fiyat güncelleme başarısız: %v", err)
+					a.app.QueueUpdateDraw(func() {
+						a.fiyatGuncellemeSonucu(err)
+					})
 				} else {
 					//log.Printf("Otomatik fiyat güncelleme başarılı")
+					olaylar := a.alarmlariDegerlendir()
 					// UI'yi güncelle
 					a.app.QueueUpdateDraw(func() {
-						a.loadData()
-						a.loadGrupData()
-						a.loadOzetData()
+						a.anaTablolariYenile()
+						a.fiyatGuncellemeSonucu(nil)
+						a.alarmGoster(olaylar)
 					})
 				}
 			case <-a.stopChan:
-				log.Printf("Otomatik fiyat güncelleme durduruldu")
+				logger.Infof("Otomatik fiyat güncelleme durduruldu")
 				return
 			}
 		}
 	}()
 
-	log.Printf("Otomatik fiyat güncelleme başlatıldı (dakikada bir)")
+	logger.Infof("Otomatik fiyat güncelleme başlatıldı (dakikada bir)")
 }
 
 // stopAutoUpdate otomatik fiyat güncellemeyi durdurur
@@ -1242,94 +1383,6 @@ func (a *App) stopAutoUpdate() {
 	}
 }
 
-// formatMoney para birimini formatlar (decimal kullanarak hassas)
-func formatMoney(amount float64) string {
-	// Float64'ü decimal'a çevir
-	d := decimal.NewFromFloat(amount)
-
-	// 2 ondalık basamakla formatla
-	formatted := d.StringFixed(2)
-
-	// Türkçe format'a çevir: 1234.56 -> 1.234,56
-	return formatTurkishNumber(formatted)
-}
-
-// formatTurkishNumber sayıyı Türkçe formata çevirir (binlik: nokta, ondalık: virgül)
-func formatTurkishNumber(numberStr string) string {
-	// Ondalık kısmı ayır
-	parts := strings.Split(numberStr, ".")
-	intPart := parts[0]
-	decimalPart := ""
-
-	if len(parts) > 1 {
-		decimalPart = parts[1]
-		// Sondaki sıfırları kaldır
-		decimalPart = strings.TrimRight(decimalPart, "0")
-	}
-
-	// Binlik ayırıcı ekle (nokta)
-	result := addThousandSeparator(intPart)
-
-	// Ondalık kısım varsa virgül ile ekle
-	if decimalPart != "" {
-		result = result + "," + decimalPart
-	}
-
-	return result
-}
-
-// formatQuantity miktarı formatlar (decimal kullanarak hassas)
-func formatQuantity(amount float64, unit string) string {
-	// Float64'ü decimal'a çevir
-	d := decimal.NewFromFloat(amount)
-
-	if unit == "adet" {
-		// Adet ise tam sayı olarak göster
-		return d.Truncate(0).String()
-	}
-
-	// Diğer birimler için ondalıklı göster ama gereksiz sıfırları temizle
-	if d.Equal(d.Truncate(0)) {
-		// Tam sayı ise ondalık gösterme
-		return formatTurkishNumber(d.Truncate(0).String())
-	}
-
-	// 2 ondalık basamakla formatla
-	formatted := d.StringFixed(2)
-	return formatTurkishNumber(formatted)
-}
-
-// addThousandSeparator sayıya binlik ayırıcı ekler
-func addThousandSeparator(s string) string {
-	// Negatif sayıları kontrol et
-	negative := false
-	if strings.HasPrefix(s, "-") {
-		negative = true
-		s = s[1:]
-	}
-
-	n := len(s)
-	if n <= 3 {
-		if negative {
-			return "-" + s
-		}
-		return s
-	}
-
-	var result strings.Builder
-	for i, digit := range s {
-		if i > 0 && (n-i)%3 == 0 {
-			result.WriteString(".")
-		}
-		result.WriteRune(digit)
-	}
-
-	if negative {
-		return "-" + result.String()
-	}
-	return result.String()
-}
-
 // updateScrollIndicators scroll indicator'ları günceller
 func (a *App) updateScrollIndicators() {
 	// Envanter tablosu için scroll indicator güncelle
@@ -1380,10 +1433,10 @@ func (a *App) updateScrollIndicators() {
 // loadProductMappings ürün mappinglerini statik olarak yükler
 func (a *App) loadProductMappings() {
 	// Artık tüm mappingler statik - API'den yükleme yapmıyoruz
-	cinsMapping = fallbackCinsMapping
+	cinsMapping = models.UrunKatalogu
 
-	altinCount := len(fallbackCinsMapping["Altın"])
-	dovizCount := len(fallbackCinsMapping["Döviz"])
-	gumusCount := len(fallbackCinsMapping["Gümüş"])
-	log.Printf("Statik ürün mappingleri yüklendi: %d altın, %d döviz, %d gümüş ürünü", altinCount, dovizCount, gumusCount)
+	altinCount := len(models.UrunKatalogu["Altın"])
+	dovizCount := len(models.UrunKatalogu["Döviz"])
+	gumusCount := len(models.UrunKatalogu["Gümüş"])
+	logger.Infof("Statik ürün mappingleri yüklendi: %d altın, %d döviz, %d gümüş ürünü", altinCount, dovizCount, gumusCount)
 }
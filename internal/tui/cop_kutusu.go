@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"fmt"
+
+	"altintakip/internal/format"
+	"altintakip/internal/models"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showCopKutusu silinmiş kayıtları geri alma ve kalıcı silme seçenekleriyle gösterir
+func (a *App) showCopKutusu() {
+	table := tview.NewTable()
+	table.SetBorders(true)
+	table.SetSelectable(true, false)
+	table.SetFixed(1, 0)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack))
+	table.SetTitle(" 🗑️  ÇÖP KUTUSU ")
+	table.SetBorderColor(tcell.ColorRed)
+	table.SetBackgroundColor(tcell.ColorBlack)
+
+	var silinenler []models.Envanter
+	yukle := func() {
+		silinenler = a.copKutusunuYukle(table)
+	}
+	yukle()
+
+	// secili tablodaki seçili silinmiş kaydı döner
+	secili := func() (models.Envanter, bool) {
+		row, _ := table.GetSelection()
+		if row <= 0 || row-1 >= len(silinenler) {
+			return models.Envanter{}, false
+		}
+		return silinenler[row-1], true
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEnter || event.Rune() == 'g' || event.Rune() == 'G':
+			envanter, ok := secili()
+			if !ok {
+				return nil
+			}
+			if _, err := a.envanterServisi().RestoreEnvanter(envanter.ID); err != nil {
+				a.showMessageWithReturn(fmt.Sprintf("Geri alma başarısız: %v", err), table)
+				return nil
+			}
+			yukle()
+			a.anaTablolariYenile()
+			a.showMessageWithReturn(fmt.Sprintf("'%s - %s' kaydı geri alındı.", envanter.Tur, envanter.Cins), table)
+			return nil
+		case event.Rune() == 's' || event.Rune() == 'S':
+			envanter, ok := secili()
+			if !ok {
+				return nil
+			}
+			a.copOnayiGoster(fmt.Sprintf("'%s - %s' kaydı işlem geçmişiyle birlikte KALICI olarak silinecek. Bu işlem geri alınamaz.", envanter.Tur, envanter.Cins), table, func() {
+				if _, err := a.envanterServisi().PurgeEnvanter(envanter.ID); err != nil {
+					a.showMessageWithReturn(fmt.Sprintf("Kalıcı silme başarısız: %v", err), table)
+					return
+				}
+				yukle()
+			})
+			return nil
+		case event.Rune() == 'h' || event.Rune() == 'H':
+			if envanter, ok := secili(); ok {
+				a.showGecmis(envanter, table)
+			}
+			return nil
+		case event.Rune() == 'b' || event.Rune() == 'B':
+			if len(silinenler) == 0 {
+				return nil
+			}
+			a.copOnayiGoster(fmt.Sprintf("Çöp kutusundaki %d kayıt işlem geçmişleriyle birlikte KALICI olarak silinecek; satış/çıkış geçmişi olanlar korunur. Bu işlem geri alınamaz.", len(silinenler)), table, func() {
+				_, korunan, err := a.envanterServisi().EmptyCopKutusu()
+				if err != nil {
+					a.showMessageWithReturn(fmt.Sprintf("Çöp kutusu boşaltılamadı: %v", err), table)
+					return
+				}
+				yukle()
+				if korunan > 0 {
+					a.showMessageWithReturn(fmt.Sprintf("%d kayıt satış/çıkış geçmişi olduğu için çöp kutusunda bırakıldı.", korunan), table)
+				}
+			})
+			return nil
+		}
+		return event
+	})
+
+	icerik := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(tview.NewTextView().
+			SetText("Enter/G: Geri Al, S: Kalıcı Sil, H: Geçmiş, B: Çöp Kutusunu Boşalt, ESC: Kapat").
+			SetTextAlign(tview.AlignCenter).
+			SetTextColor(tcell.ColorYellow), 1, 0, false)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(icerik, 20, 1, true).
+			AddItem(nil, 0, 1, false), 130, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("cop-kutusu", modal, true, true)
+	a.app.SetFocus(table)
+}
+
+// copKutusunuYukle seçili portföyün silinmiş kayıtlarını tabloya yazar ve tablodaki sırayla döner
+func (a *App) copKutusunuYukle(table *tview.Table) []models.Envanter {
+	table.Clear()
+
+	portfoySutunu := a.portfoySutunuGoster()
+	headers := []string{"TÜR", "CİNS", "MİKTAR", "ALIŞ TARİHİ", "ALIŞ FİYATI ₺", "TOPLAM ALIŞ ₺", "SİLİNME"}
+	if portfoySutunu {
+		headers = append(headers, "PORTFÖY")
+	}
+	for col, header := range headers {
+		table.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false))
+	}
+
+	silinenler, err := a.envanterServisi().GetSilinenEnvanterler()
+	if err != nil {
+		table.SetCell(1, 0, tview.NewTableCell(fmt.Sprintf("HATA: %v", err)).SetTextColor(tcell.ColorRed))
+		return nil
+	}
+	if len(silinenler) == 0 {
+		table.SetCell(1, 0, tview.NewTableCell("Çöp kutusu boş").SetTextColor(tcell.ColorYellow).SetSelectable(false))
+		return nil
+	}
+
+	for i, envanter := range silinenler {
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(envanter.Tur))
+		table.SetCell(row, 1, tview.NewTableCell(envanter.Cins))
+		table.SetCell(row, 2, tview.NewTableCell(fmt.Sprintf("%s %s", format.Quantity(envanter.Miktar, envanter.Birim), envanter.Birim)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(envanter.AlisTarihi.Format("02.01.2006")).SetAlign(tview.AlignCenter))
+		table.SetCell(row, 4, tview.NewTableCell(format.Money(envanter.AlisFiyati)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 5, tview.NewTableCell(format.Money(envanter.ToplamAlis)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 6, tview.NewTableCell(envanter.DeletedAt.Time.Format("02.01.2006 15:04")).SetAlign(tview.AlignCenter))
+		if portfoySutunu {
+			table.SetCell(row, 7, tview.NewTableCell(a.portfoyAdi(envanter.PortfoyID)))
+		}
+	}
+	table.Select(1, 0)
+	return silinenler
+}
+
+// copOnayiGoster kalıcı silme onayı ister; varsayılan düğme "İptal" olduğundan yanlışlıkla Enter'a basmak silmez
+func (a *App) copOnayiGoster(mesaj string, returnWidget tview.Primitive, onay func()) {
+	modal := tview.NewModal().
+		SetText(mesaj).
+		AddButtons([]string{"Kalıcı Sil", "İptal"}).
+		SetFocus(1).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			a.pages.RemovePage("cop-onay")
+			a.app.SetFocus(returnWidget)
+			if buttonLabel == "Kalıcı Sil" {
+				onay()
+			}
+		})
+
+	a.pages.AddPage("cop-onay", modal, true, true)
+	a.app.SetFocus(modal)
+}
+
+// showSilmeSonrasi silinen kaydın çöp kutusuna taşındığını bildirir ve hemen geri alma imkânı verir
+func (a *App) showSilmeSonrasi(envanter models.Envanter) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("'%s - %s' kaydı çöp kutusuna taşındı.\n\nGeri almak için \"Geri Al\" düğmesini veya daha sonra K (Çöp Kutusu) ekranını kullanabilirsiniz.", envanter.Tur, envanter.Cins)).
+		AddButtons([]string{"Tamam", "Geri Al"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			a.pages.RemovePage("message")
+			a.app.SetFocus(a.table)
+			if buttonLabel != "Geri Al" {
+				return
+			}
+			if _, err := a.envanterServisi().RestoreEnvanter(envanter.ID); err != nil {
+				a.showMessage(fmt.Sprintf("Geri alma başarısız: %v", err))
+				return
+			}
+			a.anaTablolariYenile()
+		})
+
+	a.pages.AddPage("message", modal, true, true)
+	a.app.SetFocus(modal)
+}
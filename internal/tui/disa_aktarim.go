@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"altintakip/internal/database"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// disaAktarimSecenekleri dışa aktarım formundaki biçim seçenekleri
+var disaAktarimSecenekleri = []struct {
+	isim        string
+	bicim       string
+	sayiFormati string
+}{
+	{"CSV (Türkçe sayı: 1.234,56)", services.BicimCSV, services.SayiFormatiTR},
+	{"CSV (Makine: 1234.56)", services.BicimCSV, services.SayiFormatiMakine},
+	{"JSON", services.BicimJSON, services.SayiFormatiMakine},
+}
+
+// showExportForm envanter, grup ve özet tablolarını dışa aktarma formunu gösterir
+func (a *App) showExportForm() {
+	returnFocus := a.app.GetFocus()
+
+	varsayilanDizin := "export"
+	if veriDizini, err := database.VeriDizini(); err == nil {
+		varsayilanDizin = filepath.Join(veriDizini, "export")
+	}
+
+	secenekIsimleri := make([]string, len(disaAktarimSecenekleri))
+	for i, secenek := range disaAktarimSecenekleri {
+		secenekIsimleri[i] = secenek.isim
+	}
+
+	form := tview.NewForm()
+	form.AddDropDown("Biçim", secenekIsimleri, 0, nil)
+	form.AddInputField("Dizin", varsayilanDizin, 50, nil, nil)
+
+	form.AddButton("Dışa Aktar", func() {
+		secim, _ := form.GetFormItem(0).(*tview.DropDown).GetCurrentOption()
+		dizin := strings.TrimSpace(form.GetFormItem(1).(*tview.InputField).GetText())
+		if secim < 0 || dizin == "" {
+			a.showMessageWithReturn("Lütfen biçim ve dizin seçin!", form)
+			return
+		}
+		secenek := disaAktarimSecenekleri[secim]
+
+		disaAktarim := a.disaAktarimServisi()
+		veri, err := disaAktarim.Topla()
+		if err != nil {
+			a.showMessageWithReturn(fmt.Sprintf("Veriler okunamadı: %v", err), form)
+			return
+		}
+		dosyalar, err := disaAktarim.SaveDosyalar(veri, dizin, secenek.bicim, secenek.sayiFormati)
+		if err != nil {
+			a.showMessageWithReturn(fmt.Sprintf("Dışa aktarım başarısız: %v", err), form)
+			return
+		}
+
+		a.pages.RemovePage("export-form")
+		a.showMessageWithReturn(fmt.Sprintf("Dışa aktarıldı:\n%s", strings.Join(dosyalar, "\n")), returnFocus)
+	})
+	form.AddButton("İptal", func() {
+		a.pages.RemovePage("export-form")
+		a.app.ForceDraw()
+		a.app.SetFocus(returnFocus)
+	})
+
+	form.SetTitle(" 📤 DIŞA AKTAR (Envanter, Gruplar, Özet) ").SetBorder(true)
+	form.SetBackgroundColor(tcell.ColorBlack)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 9, 1, true).
+			AddItem(nil, 0, 1, false), 80, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("export-form", modal, true, true)
+	a.app.SetFocus(form)
+}
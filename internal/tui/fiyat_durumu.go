@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Gösterilen fiyatların yaşına göre renk eşikleri: taze (yeşil), eskimiş (sarı), bayat (kırmızı)
+const (
+	fiyatTazeSuresi  = 5 * time.Minute
+	fiyatBayatSuresi = 30 * time.Minute
+)
+
+// fiyatDurumunuGoster durum çubuğunun sağında gösterilen fiyatların ne zaman çekildiğini ve
+// kısmi ya da başarısız güncellemeleri yazar. UI goroutine'inde çağrılmalıdır.
+func (a *App) fiyatDurumunuGoster() {
+	if a.fiyatDurumu == nil {
+		return
+	}
+
+	fiyatlar := a.envanterService.SonFiyatlar()
+	if fiyatlar == nil {
+		a.fiyatDurumu.SetText("Fiyat bilgisi yok ")
+		a.fiyatDurumu.SetTextColor(tcell.ColorGray)
+		return
+	}
+
+	zaman := fiyatlar.EnEskiZaman()
+	yas := time.Since(zaman)
+	metin := fmt.Sprintf("Fiyatlar: %s (%s) ", zaman.Format("02.01 15:04"), yasMetni(yas))
+
+	renk := tcell.ColorGreen
+	switch {
+	case yas >= fiyatBayatSuresi:
+		renk = tcell.ColorRed
+	case yas >= fiyatTazeSuresi:
+		renk = tcell.ColorYellow
+	}
+	if len(fiyatlar.Eksikler) > 0 {
+		metin = fmt.Sprintf("⚠ %s alınamadı, son fiyatlar  %s", strings.Join(fiyatlar.Eksikler, ", "), metin)
+		if renk == tcell.ColorGreen {
+			renk = tcell.ColorYellow
+		}
+	}
+	// Elde tutulan ürünlerden kuyumcunun kapattığı veya askıya aldıkları en başta gösterilir
+	if kapalilar := a.kapaliKodlar(); len(kapalilar) > 0 {
+		metin = fmt.Sprintf("⚠ Kapalı/askıda: %s  %s", strings.Join(kapalilar, ", "), metin)
+		renk = tcell.ColorRed
+	}
+	if a.fiyatHatasi {
+		metin = "⚠ Son güncelleme başarısız  " + metin
+		if renk == tcell.ColorGreen {
+			renk = tcell.ColorYellow
+		}
+	}
+
+	a.fiyatDurumu.SetText(metin)
+	a.fiyatDurumu.SetTextColor(renk)
+}
+
+// fiyatGuncellemeSonucu güncelleme denemesinin sonucunu fiyat durumuna işler. UI goroutine'inde çağrılmalıdır.
+func (a *App) fiyatGuncellemeSonucu(err error) {
+	a.fiyatHatasi = err != nil
+	a.fiyatDurumunuGoster()
+}
+
+// yasMetni süreyi "3 dk önce" biçiminde yazar
+func yasMetni(sure time.Duration) string {
+	switch {
+	case sure < time.Minute:
+		return "az önce"
+	case sure < time.Hour:
+		return fmt.Sprintf("%d dk önce", int(sure.Minutes()))
+	case sure < 24*time.Hour:
+		return fmt.Sprintf("%d sa önce", int(sure.Hours()))
+	default:
+		return fmt.Sprintf("%d gün önce", int(sure.Hours()/24))
+	}
+}
+
+// fiyatKalemleriniYukle son fiyat görüntüsündeki ürünleri tablolarda kullanılmak üzere koda göre eşler
+func (a *App) fiyatKalemleriniYukle() {
+	a.fiyatKalemleri = nil
+	if fiyatlar := a.envanterService.SonFiyatlar(); fiyatlar != nil {
+		a.fiyatKalemleri = fiyatlar.Kalemler()
+	}
+}
+
+// fiyatKalemi kodun son fiyat görüntüsündeki bilgisini döner; görüntüde yoksa nil döner
+func (a *App) fiyatKalemi(kod string) *services.RestPriceItem {
+	return a.fiyatKalemleri[strings.ToUpper(kod)]
+}
+
+// kapaliKodlar görüntülenen lotlardan durumu kapalı veya askıda olan ürünlerin kodlarını sıralı döner
+func (a *App) kapaliKodlar() []string {
+	if a.gorunum == nil {
+		return nil
+	}
+	goruldu := make(map[string]bool)
+	var kodlar []string
+	for _, envanter := range a.gorunum.Envanterler {
+		if goruldu[envanter.Kod] {
+			continue
+		}
+		goruldu[envanter.Kod] = true
+		if kalem := a.fiyatKalemi(envanter.Kod); kalem != nil && kalem.DurumUyarisi() != "" {
+			kodlar = append(kodlar, envanter.Kod)
+		}
+	}
+	sort.Strings(kodlar)
+	return kodlar
+}
+
+// cinsHucresi cins adını gösterir; ürün kapalı veya askıdaysa kırmızı ve ⚠ ile işaretler
+func cinsHucresi(cins string, kalem *services.RestPriceItem) *tview.TableCell {
+	if kalem != nil && kalem.DurumUyarisi() != "" {
+		return tview.NewTableCell("⚠ " + cins).SetTextColor(tcell.ColorRed)
+	}
+	return tview.NewTableCell(cins)
+}
+
+// degisimHucresi API'nin bildirdiği günlük değişimi yön okuyla gösterir
+func degisimHucresi(kalem *services.RestPriceItem) *tview.TableCell {
+	if kalem == nil || kalem.Change == nil {
+		return tview.NewTableCell("-").SetTextColor(tcell.ColorGray).SetAlign(tview.AlignRight)
+	}
+
+	degisim := *kalem.Change
+	metin, renk := fmt.Sprintf("→ %.2f%%", degisim), tcell.ColorGray
+	switch {
+	case degisim > 0:
+		metin, renk = fmt.Sprintf("↑ +%.2f%%", degisim), tcell.ColorGreen
+	case degisim < 0:
+		metin, renk = fmt.Sprintf("↓ %.2f%%", degisim), tcell.ColorRed
+	}
+	return tview.NewTableCell(metin).SetTextColor(renk).SetAlign(tview.AlignRight)
+}
+
+// fiyatZamaniHucresi kuyumcunun fiyatı son güncellediği zamanı gösterir; ürün kapalı veya
+// askıdaysa durum metni kırmızı olarak eklenir
+func fiyatZamaniHucresi(kalem *services.RestPriceItem) *tview.TableCell {
+	if kalem == nil {
+		return tview.NewTableCell("-").SetTextColor(tcell.ColorGray)
+	}
+
+	metin := kalem.GuncellenmeZamani
+	if zaman, err := kalem.Zaman(); err == nil {
+		metin = zaman.Format("02.01 15:04")
+	}
+	if durum := kalem.DurumUyarisi(); durum != "" {
+		return tview.NewTableCell(fmt.Sprintf("%s (%s)", metin, durum)).SetTextColor(tcell.ColorRed)
+	}
+	return tview.NewTableCell(metin).SetTextColor(tcell.ColorGray)
+}
+
+// kaynakHucresi fiyatın alındığı sağlayıcıyı gösterir
+func kaynakHucresi(kaynak string) *tview.TableCell {
+	if kaynak == "" {
+		kaynak = "-"
+	}
+	return tview.NewTableCell(kaynak).SetTextColor(tcell.ColorGray)
+}
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"altintakip/internal/models"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showSeciliGecmis envanter tablosunda seçili lotun değişiklik geçmişini gösterir
+func (a *App) showSeciliGecmis() {
+	row, _ := a.table.GetSelection()
+	if row <= 0 {
+		a.showMessage("Lütfen geçmişini görmek için bir kayıt seçin!")
+		return
+	}
+
+	envanter, ok := a.satirEnvanteri(row)
+	if !ok {
+		a.showMessage("Kayıt bulunamadı!")
+		return
+	}
+	a.showGecmis(envanter, a.table)
+}
+
+// showGecmis lotun denetim kaydındaki değişikliklerini (kim, ne zaman, ne değişti) eskiden yeniye gösterir;
+// seçili değişikliğin alan alan önceki ve sonraki değerleri alt panelde yer alır
+func (a *App) showGecmis(envanter models.Envanter, returnFocus tview.Primitive) {
+	table := tview.NewTable()
+	table.SetBorders(true)
+	table.SetSelectable(true, false)
+	table.SetFixed(1, 0)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack))
+	table.SetTitle(fmt.Sprintf(" 📜 DEĞİŞİKLİK GEÇMİŞİ - %s %s (ID %d) ", envanter.Tur, envanter.Cins, envanter.ID))
+	table.SetBorderColor(tcell.ColorBlue)
+	table.SetBackgroundColor(tcell.ColorBlack)
+
+	detay := tview.NewTextView()
+	detay.SetBorder(true)
+	detay.SetTitle(" DETAY ")
+	detay.SetScrollable(true)
+
+	headers := []string{"ZAMAN", "TÜR", "KİM", "KAYNAK", "DEĞİŞİKLİKLER"}
+	for col, header := range headers {
+		table.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false))
+	}
+
+	kayitlar, err := services.NewDegisiklikKaydiService().GetEnvanterGecmisi(envanter.ID)
+	switch {
+	case err != nil:
+		table.SetCell(1, 0, tview.NewTableCell(fmt.Sprintf("HATA: %v", err)).SetTextColor(tcell.ColorRed))
+	case len(kayitlar) == 0:
+		table.SetCell(1, 0, tview.NewTableCell("Bu kayıt için değişiklik kaydı yok").SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	for i := range kayitlar {
+		kayit := &kayitlar[i]
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(kayit.Zaman.Format("02.01.2006 15:04:05")).SetAlign(tview.AlignCenter))
+		table.SetCell(row, 1, tview.NewTableCell(models.DegisiklikTurleri[kayit.Tur]))
+		table.SetCell(row, 2, tview.NewTableCell(kayit.Kullanici))
+		table.SetCell(row, 3, tview.NewTableCell(kayit.Kaynak))
+		table.SetCell(row, 4, tview.NewTableCell(services.DegisiklikOzeti(kayit)).SetExpansion(1))
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if row <= 0 || row-1 >= len(kayitlar) {
+			detay.SetText("")
+			return
+		}
+		detay.SetText(degisiklikDetayi(&kayitlar[row-1]))
+		detay.ScrollToBeginning()
+	})
+	if len(kayitlar) > 0 {
+		// En son değişiklik seçili açılır
+		table.Select(len(kayitlar), 0)
+	}
+
+	icerik := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(detay, 12, 0, false).
+		AddItem(tview.NewTextView().
+			SetText("↑/↓: Değişiklik Seç, ESC: Kapat").
+			SetTextAlign(tview.AlignCenter).
+			SetTextColor(tcell.ColorYellow), 1, 0, false)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(icerik, 30, 1, true).
+			AddItem(nil, 0, 1, false), 140, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.gecmisReturnFocus = returnFocus
+	a.pages.AddPage("gecmis", modal, true, true)
+	a.app.SetFocus(table)
+}
+
+// degisiklikDetayi düzenleme ve çıkışlarda değişen tüm alanları, tek taraflı kayıtlarda lotun o anki halini yazar
+func degisiklikDetayi(kayit *models.DegisiklikKaydi) string {
+	farklar, err := services.DegisiklikFarklari(kayit)
+	if err != nil {
+		return fmt.Sprintf("HATA: %v", err)
+	}
+	if kayit.Onceki != "" && kayit.Sonraki != "" {
+		if len(farklar) == 0 {
+			return "Değişen alan yok"
+		}
+		var sb strings.Builder
+		for _, fark := range farklar {
+			fmt.Fprintf(&sb, "%-20s %s → %s\n", fark.Alan, fark.Onceki, fark.Sonraki)
+		}
+		return sb.String()
+	}
+
+	baslik, veri := "Sonraki hali:", kayit.Sonraki
+	if veri == "" {
+		baslik, veri = "Önceki hali:", kayit.Onceki
+	}
+	var girintili bytes.Buffer
+	if err := json.Indent(&girintili, []byte(veri), "", "  "); err != nil {
+		return veri
+	}
+	return baslik + "\n" + girintili.String()
+}
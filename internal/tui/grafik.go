@@ -0,0 +1,302 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"altintakip/internal/format"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/shopspring/decimal"
+)
+
+// grafikAraligi grafikte seçilebilen zaman aralığı
+type grafikAraligi struct {
+	Etiket string
+	Sure   func(simdi time.Time) time.Time // Aralığın başlangıcı (sıfır: tüm geçmiş)
+}
+
+// grafikAraliklari 1G/1H/1A/1Y/Tümü aralıkları
+var grafikAraliklari = []grafikAraligi{
+	{"1G", func(t time.Time) time.Time { return t.Add(-24 * time.Hour) }},
+	{"1H", func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }},
+	{"1A", func(t time.Time) time.Time { return t.AddDate(0, -1, 0) }},
+	{"1Y", func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) }},
+	{"Tümü", func(t time.Time) time.Time { return time.Time{} }},
+}
+
+// fiyatGrafigi bir kodun fiyat geçmişini çizgi grafik olarak çizen tview primitive'i
+type fiyatGrafigi struct {
+	*tview.Box
+
+	kod           string
+	referans      float64 // Alış fiyatı referans çizgisi (0 ise çizilmez); yalnızca çizim için float
+	degerlemeModu string  // Alış, orta veya satış fiyatı çizilir
+	aralikIndex   int
+
+	baslangic time.Time
+	bitis     time.Time
+	kayitlar  []models.FiyatGecmisi
+	hata      error
+}
+
+// newFiyatGrafigi yeni grafik oluşturur ve varsayılan aralık (1A) için veriyi yükler
+func newFiyatGrafigi(kod string, referans float64) *fiyatGrafigi {
+	g := &fiyatGrafigi{
+		Box:           tview.NewBox(),
+		kod:           kod,
+		referans:      referans,
+		degerlemeModu: services.DegerlemeModu(),
+		aralikIndex:   2,
+	}
+	g.SetBorder(true)
+	g.SetBorderColor(tcell.ColorAqua)
+	g.SetBackgroundColor(tcell.ColorBlack)
+	g.yukle()
+	return g
+}
+
+// yukle seçili aralık için fiyat geçmişini veritabanından okur
+func (g *fiyatGrafigi) yukle() {
+	fiyatGecmisi := services.NewFiyatGecmisiService()
+	g.bitis = time.Now()
+	g.baslangic = grafikAraliklari[g.aralikIndex].Sure(g.bitis)
+
+	g.kayitlar, g.hata = fiyatGecmisi.GetFiyatGecmisi(g.kod, g.baslangic)
+	if g.hata != nil {
+		logger.Errorf("Grafik verisi yüklenemedi: %v", g.hata)
+		return
+	}
+
+	// Aralık başındaki fiyatı bilmek için önceki son kaydı da ekle
+	if !g.baslangic.IsZero() {
+		onceki, err := fiyatGecmisi.GetFiyatAt(g.kod, g.baslangic)
+		if err == nil && onceki != nil {
+			g.kayitlar = append([]models.FiyatGecmisi{*onceki}, g.kayitlar...)
+		}
+	} else if len(g.kayitlar) > 0 {
+		g.baslangic = g.kayitlar[0].CekilmeZamani
+	}
+
+	g.SetTitle(fmt.Sprintf(" 📈 %s (%s) - %s  [1:1G 2:1H 3:1A 4:1Y 5:Tümü, ←/→: Aralık, ESC: Kapat] ",
+		getCinsNameFromCode(g.kod), g.kod, grafikAraliklari[g.aralikIndex].Etiket))
+}
+
+// setAralik aralığı değiştirir ve veriyi yeniden yükler
+func (g *fiyatGrafigi) setAralik(index int) {
+	if index < 0 || index >= len(grafikAraliklari) {
+		return
+	}
+	g.aralikIndex = index
+	g.yukle()
+}
+
+// InputHandler aralık seçimi için klavye girişlerini işler
+func (g *fiyatGrafigi) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return g.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			g.setAralik(g.aralikIndex - 1)
+			return
+		case tcell.KeyRight:
+			g.setAralik(g.aralikIndex + 1)
+			return
+		}
+		if r := event.Rune(); r >= '1' && r <= '5' {
+			g.setAralik(int(r - '1'))
+		}
+	})
+}
+
+// fiyatAt adım fonksiyonu olarak t anındaki fiyatı döner (kayıtlar sadece değişimleri tutar)
+func (g *fiyatGrafigi) fiyatAt(t time.Time) (float64, bool) {
+	fiyat, bulundu := 0.0, false
+	for _, kayit := range g.kayitlar {
+		if kayit.CekilmeZamani.After(t) {
+			break
+		}
+		fiyat, bulundu = services.DegerlemeFiyati(decimal.NewFromFloat(kayit.Alis), decimal.NewFromFloat(kayit.Satis), g.degerlemeModu).InexactFloat64(), true
+	}
+	return fiyat, bulundu
+}
+
+// Draw grafiği çizer
+func (g *fiyatGrafigi) Draw(screen tcell.Screen) {
+	g.Box.DrawForSubclass(screen, g)
+	x, y, width, height := g.GetInnerRect()
+
+	const eksenGenisligi = 14
+	grafikX, grafikW := x+eksenGenisligi, width-eksenGenisligi
+	grafikY, grafikH := y, height-2 // Alt iki satır: zaman ekseni ve açıklama
+	if grafikW < 10 || grafikH < 3 {
+		return
+	}
+
+	if g.hata != nil {
+		tview.Print(screen, fmt.Sprintf("HATA: %v", g.hata), x, y, width, tview.AlignCenter, tcell.ColorRed)
+		return
+	}
+	if len(g.kayitlar) == 0 {
+		tview.Print(screen, "Bu aralıkta fiyat geçmişi yok", x, y+height/2, width, tview.AlignCenter, tcell.ColorYellow)
+		return
+	}
+
+	// Her sütun için adım fonksiyonundan fiyatı örnekle
+	sure := g.bitis.Sub(g.baslangic)
+	degerler := make([]float64, grafikW)
+	dolu := make([]bool, grafikW)
+	minDeger, maxDeger := 0.0, 0.0
+	ilk := true
+	for col := 0; col < grafikW; col++ {
+		t := g.baslangic.Add(time.Duration(float64(sure) * float64(col) / float64(grafikW-1)))
+		fiyat, bulundu := g.fiyatAt(t)
+		if !bulundu {
+			continue
+		}
+		degerler[col], dolu[col] = fiyat, true
+		if ilk || fiyat < minDeger {
+			minDeger = fiyat
+		}
+		if ilk || fiyat > maxDeger {
+			maxDeger = fiyat
+		}
+		ilk = false
+	}
+	if ilk {
+		tview.Print(screen, "Bu aralıkta fiyat geçmişi yok", x, y+height/2, width, tview.AlignCenter, tcell.ColorYellow)
+		return
+	}
+	sonFiyat := degerler[grafikW-1]
+
+	// Referans çizgisi görünür olsun diye ölçeğe dahil et
+	if g.referans > 0 {
+		if g.referans < minDeger {
+			minDeger = g.referans
+		}
+		if g.referans > maxDeger {
+			maxDeger = g.referans
+		}
+	}
+	if maxDeger == minDeger {
+		maxDeger += 1
+		minDeger -= 1
+	}
+
+	satirAt := func(deger float64) int {
+		oran := (deger - minDeger) / (maxDeger - minDeger)
+		return grafikY + grafikH - 1 - int(oran*float64(grafikH-1)+0.5)
+	}
+
+	// Y ekseni etiketleri (üst, orta, alt)
+	for _, deger := range []float64{maxDeger, (maxDeger + minDeger) / 2, minDeger} {
+		tview.Print(screen, format.Money(decimal.NewFromFloat(deger)), x, satirAt(deger), eksenGenisligi-1, tview.AlignRight, tcell.ColorGray)
+	}
+	for row := grafikY; row < grafikY+grafikH; row++ {
+		screen.SetContent(grafikX-1, row, '│', nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
+	}
+
+	// Referans (alış fiyatı) çizgisi
+	if g.referans > 0 {
+		refSatir := satirAt(g.referans)
+		for col := 0; col < grafikW; col++ {
+			screen.SetContent(grafikX+col, refSatir, '─', nil, tcell.StyleDefault.Foreground(tcell.ColorYellow))
+		}
+	}
+
+	// Fiyat çizgisi: ardışık sütunlar arasındaki boşluk dikey çizgiyle bağlanır
+	cizgiRengi := tcell.ColorGreen
+	if g.referans > 0 && sonFiyat < g.referans {
+		cizgiRengi = tcell.ColorRed
+	}
+	stil := tcell.StyleDefault.Foreground(cizgiRengi)
+	oncekiSatir := -1
+	for col := 0; col < grafikW; col++ {
+		if !dolu[col] {
+			continue
+		}
+		satir := satirAt(degerler[col])
+		if oncekiSatir >= 0 && oncekiSatir != satir {
+			ust, alt := oncekiSatir, satir
+			if ust > alt {
+				ust, alt = alt, ust
+			}
+			for r := ust; r <= alt; r++ {
+				screen.SetContent(grafikX+col, r, '│', nil, stil)
+			}
+		}
+		screen.SetContent(grafikX+col, satir, '•', nil, stil)
+		oncekiSatir = satir
+	}
+
+	// Zaman ekseni
+	zamanFormati := "02.01.2006"
+	if sure <= 48*time.Hour {
+		zamanFormati = "02.01 15:04"
+	}
+	eksenY := grafikY + grafikH
+	tview.Print(screen, g.baslangic.Format(zamanFormati), grafikX, eksenY, grafikW, tview.AlignLeft, tcell.ColorGray)
+	tview.Print(screen, g.bitis.Format(zamanFormati), grafikX, eksenY, grafikW, tview.AlignRight, tcell.ColorGray)
+
+	// Açıklama
+	aciklama := fmt.Sprintf("[green]• Fiyat[-] Son: %s ₺  Min: %s ₺  Maks: %s ₺", format.Money(decimal.NewFromFloat(sonFiyat)), format.Money(decimal.NewFromFloat(minDeger)), format.Money(decimal.NewFromFloat(maxDeger)))
+	if g.referans > 0 {
+		aciklama += fmt.Sprintf("   [yellow]── Alış fiyatı: %s ₺[-]", format.Money(decimal.NewFromFloat(g.referans)))
+	}
+	tview.Print(screen, aciklama, x, eksenY+1, width, tview.AlignCenter, tcell.ColorWhite)
+}
+
+// showGrafik envanter veya grup tablosunda seçili kodun fiyat grafiğini açar
+func (a *App) showGrafik() {
+	returnFocus := a.app.GetFocus()
+
+	var kod string
+	var referans float64
+	if returnFocus == a.grupTable {
+		row, _ := a.grupTable.GetSelection()
+		if row <= 0 || row-1 >= len(a.grupKodlari) {
+			a.showMessage("Lütfen grafik için bir grup seçin!")
+			return
+		}
+		kod = a.grupKodlari[row-1]
+
+		if a.gorunum != nil {
+			if grup, exists := a.gorunum.Gruplar[kod]; exists {
+				if ortalama, ok := grup["ortalama_alis_fiyati"].(decimal.Decimal); ok {
+					referans = ortalama.InexactFloat64()
+				}
+			}
+		}
+	} else {
+		row, _ := a.table.GetSelection()
+		envanter, ok := a.satirEnvanteri(row)
+		if !ok {
+			a.showMessage("Lütfen grafik için bir kayıt seçin!")
+			return
+		}
+		kod = envanter.Kod
+		referans = envanter.AlisFiyati.InexactFloat64()
+	}
+
+	if kod == "" {
+		a.showMessage("Seçili kaydın ürün kodu yok, grafik çizilemez!")
+		return
+	}
+
+	grafik := newFiyatGrafigi(kod, referans)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(grafik, 24, 1, true).
+			AddItem(nil, 0, 1, false), 120, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.grafikReturnFocus = returnFocus
+	a.pages.AddPage("grafik", modal, true, true)
+	a.app.SetFocus(grafik)
+}
@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"altintakip/internal/format"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// iceAktarimFormatlari içe aktarım formundaki sayı/tarih formatı seçenekleri
+var iceAktarimFormatlari = []struct {
+	isim string
+	kod  string
+}{
+	{"Türkçe (1.234,56 - 02.01.2006)", services.IceAktarimTR},
+	{"İngilizce (1,234.56 - 2006-01-02)", services.IceAktarimEN},
+}
+
+// showImportForm CSV içe aktarım formunu gösterir
+func (a *App) showImportForm() {
+	formatIsimleri := make([]string, len(iceAktarimFormatlari))
+	for i, secenek := range iceAktarimFormatlari {
+		formatIsimleri[i] = secenek.isim
+	}
+
+	form := tview.NewForm()
+	form.AddInputField("CSV Dosyası", "", 50, nil, nil)
+	form.AddDropDown("Format", formatIsimleri, 0, nil)
+	form.AddInputField("Sütunlar (opsiyonel)", "", 50, nil, nil)
+
+	form.AddButton("Önizle", func() {
+		yol := strings.TrimSpace(form.GetFormItem(0).(*tview.InputField).GetText())
+		formatIndex, _ := form.GetFormItem(1).(*tview.DropDown).GetCurrentOption()
+		sutunMetni := form.GetFormItem(2).(*tview.InputField).GetText()
+		if yol == "" || formatIndex < 0 {
+			a.showMessageWithReturn("Lütfen dosya yolu ve format seçin!", form)
+			return
+		}
+
+		eslesme, err := services.SutunEslesmesiCoz(sutunMetni)
+		if err != nil {
+			a.showMessageWithReturn(err.Error(), form)
+			return
+		}
+
+		dosya, err := os.Open(yol)
+		if err != nil {
+			a.showMessageWithReturn(fmt.Sprintf("Dosya açılamadı: %v", err), form)
+			return
+		}
+		defer dosya.Close()
+
+		onizleme, err := a.iceAktarimServisi().Onizle(dosya, iceAktarimFormatlari[formatIndex].kod, eslesme)
+		if err != nil {
+			a.showMessageWithReturn(fmt.Sprintf("Önizleme başarısız: %v", err), form)
+			return
+		}
+
+		a.showImportPreview(onizleme)
+	})
+	form.AddButton("İptal", func() {
+		a.pages.RemovePage("import-form")
+		a.app.ForceDraw()
+		a.app.SetFocus(a.table)
+	})
+
+	form.SetTitle(" 📥 CSV İÇE AKTAR (Sütunlar: kod=Ürün,miktar=Adet,fiyat=Fiyat,tarih=Tarih) ").SetBorder(true)
+	form.SetBackgroundColor(tcell.ColorBlack)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 11, 1, true).
+			AddItem(nil, 0, 1, false), 90, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("import-form", modal, true, true)
+	a.app.SetFocus(form)
+}
+
+// showImportPreview ayrıştırılan satırları durumlarıyla gösterir ve içe aktarımı onaylatır
+func (a *App) showImportPreview(onizleme *services.IceAktarimOnizleme) {
+	table := tview.NewTable().SetBorders(false).SetSelectable(true, false).SetFixed(1, 0)
+
+	basliklar := []string{"SATIR", "KOD", "CİNS", "MİKTAR", "BİRİM", "ALIŞ TARİHİ", "ALIŞ FİYATI ₺", "DURUM"}
+	for col, baslik := range basliklar {
+		table.SetCell(0, col, tview.NewTableCell(baslik).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold))
+	}
+
+	for i, satir := range onizleme.Satirlar {
+		row := i + 1
+		e := satir.Envanter
+
+		durum, renk := "OK", tcell.ColorGreen
+		if satir.Hata != "" {
+			durum, renk = "HATA: "+satir.Hata, tcell.ColorRed
+		} else if satir.OlasiKopya {
+			durum, renk = "KOPYA? "+satir.KopyaNotu, tcell.ColorOrange
+		}
+
+		tarih := ""
+		if !e.AlisTarihi.IsZero() {
+			tarih = e.AlisTarihi.Format("02.01.2006")
+		}
+
+		table.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf("%d", satir.Satir)))
+		table.SetCell(row, 1, tview.NewTableCell(e.Kod))
+		table.SetCell(row, 2, tview.NewTableCell(e.Cins))
+		table.SetCell(row, 3, tview.NewTableCell(format.Quantity(e.Miktar, e.Birim)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 4, tview.NewTableCell(e.Birim))
+		table.SetCell(row, 5, tview.NewTableCell(tarih))
+		table.SetCell(row, 6, tview.NewTableCell(format.Money(e.AlisFiyati)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 7, tview.NewTableCell(durum).SetTextColor(renk))
+	}
+
+	hataSayisi := onizleme.HataSayisi()
+	kopyaSayisi := onizleme.KopyaSayisi()
+	ozet := fmt.Sprintf("%d satır, %d olası kopya, %d hatalı", len(onizleme.Satirlar), kopyaSayisi, hataSayisi)
+	if hataSayisi > 0 {
+		ozet += " - hatalı satırlar düzeltilmeden içe aktarım yapılamaz"
+	}
+
+	kaydet := func(kopyalariDahilEt bool) {
+		eklenen, err := a.iceAktarimServisi().Kaydet(onizleme, kopyalariDahilEt, a.isListMode)
+		if err != nil {
+			a.showMessageWithReturn(fmt.Sprintf("İçe aktarım başarısız: %v", err), table)
+			return
+		}
+		a.pages.RemovePage("import-form")
+		a.refreshDataAndCloseForm("import-preview", fmt.Sprintf("%d kayıt içe aktarıldı", eklenen))
+	}
+
+	butonlar := tview.NewForm().SetButtonsAlign(tview.AlignCenter)
+	if hataSayisi == 0 {
+		butonlar.AddButton("İçe Aktar (kopyalar hariç)", func() { kaydet(false) })
+		if kopyaSayisi > 0 {
+			butonlar.AddButton("Kopyalarla Birlikte", func() { kaydet(true) })
+		}
+	}
+	butonlar.AddButton("Geri", func() {
+		a.closeImportPreview()
+	})
+	butonlar.SetBackgroundColor(tcell.ColorBlack)
+
+	icerik := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(tview.NewTextView().
+			SetText(ozet+" (Tab: Butonlar, Shift+Tab: Tablo)").
+			SetTextAlign(tview.AlignCenter).
+			SetTextColor(tcell.ColorYellow), 1, 0, false).
+		AddItem(butonlar, 3, 0, false)
+	icerik.SetBorder(true).SetTitle(" 📥 İÇE AKTARIM ÖNİZLEME ")
+
+	// Tab tablodan butonlara, Shift+Tab butonlardan tabloya geçer
+	icerik.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyTab && table.HasFocus() {
+			a.app.SetFocus(butonlar)
+			return nil
+		}
+		if event.Key() == tcell.KeyBacktab && butonlar.HasFocus() {
+			a.app.SetFocus(table)
+			return nil
+		}
+		return event
+	})
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(icerik, 24, 1, true).
+			AddItem(nil, 0, 1, false), 130, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("import-preview", modal, true, true)
+	a.app.SetFocus(table)
+}
+
+// closeImportPreview önizlemeyi kapatıp içe aktarım formuna döner
+func (a *App) closeImportPreview() {
+	a.pages.RemovePage("import-preview")
+	a.app.ForceDraw()
+	if _, form := a.pages.GetFrontPage(); form != nil {
+		a.app.SetFocus(form)
+	}
+}
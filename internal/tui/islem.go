@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"fmt"
+
+	"altintakip/internal/format"
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/shopspring/decimal"
+)
+
+// selectedKod envanter veya grup tablosunda seçili satırın ürün kodunu döner
+func (a *App) selectedKod() (string, error) {
+	if a.app.GetFocus() == a.grupTable {
+		row, _ := a.grupTable.GetSelection()
+		if row <= 0 || row-1 >= len(a.grupKodlari) {
+			return "", fmt.Errorf("lütfen bir grup seçin")
+		}
+		return a.grupKodlari[row-1], nil
+	}
+
+	row, _ := a.table.GetSelection()
+	if row <= 0 {
+		return "", fmt.Errorf("lütfen bir kayıt seçin")
+	}
+	envanter, ok := a.satirEnvanteri(row)
+	if !ok {
+		return "", fmt.Errorf("kayıt bulunamadı")
+	}
+	return envanter.Kod, nil
+}
+
+// showDisposeForm seçili kod için satış / hediye çıkışı formunu gösterir.
+// Tüketilecek lotlar kodun maliyet yöntemine göre seçilir.
+func (a *App) showDisposeForm() {
+	returnFocus := a.app.GetFocus()
+	kod, err := a.selectedKod()
+	if err != nil {
+		a.showMessage(fmt.Sprintf("Satış/çıkış yapılamadı: %v", err))
+		return
+	}
+
+	envanterService := a.envanterServisi()
+	envanterler, err := envanterService.GetAllEnvanterFromDB()
+	if err != nil {
+		a.showMessage(fmt.Sprintf("Kayıtlar okunamadı: %v", err))
+		return
+	}
+
+	// Kodun açık lotlarındaki toplam miktar ve güncel fiyat
+	var toplamMiktar, guncelFiyat decimal.Decimal
+	var ornek models.Envanter
+	for _, envanter := range envanterler {
+		if envanter.Kod != kod {
+			continue
+		}
+		toplamMiktar = toplamMiktar.Add(envanter.Miktar)
+		if envanter.GuncelFiyat.IsPositive() {
+			guncelFiyat = envanter.GuncelFiyat
+		}
+		ornek = envanter
+	}
+	if !toplamMiktar.IsPositive() {
+		a.showMessage(fmt.Sprintf("%s için kalan miktar yok!", kod))
+		return
+	}
+
+	yontem, err := envanterService.GetMaliyetYontemi(kod)
+	if err != nil {
+		a.showMessage(fmt.Sprintf("Maliyet yöntemi okunamadı: %v", err))
+		return
+	}
+
+	// Varsayılan olarak tüm kalan miktar ve güncel fiyat önerilir
+	miktarStr := toplamMiktar.String()
+	fiyatStr := ""
+	if guncelFiyat.IsPositive() {
+		fiyatStr = guncelFiyat.StringFixed(2)
+	}
+
+	cikisTipiDropdown := tview.NewDropDown().
+		SetLabel("İşlem Tipi").
+		SetOptions(cikisTipiOptions, nil).
+		SetCurrentOption(0)
+
+	form := tview.NewForm()
+	form.AddFormItem(cikisTipiDropdown)
+	form.AddInputField(fmt.Sprintf("Miktar (kalan %s %s)", format.Quantity(toplamMiktar, ornek.Birim), ornek.Birim), miktarStr, 20, nil, nil)
+	form.AddInputField("Tarih", "", 20, nil, nil)
+	form.AddInputField("Satış Fiyatı", fiyatStr, 20, nil, nil)
+	form.AddInputField("Not (opsiyonel)", "", 40, nil, nil)
+
+	form.AddButton("Kaydet", func() {
+		a.saveDisposeForm(form, kod, returnFocus)
+	})
+	form.AddButton("İptal", func() {
+		a.pages.RemovePage("dispose-form")
+		a.app.ForceDraw()
+		a.app.SetFocus(returnFocus)
+	})
+
+	cinsIsmi := getCinsNameFromCode(kod)
+	if cinsIsmi == "" {
+		cinsIsmi = ornek.Cins
+	}
+	form.SetTitle(fmt.Sprintf(" 💸 SATIŞ / ÇIKIŞ: %s - %s (Yöntem: %s, Tarih boşsa bugün) ",
+		ornek.Tur, cinsIsmi, models.MaliyetYontemiIsmi(yontem))).SetBorder(true)
+	form.SetBackgroundColor(tcell.ColorBlack)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 16, 1, true).
+			AddItem(nil, 0, 1, false), 90, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("dispose-form", modal, true, true)
+	a.app.SetFocus(form)
+}
+
+// saveDisposeForm satış / hediye çıkışı formunu doğrular ve kaydeder
+func (a *App) saveDisposeForm(form *tview.Form, kod string, returnFocus tview.Primitive) {
+	tipIndex, _ := form.GetFormItem(0).(*tview.DropDown).GetCurrentOption()
+	miktarStr := form.GetFormItem(1).(*tview.InputField).GetText()
+	tarihStr := form.GetFormItem(2).(*tview.InputField).GetText()
+	fiyatStr := form.GetFormItem(3).(*tview.InputField).GetText()
+	notlar := form.GetFormItem(4).(*tview.InputField).GetText()
+
+	tip := models.IslemSatis
+	if tipIndex == 1 {
+		tip = models.IslemHediyeCikis
+	}
+
+	miktar := format.ParsePrice(miktarStr)
+	if !miktar.IsPositive() {
+		a.showMessageWithReturn("Miktar sıfırdan büyük bir sayı olmalı!", form)
+		return
+	}
+
+	fiyat := format.ParsePrice(fiyatStr)
+	if tip == models.IslemSatis && !fiyat.IsPositive() {
+		a.showMessageWithReturn("Satış fiyatı sıfırdan büyük bir sayı olmalı!", form)
+		return
+	}
+
+	tarih, err := a.parseAlisTarihi(tarihStr)
+	if err != nil {
+		a.showMessageWithReturn("Tarih geçerli formatta olmalı! (GG.AA.YYYY)", form)
+		return
+	}
+
+	envanterService := a.envanterServisi()
+	islemler, err := envanterService.DisposeByKod(kod, tip, miktar, fiyat, tarih, notlar)
+	if err != nil {
+		a.showMessageWithReturn(fmt.Sprintf("İşlem başarısız: %v", err), form)
+		return
+	}
+
+	mesaj := fmt.Sprintf("%s kaydedildi (%d lot).", models.IslemTipleri[tip], len(islemler))
+	if tip == models.IslemSatis {
+		karZarar := decimal.Zero
+		for _, islem := range islemler {
+			karZarar = karZarar.Add(islem.GerceklesenKarZarar)
+		}
+		mesaj = fmt.Sprintf("Satış kaydedildi (%d lot). Gerçekleşen kar/zarar: %s ₺", len(islemler), format.Money(karZarar))
+	}
+	a.refreshDataAndCloseForm("dispose-form", mesaj)
+	a.app.SetFocus(returnFocus)
+}
+
+// cycleMaliyetYontemi grup tablosunda seçili kodun maliyet yöntemini sıradakine geçirir
+func (a *App) cycleMaliyetYontemi() {
+	kod, err := a.selectedKod()
+	if err != nil {
+		a.showMessage(fmt.Sprintf("Maliyet yöntemi değiştirilemedi: %v", err))
+		return
+	}
+
+	envanterService := a.envanterServisi()
+	mevcut, err := envanterService.GetMaliyetYontemi(kod)
+	if err != nil {
+		a.showMessage(fmt.Sprintf("Maliyet yöntemi okunamadı: %v", err))
+		return
+	}
+
+	yeni := services.SonrakiMaliyetYontemi(mevcut)
+	if err := envanterService.SetMaliyetYontemi(kod, yeni); err != nil {
+		a.showMessage(fmt.Sprintf("Maliyet yöntemi kaydedilemedi: %v", err))
+		return
+	}
+
+	a.showMessageWithReturn(fmt.Sprintf("%s için maliyet yöntemi: %s\n\nSonraki satışlar bu yönteme göre lot tüketecek.",
+		kod, models.MaliyetYontemiIsmi(yeni)), a.grupTable)
+}
+
+// loadGerceklesenData her satış/çıkış için gerçekleşen kar/zarar panelini yükler
+func (a *App) loadGerceklesenData() {
+	envanterService := a.envanterServisi()
+	cikislar, err := envanterService.GetGerceklesenCikislar()
+	if err != nil {
+		logger.Errorf("Gerçekleşen kar/zarar verileri yüklenemedi: %v", err)
+		return
+	}
+
+	a.gerceklesenTable.Clear()
+
+	headers := []string{"TARİH", "CİNS", "İŞLEM", "YÖNTEM", "MİKTAR", "TUTAR ₺", "MALİYET ₺", "K/Z ₺"}
+	for col, header := range headers {
+		a.gerceklesenTable.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false))
+	}
+
+	if len(cikislar) == 0 {
+		a.gerceklesenTable.SetCell(1, 0, tview.NewTableCell("Henüz satış yok").
+			SetTextColor(tcell.ColorYellow))
+		return
+	}
+
+	for i, cikis := range cikislar {
+		row := i + 1
+		karZararColor := tcell.ColorGreen
+		karZararPrefix := "+"
+		if cikis.KarZarar.IsNegative() {
+			karZararColor = tcell.ColorRed
+			karZararPrefix = ""
+		}
+
+		yontem := "-"
+		if cikis.Yontem != "" {
+			yontem = models.MaliyetYontemiIsmi(cikis.Yontem)
+		}
+
+		a.gerceklesenTable.SetCell(row, 0, tview.NewTableCell(cikis.Tarih.Format("02.01.2006")))
+		a.gerceklesenTable.SetCell(row, 1, tview.NewTableCell(getCinsNameFromCode(cikis.Kod)))
+		a.gerceklesenTable.SetCell(row, 2, tview.NewTableCell(models.IslemTipleri[cikis.Tip]))
+		a.gerceklesenTable.SetCell(row, 3, tview.NewTableCell(yontem))
+		a.gerceklesenTable.SetCell(row, 4, tview.NewTableCell(format.TurkishNumber(cikis.Miktar.StringFixed(2))).
+			SetAlign(tview.AlignRight))
+		a.gerceklesenTable.SetCell(row, 5, tview.NewTableCell(format.Money(cikis.Tutar)).SetAlign(tview.AlignRight))
+		a.gerceklesenTable.SetCell(row, 6, tview.NewTableCell(format.Money(cikis.Maliyet)).SetAlign(tview.AlignRight))
+		a.gerceklesenTable.SetCell(row, 7, tview.NewTableCell(fmt.Sprintf("%s%s", karZararPrefix, format.Money(cikis.KarZarar))).
+			SetTextColor(karZararColor).
+			SetAlign(tview.AlignRight))
+	}
+
+	logger.Debugf("Gerçekleşen kar/zarar paneli hazırlandı")
+}
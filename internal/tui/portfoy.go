@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"fmt"
+
+	"altintakip/internal/logger"
+	"altintakip/internal/models"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// tumPortfoylerSecenegi portföy seçicide birleşik görünümü temsil eden satır
+const tumPortfoylerSecenegi = "🗂️  Tüm Portföyler"
+
+// envanterServisi seçili portföyle sınırlanmış envanter servisi oluşturur
+func (a *App) envanterServisi() *services.EnvanterService {
+	envanterService := services.NewEnvanterService()
+	envanterService.SetPortfoy(a.portfoyID)
+	return envanterService
+}
+
+// disaAktarimServisi seçili portföyle sınırlanmış dışa aktarım servisi oluşturur
+func (a *App) disaAktarimServisi() *services.DisaAktarimService {
+	disaAktarim := services.NewDisaAktarimService()
+	disaAktarim.SetPortfoy(a.portfoyID)
+	return disaAktarim
+}
+
+// iceAktarimServisi kayıtları seçili portföye (tüm portföy görünümünde varsayılana) ekleyen servis oluşturur
+func (a *App) iceAktarimServisi() *services.IceAktarimService {
+	iceAktarim := services.NewIceAktarimService()
+	iceAktarim.SetPortfoy(a.portfoyID)
+	return iceAktarim
+}
+
+// portfoyleriYukle portföy listesini ve ID -> ad eşlemesini yeniler
+func (a *App) portfoyleriYukle() {
+	portfoylar, err := services.NewPortfoyService().GetPortfoyler()
+	if err != nil {
+		logger.Errorf("Portföyler yüklenemedi: %v", err)
+		return
+	}
+	a.portfoylar = portfoylar
+}
+
+// portfoyAdi ID'si verilen portföyün adını döner
+func (a *App) portfoyAdi(id uint) string {
+	for _, portfoy := range a.portfoylar {
+		if portfoy.ID == id {
+			return portfoy.Ad
+		}
+	}
+	return fmt.Sprintf("#%d", id)
+}
+
+// portfoySutunuGoster tüm portföyler görünümünde birden fazla portföy varsa envanterde PORTFÖY sütununu açar
+func (a *App) portfoySutunuGoster() bool {
+	return a.portfoyID == 0 && len(a.portfoylar) > 1
+}
+
+// envanterBasligi envanter tablosu başlığını seçili portföye göre ayarlar
+func (a *App) envanterBasligi() {
+	if a.portfoyID == 0 {
+		a.table.SetTitle(" 📊 ENVANTER - Tüm Portföyler (P: Portföy) ")
+		return
+	}
+	a.table.SetTitle(fmt.Sprintf(" 📊 ENVANTER - %s (P: Portföy) ", a.portfoyAdi(a.portfoyID)))
+}
+
+// portfoySec görünümü seçilen portföye (0: tüm portföyler) geçirir ve tabloları yeniler
+func (a *App) portfoySec(portfoyID uint) {
+	a.portfoyID = portfoyID
+	a.envanterBasligi()
+	a.clearAllTables()
+	a.anaTablolariYenile()
+	a.app.SetFocus(a.table)
+	if a.table.GetRowCount() > 1 {
+		a.table.Select(1, 0)
+	}
+}
+
+// portfoyDropdown ekleme/düzenleme formları için portföy seçimi oluşturur
+func (a *App) portfoyDropdown(seciliID uint) *tview.DropDown {
+	isimler := make([]string, len(a.portfoylar))
+	secili := 0
+	for i, portfoy := range a.portfoylar {
+		isimler[i] = portfoy.Ad
+		if portfoy.ID == seciliID {
+			secili = i
+		}
+	}
+	return tview.NewDropDown().
+		SetLabel("Portföy").
+		SetOptions(isimler, nil).
+		SetCurrentOption(secili)
+}
+
+// secilenPortfoyID portföy dropdown'unda seçili portföyün ID'sini döner
+func (a *App) secilenPortfoyID(dropdown *tview.DropDown) uint {
+	index, _ := dropdown.GetCurrentOption()
+	if index < 0 || index >= len(a.portfoylar) {
+		return models.VarsayilanPortfoyID
+	}
+	return a.portfoylar[index].ID
+}
+
+// showPortfoySecici portföy değiştirme listesini gösterir
+func (a *App) showPortfoySecici() {
+	a.portfoyleriYukle()
+
+	liste := tview.NewList().ShowSecondaryText(false)
+	liste.AddItem(tumPortfoylerSecenegi, "", 0, func() {
+		a.pages.RemovePage("portfoy-secici")
+		a.portfoySec(0)
+	})
+	secili := 0
+	for i, portfoy := range a.portfoylar {
+		portfoyID := portfoy.ID
+		liste.AddItem(portfoy.Ad, "", 0, func() {
+			a.pages.RemovePage("portfoy-secici")
+			a.portfoySec(portfoyID)
+		})
+		if portfoyID == a.portfoyID {
+			secili = i + 1
+		}
+	}
+	liste.AddItem("➕ Yeni Portföy", "", 0, func() {
+		a.pages.RemovePage("portfoy-secici")
+		a.showPortfoyForm()
+	})
+	liste.SetCurrentItem(secili)
+
+	liste.SetTitle(" 🗂️  PORTFÖY SEÇ (Enter: Seç, ESC: Kapat) ").SetBorder(true)
+	liste.SetBackgroundColor(tcell.ColorBlack)
+
+	yukseklik := len(a.portfoylar) + 4
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(liste, yukseklik, 1, true).
+			AddItem(nil, 0, 1, false), 50, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("portfoy-secici", modal, true, true)
+	a.app.SetFocus(liste)
+}
+
+// showPortfoyForm yeni portföy ekleme formunu gösterir; eklenen portföye geçilir
+func (a *App) showPortfoyForm() {
+	form := tview.NewForm()
+	form.AddInputField("Ad", "", 30, nil, nil)
+	form.AddInputField("Notlar", "", 30, nil, nil)
+
+	form.AddButton("Kaydet", func() {
+		ad := form.GetFormItem(0).(*tview.InputField).GetText()
+		notlar := form.GetFormItem(1).(*tview.InputField).GetText()
+
+		portfoy, err := services.NewPortfoyService().AddPortfoy(ad, notlar)
+		if err != nil {
+			a.showMessageWithReturn(fmt.Sprintf("Portföy eklenemedi: %v", err), form)
+			return
+		}
+
+		a.pages.RemovePage("portfoy-form")
+		a.portfoyleriYukle()
+		a.portfoySec(portfoy.ID)
+	})
+	form.AddButton("İptal", func() {
+		a.pages.RemovePage("portfoy-form")
+		a.app.ForceDraw()
+		a.app.SetFocus(a.table)
+	})
+
+	form.SetTitle(" ➕ YENİ PORTFÖY ").SetBorder(true)
+	form.SetBackgroundColor(tcell.ColorBlack)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 9, 1, true).
+			AddItem(nil, 0, 1, false), 60, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("portfoy-form", modal, true, true)
+	a.app.SetFocus(form)
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+
+	"altintakip/internal/format"
+	"altintakip/internal/services"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showRapor portföy değerinin haftalık ve aylık değişimini gösterir
+func (a *App) showRapor() {
+	haftalik := a.newDonemTable(" 📅 HAFTALIK DEĞİŞİM ", services.DonemHafta, 12)
+	aylik := a.newDonemTable(" 🗓️ AYLIK DEĞİŞİM ", services.DonemAy, 12)
+
+	icerik := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).
+			AddItem(haftalik, 0, 1, true).
+			AddItem(aylik, 0, 1, false), 0, 1, true).
+		AddItem(tview.NewTextView().
+			SetText("Tab: Tablolar Arası Geçiş, ESC: Kapat").
+			SetTextAlign(tview.AlignCenter).
+			SetTextColor(tcell.ColorYellow), 1, 0, false)
+
+	// Sayfa içinde Tab ile iki tablo arasında geçiş
+	icerik.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyTab {
+			if haftalik.HasFocus() {
+				a.app.SetFocus(aylik)
+			} else {
+				a.app.SetFocus(haftalik)
+			}
+			return nil
+		}
+		return event
+	})
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(icerik, 20, 1, true).
+			AddItem(nil, 0, 1, false), 150, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("rapor", modal, true, true)
+	a.app.SetFocus(haftalik)
+}
+
+// newDonemTable dönemsel değişim tablosunu oluşturur
+func (a *App) newDonemTable(title, donemTipi string, adet int) *tview.Table {
+	table := tview.NewTable()
+	table.SetBorders(true)
+	table.SetSelectable(true, false)
+	table.SetFixed(1, 0)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack))
+	table.SetTitle(title)
+	table.SetBorderColor(tcell.ColorGreen)
+	table.SetBackgroundColor(tcell.ColorBlack)
+
+	headers := []string{"DÖNEM", "SON GÜN", "DEĞER ₺", "DEĞİŞİM ₺", "DEĞİŞİM %"}
+	for col, header := range headers {
+		table.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false))
+	}
+
+	donemler, err := services.NewPortfoyGecmisiService().GetDonemselDegisimler(donemTipi, adet)
+	if err != nil {
+		table.SetCell(1, 0, tview.NewTableCell(fmt.Sprintf("HATA: %v", err)).SetTextColor(tcell.ColorRed))
+		return table
+	}
+	if len(donemler) == 0 {
+		table.SetCell(1, 0, tview.NewTableCell("Henüz günlük özet yok").SetTextColor(tcell.ColorYellow))
+		return table
+	}
+
+	// En yeni dönem en üstte
+	for i := range donemler {
+		donem := donemler[len(donemler)-1-i]
+		row := i + 1
+
+		degisimColor := tcell.ColorGreen
+		degisimPrefix := "+"
+		if donem.Degisim.IsNegative() {
+			degisimColor = tcell.ColorRed
+			degisimPrefix = ""
+		}
+
+		table.SetCell(row, 0, tview.NewTableCell(donem.Donem))
+		table.SetCell(row, 1, tview.NewTableCell(donem.SonGun))
+		table.SetCell(row, 2, tview.NewTableCell(format.Money(donem.GuncelTutar)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(fmt.Sprintf("%s%s", degisimPrefix, format.Money(donem.Degisim))).
+			SetTextColor(degisimColor).
+			SetAlign(tview.AlignRight))
+		table.SetCell(row, 4, tview.NewTableCell(fmt.Sprintf("%s%s%%", degisimPrefix, donem.DegisimYuzde.StringFixed(2))).
+			SetTextColor(degisimColor).
+			SetAlign(tview.AlignRight))
+	}
+
+	return table
+}